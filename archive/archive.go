@@ -0,0 +1,302 @@
+// Package archive backs up raw github events, so they can be re-published (replayed) later.
+//
+// Events are stored as gzip compressed NDJSON (one github.Event per line) objects,
+// partitioned by the hour the webhook received them: YYYY/MM/DD/HH/<unix nano>-<random>.ndjson.gz
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/athenianco/metadata/github"
+)
+
+const (
+	partitionLayout = "2006/01/02/15/"
+	dayLayout       = "2006/01/02/"
+)
+
+// DefaultMaxBuffered is the default for how many events the archiver buffers while the storage fails.
+const DefaultMaxBuffered = 10000
+
+// Archiver buffers events and writes them to the storage in batches.
+type Archiver struct {
+	// BatchSize is the number of events which triggers the flush.
+	// Cloud Functions should use 1, because buffered events are lost when the instance is stopped.
+	BatchSize int
+	// MaxBuffered limits events kept in the batch while flushes fail. The oldest events over the limit
+	// are dropped (and logged), so a failing storage doesn't grow the memory and every retried flush.
+	MaxBuffered int
+
+	storage Storage
+
+	mu    sync.Mutex
+	batch []*github.Event
+}
+
+// NewArchiver creates a new archiver which writes to the given storage.
+func NewArchiver(storage Storage, batchSize int) *Archiver {
+	return &Archiver{
+		BatchSize:   batchSize,
+		MaxBuffered: DefaultMaxBuffered,
+		storage:     storage,
+	}
+}
+
+// Archive adds the event to the current batch and flushes the batch if it is full.
+// The oldest events are dropped if the batch exceeds MaxBuffered (the storage has been failing).
+func (a *Archiver) Archive(ctx context.Context, event *github.Event) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.batch = append(a.batch, event)
+	if n := len(a.batch) - a.MaxBuffered; a.MaxBuffered > 0 && n > 0 {
+		for _, e := range a.batch[:n] {
+			log.Printf("archive buffer is full, dropped delivery: %s, type: %s\n", e.DeliveryID, e.Type)
+		}
+		a.batch = append(a.batch[:0], a.batch[n:]...)
+	}
+	if len(a.batch) < a.BatchSize {
+		return nil
+	}
+	return a.flush(ctx)
+}
+
+// Flush writes all buffered events to the storage.
+func (a *Archiver) Flush(ctx context.Context) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.flush(ctx)
+}
+
+// OnEvent returns the webhook's OnEvent callback which archives the event before passing it to next.
+// Archive errors are logged, but the event is passed anyway (it stays buffered for the next flush),
+// so failures of the storage don't stop the processing.
+func (a *Archiver) OnEvent(next func(ctx context.Context, event *github.Event) error) func(ctx context.Context, event *github.Event) error {
+	return func(ctx context.Context, event *github.Event) error {
+		if err := a.Archive(ctx, event); err != nil {
+			log.Printf("archive delivery: %s, type: %s, error: %v\n", event.DeliveryID, event.Type, err)
+		}
+		return next(ctx, event)
+	}
+}
+
+// flush writes every partition of the batch to its own object. Events of the written partitions are removed
+// from the batch, even if a later partition fails, so they are not written twice by the next flush.
+func (a *Archiver) flush(ctx context.Context) error {
+	// Every partition (hour) gets its own object.
+	var partitions []string
+	events := make(map[string][]*github.Event)
+	for _, e := range a.batch {
+		p := e.ReceivedAt.UTC().Format(partitionLayout)
+		if _, ok := events[p]; !ok {
+			partitions = append(partitions, p)
+		}
+		events[p] = append(events[p], e)
+	}
+
+	stored := make(map[string]bool)
+	defer func() {
+		kept := a.batch[:0]
+		for _, e := range a.batch {
+			if !stored[e.ReceivedAt.UTC().Format(partitionLayout)] {
+				kept = append(kept, e)
+			}
+		}
+		a.batch = kept
+	}()
+
+	for _, p := range partitions {
+		data, err := encode(events[p])
+		if err != nil {
+			return err
+		}
+
+		suffix := make([]byte, 4)
+		if _, err = rand.Read(suffix); err != nil {
+			return err
+		}
+		key := fmt.Sprintf("%s%d-%s.ndjson.gz", p, time.Now().UnixNano(), hex.EncodeToString(suffix))
+		if err = a.storage.Put(ctx, key, data); err != nil {
+			return err
+		}
+		stored[p] = true
+	}
+	return nil
+}
+
+func encode(events []*github.Event) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	enc := json.NewEncoder(zw)
+	for _, e := range events {
+		if err := enc.Encode(e); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Filter selects archived events for the replay.
+type Filter struct {
+	// From (inclusive) and To (exclusive) is the time range when the events were received.
+	From, To time.Time
+	// Types of events, all types if empty.
+	Types []string
+	// DeliveryIDs of events, all deliveries if empty.
+	DeliveryIDs []string
+}
+
+func (f *Filter) match(event *github.Event) bool {
+	if event.ReceivedAt.Before(f.From) || !event.ReceivedAt.Before(f.To) {
+		return false
+	}
+	return contains(f.Types, event.Type) && contains(f.DeliveryIDs, event.DeliveryID)
+}
+
+func contains(values []string, v string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Replay reads archived events which match the filter and calls fn for every event
+// (in the order they were archived). It returns the number of replayed events.
+func Replay(ctx context.Context, storage Storage, filter Filter, fn func(ctx context.Context, event *github.Event) error) (int, error) {
+	n := 0
+	for hour := filter.From.UTC().Truncate(time.Hour); hour.Before(filter.To); hour = hour.Add(time.Hour) {
+		keys, err := storage.List(ctx, hour.Format(partitionLayout))
+		if err != nil {
+			return n, err
+		}
+
+		for _, key := range keys {
+			events, err := read(ctx, storage, key)
+			if err != nil {
+				return n, fmt.Errorf("%s: %v", key, err)
+			}
+
+			for _, e := range events {
+				if !filter.match(e) {
+					continue
+				}
+				if err = fn(ctx, e); err != nil {
+					return n, err
+				}
+				n++
+			}
+		}
+	}
+	return n, nil
+}
+
+// Purge rewrites archived objects without the events for which purged returns true (e.g. events
+// of purged installations), objects left empty are deleted. Only partitions since the first purged event
+// (e.g. since the installation was created) are read, all partitions if since is zero.
+// It returns the number of deleted events.
+func Purge(ctx context.Context, storage Storage, since time.Time, purged func(event *github.Event) bool) (int, error) {
+	keys, err := listSince(ctx, storage, since)
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for _, key := range keys {
+		events, err := read(ctx, storage, key)
+		if err != nil {
+			return n, fmt.Errorf("%s: %v", key, err)
+		}
+
+		var kept []*github.Event
+		for _, e := range events {
+			if !purged(e) {
+				kept = append(kept, e)
+			}
+		}
+		if len(kept) == len(events) {
+			continue
+		}
+
+		if len(kept) == 0 {
+			err = storage.Delete(ctx, key)
+		} else {
+			var data []byte
+			if data, err = encode(kept); err == nil {
+				err = storage.Put(ctx, key, data)
+			}
+		}
+		if err != nil {
+			return n, fmt.Errorf("%s: %v", key, err)
+		}
+		n += len(events) - len(kept)
+	}
+	return n, nil
+}
+
+// listSince returns keys of partitions since the time (all keys if it's zero), listed by days.
+func listSince(ctx context.Context, storage Storage, since time.Time) ([]string, error) {
+	if since.IsZero() {
+		return storage.List(ctx, "")
+	}
+
+	first := since.UTC().Format(partitionLayout)
+	var keys []string
+	for day := since.UTC().Truncate(24 * time.Hour); !day.After(time.Now()); day = day.Add(24 * time.Hour) {
+		dayKeys, err := storage.List(ctx, day.Format(dayLayout))
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range dayKeys {
+			if key >= first {
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys, nil
+}
+
+func read(ctx context.Context, storage Storage, key string) ([]*github.Event, error) {
+	rc, err := storage.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	zr, err := gzip.NewReader(rc)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	var events []*github.Event
+	dec := json.NewDecoder(zr)
+	for {
+		var e github.Event
+		if err = dec.Decode(&e); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		events = append(events, &e)
+	}
+	return events, nil
+}
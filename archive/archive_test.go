@@ -0,0 +1,198 @@
+package archive
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/athenianco/metadata/github"
+)
+
+var (
+	_ Storage = (*Filesystem)(nil)
+	_ Storage = (*S3)(nil)
+)
+
+func TestArchiveReplay(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "archive")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	storage, err := NewFilesystem(dir)
+	require.NoError(err)
+	testArchiveReplay(t, storage)
+
+	// Temporary files are not listed.
+	require.NoError(ioutil.WriteFile(dir+"/2020/01/01/10/broken.ndjson.gz.tmp", []byte("x"), 0644))
+	keys, err := storage.List(ctx, "2020/01/01/10/")
+	require.NoError(err)
+	require.Len(keys, 1)
+}
+
+// failingStorage fails to put objects with the prefix (if set).
+type failingStorage struct {
+	Storage
+	prefix string
+}
+
+func (s *failingStorage) Put(ctx context.Context, key string, data []byte) error {
+	if s.prefix != "" && strings.HasPrefix(key, s.prefix) {
+		return errors.New("put failed")
+	}
+	return s.Storage.Put(ctx, key, data)
+}
+
+func TestArchiveFailedPartition(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "archive")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	fs, err := NewFilesystem(dir)
+	require.NoError(err)
+	storage := &failingStorage{Storage: fs, prefix: "2020/01/01/13/"}
+
+	start := time.Date(2020, 1, 1, 10, 30, 0, 0, time.UTC)
+	events := []*github.Event{
+		{Type: "push", DeliveryID: "1", ReceivedAt: start},
+		{Type: "pull_request", DeliveryID: "2", ReceivedAt: start.Add(3 * time.Hour)},
+	}
+
+	// archive errors don't stop publishing.
+	var published []string
+	archiver := NewArchiver(storage, 2)
+	onEvent := archiver.OnEvent(func(ctx context.Context, event *github.Event) error {
+		published = append(published, event.DeliveryID)
+		return nil
+	})
+	for _, e := range events {
+		e.Payload = json.RawMessage(`{"id":` + e.DeliveryID + `}`)
+		require.NoError(onEvent(ctx, e))
+	}
+	require.Equal([]string{"1", "2"}, published)
+
+	// the stored partition is not written again.
+	storage.prefix = ""
+	require.NoError(archiver.Flush(ctx))
+	for _, prefix := range []string{"2020/01/01/10/", "2020/01/01/13/"} {
+		keys, err := storage.List(ctx, prefix)
+		require.NoError(err)
+		require.Len(keys, 1, prefix)
+	}
+}
+
+func TestArchiveMaxBuffered(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "archive")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	fs, err := NewFilesystem(dir)
+	require.NoError(err)
+	storage := &failingStorage{Storage: fs, prefix: "2020/"}
+
+	start := time.Date(2020, 1, 1, 10, 30, 0, 0, time.UTC)
+	archiver := NewArchiver(storage, 1)
+	archiver.MaxBuffered = 2
+	for i := 1; i <= 4; i++ {
+		e := &github.Event{Type: "push", DeliveryID: strconv.Itoa(i), ReceivedAt: start, Payload: json.RawMessage(`{}`)}
+		require.Error(archiver.Archive(ctx, e))
+	}
+
+	// the oldest events are dropped
+	storage.prefix = ""
+	require.NoError(archiver.Flush(ctx))
+	var ids []string
+	_, err = Replay(ctx, storage, Filter{From: start, To: start.Add(time.Hour)}, func(ctx context.Context, event *github.Event) error {
+		ids = append(ids, event.DeliveryID)
+		return nil
+	})
+	require.NoError(err)
+	require.Equal([]string{"3", "4"}, ids)
+}
+
+func testArchiveReplay(t *testing.T, storage Storage) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	start := time.Date(2020, 1, 1, 10, 30, 0, 0, time.UTC)
+	events := []*github.Event{
+		{Type: "push", DeliveryID: "1", ReceivedAt: start},
+		{Type: "issues", DeliveryID: "2", ReceivedAt: start.Add(10 * time.Minute)},
+		{Type: "push", DeliveryID: "3", ReceivedAt: start.Add(40 * time.Minute)},
+		{Type: "pull_request", DeliveryID: "4", ReceivedAt: start.Add(3 * time.Hour)},
+	}
+
+	var published []string
+	archiver := NewArchiver(storage, 3)
+	onEvent := archiver.OnEvent(func(ctx context.Context, event *github.Event) error {
+		published = append(published, event.DeliveryID)
+		return nil
+	})
+	for _, e := range events {
+		e.Payload = json.RawMessage(`{"id":` + e.DeliveryID + `}`)
+		require.NoError(onEvent(ctx, e))
+	}
+	require.Equal([]string{"1", "2", "3", "4"}, published)
+
+	// The last event is buffered until the flush.
+	keys, err := storage.List(ctx, "2020/01/01/13/")
+	require.NoError(err)
+	require.Empty(keys)
+	require.NoError(archiver.Flush(ctx))
+	keys, err = storage.List(ctx, "2020/01/01/13/")
+	require.NoError(err)
+	require.Len(keys, 1)
+
+	replay := func(filter Filter) []string {
+		var ids []string
+		n, err := Replay(ctx, storage, filter, func(ctx context.Context, event *github.Event) error {
+			require.Equal(`{"id":`+event.DeliveryID+`}`, string(event.Payload))
+			ids = append(ids, event.DeliveryID)
+			return nil
+		})
+		require.NoError(err)
+		require.Equal(len(ids), n)
+		return ids
+	}
+
+	end := start.Add(24 * time.Hour)
+	require.Equal([]string{"1", "2", "3", "4"}, replay(Filter{From: start, To: end}))
+	require.Equal([]string{"2", "3"}, replay(Filter{From: start.Add(time.Minute), To: start.Add(3 * time.Hour)}))
+	require.Equal([]string{"1", "3"}, replay(Filter{From: start, To: end, Types: []string{"push"}}))
+	require.Equal([]string{"4"}, replay(Filter{From: start, To: end, DeliveryIDs: []string{"4", "5"}}))
+	require.Empty(replay(Filter{From: end, To: end.Add(time.Hour)}))
+
+	// partitions before since aren't read
+	n, err := Purge(ctx, storage, start.Add(3*time.Hour), func(event *github.Event) bool {
+		return event.DeliveryID == "2"
+	})
+	require.NoError(err)
+	require.Equal(0, n)
+
+	// purged events are removed from their objects, emptied objects are deleted
+	n, err = Purge(ctx, storage, start, func(event *github.Event) bool {
+		return event.DeliveryID == "2" || event.DeliveryID == "4"
+	})
+	require.NoError(err)
+	require.Equal(2, n)
+	require.Equal([]string{"1", "3"}, replay(Filter{From: start, To: end}))
+	keys, err = storage.List(ctx, "2020/01/01/13/")
+	require.NoError(err)
+	require.Empty(keys)
+}
@@ -0,0 +1,137 @@
+package archive
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Storage is where archived objects are stored.
+// Keys are slash-separated paths relative to the storage's root.
+type Storage interface {
+	// Put stores the object under the key (atomically, readers never see partial objects).
+	Put(ctx context.Context, key string, data []byte) error
+	// Get opens the object stored under the key.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// List returns sorted keys with the given prefix.
+	List(ctx context.Context, prefix string) ([]string, error)
+	// Delete removes the object stored under the key.
+	Delete(ctx context.Context, key string) error
+}
+
+// OpenStorage opens the storage described by the URI:
+//   - file:///path/to/dir (or just /path/to/dir) - local filesystem,
+//   - s3://bucket/prefix?endpoint=https://host&region=us-east-1 - S3 compatible storage,
+//     credentials are read from AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY environment variables.
+func OpenStorage(uri string) (Storage, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	switch u.Scheme {
+	case "", "file":
+		return NewFilesystem(u.Path)
+
+	case "s3":
+		endpoint := u.Query().Get("endpoint")
+		if endpoint == "" {
+			endpoint = "https://s3.amazonaws.com"
+		}
+		region := u.Query().Get("region")
+		if region == "" {
+			region = "us-east-1"
+		}
+		return &S3{
+			Endpoint:  endpoint,
+			Region:    region,
+			Bucket:    u.Host,
+			Prefix:    strings.TrimPrefix(u.Path, "/"),
+			AccessKey: os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported storage: %s", uri)
+}
+
+// Filesystem is a storage in the local directory.
+type Filesystem struct {
+	dir string
+}
+
+// NewFilesystem creates a new storage in the given directory.
+// The directory is created if it does not exist.
+func NewFilesystem(dir string) (*Filesystem, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &Filesystem{dir: dir}, nil
+}
+
+// Put writes the object to a temporary file and renames it to the key.
+func (fs *Filesystem) Put(ctx context.Context, key string, data []byte) error {
+	path := filepath.Join(fs.dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// Get opens the file.
+func (fs *Filesystem) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(fs.dir, filepath.FromSlash(key)))
+}
+
+// Delete removes the file.
+func (fs *Filesystem) Delete(ctx context.Context, key string) error {
+	return os.Remove(filepath.Join(fs.dir, filepath.FromSlash(key)))
+}
+
+// List walks the directory and returns keys of files with the given prefix.
+func (fs *Filesystem) List(ctx context.Context, prefix string) ([]string, error) {
+	// Walk only the deepest directory of the prefix.
+	root := fs.dir
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		root = filepath.Join(fs.dir, filepath.FromSlash(prefix[:i]))
+	}
+
+	var keys []string
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() || strings.HasSuffix(path, ".tmp") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(fs.dir, path)
+		if err != nil {
+			return err
+		}
+		if key := filepath.ToSlash(rel); strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	sort.Strings(keys)
+	return keys, err
+}
@@ -0,0 +1,170 @@
+package backfill
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	gh "github.com/google/go-github/v28/github"
+)
+
+// TokenTransport authenticates requests with the personal access token.
+type TokenTransport struct {
+	Token string
+	// Base is the underlying transport, http.DefaultTransport if nil.
+	Base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *TokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = cloneRequest(req)
+	req.Header.Set("Authorization", "token "+t.Token)
+	return base(t.Base).RoundTrip(req)
+}
+
+// AppTransport authenticates requests as the installation of the GitHub App.
+// The installation token is created with the App's JWT and renewed before it expires.
+type AppTransport struct {
+	// Base is the underlying transport, http.DefaultTransport if nil.
+	Base http.RoundTripper
+
+	appID          int64
+	installationID int64
+	key            *rsa.PrivateKey
+	baseURL        *url.URL
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+// NewAppTransport creates a new transport of the App's installation.
+// The private key is PEM encoded (as downloaded from the App's settings).
+// Base URL is the API endpoint, https://api.github.com/ if empty.
+func NewAppTransport(appID, installationID int64, privateKey []byte, baseURL string) (*AppTransport, error) {
+	block, _ := pem.Decode(privateKey)
+	if block == nil {
+		return nil, errors.New("private key is not PEM encoded")
+	}
+	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	if baseURL == "" {
+		baseURL = "https://api.github.com/"
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AppTransport{
+		appID:          appID,
+		installationID: installationID,
+		key:            key,
+		baseURL:        u,
+	}, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *AppTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.installationToken(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	req = cloneRequest(req)
+	req.Header.Set("Authorization", "token "+token)
+	return base(t.Base).RoundTrip(req)
+}
+
+func (t *AppTransport) installationToken(ctx context.Context) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.token != "" && time.Now().Add(time.Minute).Before(t.expiresAt) {
+		return t.token, nil
+	}
+
+	jwt, err := t.jwt(time.Now())
+	if err != nil {
+		return "", err
+	}
+	client := gh.NewClient(&http.Client{Transport: &bearerTransport{token: jwt, base: t.Base}})
+	client.BaseURL = t.baseURL
+	token, _, err := client.Apps.CreateInstallationToken(ctx, t.installationID, nil)
+	if err != nil {
+		return "", err
+	}
+
+	t.token, t.expiresAt = token.GetToken(), token.GetExpiresAt()
+	return t.token, nil
+}
+
+// jwt returns the App's JSON Web Token (RS256), valid for 10 minutes at most.
+func (t *AppTransport) jwt(now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		// backdated against clock drift
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": strconv.FormatInt(t.appID, 10),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	enc := base64.RawURLEncoding
+	unsigned := enc.EncodeToString(header) + "." + enc.EncodeToString(claims)
+	hash := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, t.key, crypto.SHA256, hash[:])
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + enc.EncodeToString(sig), nil
+}
+
+type bearerTransport struct {
+	token string
+	base  http.RoundTripper
+}
+
+func (t *bearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = cloneRequest(req)
+	req.Header.Set("Authorization", "Bearer "+t.token)
+	return base(t.base).RoundTrip(req)
+}
+
+func base(rt http.RoundTripper) http.RoundTripper {
+	if rt == nil {
+		return http.DefaultTransport
+	}
+	return rt
+}
+
+// cloneRequest returns a copy of the request with its own headers (transports must not modify the request).
+func cloneRequest(req *http.Request) *http.Request {
+	r := new(http.Request)
+	*r = *req
+	r.Header = make(http.Header, len(req.Header))
+	for k, v := range req.Header {
+		r.Header[k] = append([]string(nil), v...)
+	}
+	return r
+}
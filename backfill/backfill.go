@@ -0,0 +1,364 @@
+// Package backfill loads the existing metadata of repositories (pull requests, reviews, review comments,
+// issues and comments) from the GitHub REST API into the database, so it's not limited to events received
+// by the webhook. Everything is written by the same upserts as the webhook events.
+//
+// Every repository is backfilled in stages (pull requests, issues) page by page. The next page of the stage
+// is checkpointed after every page, so the interrupted backfill resumes where it stopped.
+//
+// Reconciler keeps backfilled repositories eventually consistent, see ReconcileRepository.
+package backfill
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/athenianco/metadata/github"
+	gh "github.com/google/go-github/v28/github"
+)
+
+// Stages of the repository's backfill.
+const (
+	stagePullRequests = "pull_requests"
+	stageIssues       = "issues"
+)
+
+// Store is where the backfill writes to, implemented by github.Database.
+type Store interface {
+	UpsertRepository(ctx context.Context, repo *gh.Repository) error
+	InsertUsers(ctx context.Context, org *gh.Organization, users []*gh.User) error
+	UpsertPullRequest(ctx context.Context, repo *gh.Repository, pr *gh.PullRequest) error
+	UpsertPullRequestReview(ctx context.Context, repo *gh.Repository, pr *gh.PullRequest, review *gh.PullRequestReview) error
+	UpsertPullRequestReviewComment(ctx context.Context, repo *gh.Repository, pr *gh.PullRequest, comment *gh.PullRequestComment) error
+	UpsertIssues(ctx context.Context, repo *gh.Repository, issue *gh.Issue) error
+	UpsertIssueComment(ctx context.Context, repo *gh.Repository, issue *gh.Issue, comment *gh.IssueComment) error
+	UpsertIssueCommentAsPullRequest(ctx context.Context, repo *gh.Repository, issue *gh.Issue, comment *gh.IssueComment) error
+
+	BackfillCheckpoint(ctx context.Context, repo, stage string) (int, bool, error)
+	SaveBackfillCheckpoint(ctx context.Context, repo, stage string, page int, done bool) error
+}
+
+var _ Store = (*github.Database)(nil)
+
+// Backfiller walks repositories through the GitHub API and writes them to the store.
+type Backfiller struct {
+	// Org lists repositories of the organization, otherwise repositories accessible by the installation
+	// (the client has to be authenticated as the installation, see NewAppTransport).
+	Org string
+	// Repositories limits the backfill to these repositories (full names), all repositories if empty.
+	Repositories []string
+	// PerPage is the page size of API listings (at most 100).
+	PerPage int
+
+	client *gh.Client
+	store  Store
+}
+
+// New creates a new backfiller.
+func New(client *gh.Client, store Store) *Backfiller {
+	return &Backfiller{
+		PerPage: 100,
+		client:  client,
+		store:   store,
+	}
+}
+
+// Run backfills all repositories. Repositories and stages which are done are skipped.
+func (b *Backfiller) Run(ctx context.Context) error {
+	repos, err := b.repositories(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, repo := range repos {
+		if err = b.Repository(ctx, repo); err != nil {
+			return fmt.Errorf("%s: %v", repo.GetFullName(), err)
+		}
+	}
+	return nil
+}
+
+// Repository backfills a single repository, resuming its stages from checkpoints.
+func (b *Backfiller) Repository(ctx context.Context, repo *gh.Repository) error {
+	log.Printf("backfilling %s\n", repo.GetFullName())
+	if err := b.store.UpsertRepository(ctx, repo); err != nil {
+		return err
+	}
+
+	if err := b.stage(ctx, repo, stagePullRequests, b.pullRequests); err != nil {
+		return err
+	}
+	return b.stage(ctx, repo, stageIssues, b.issues)
+}
+
+// repositories returns the repositories to backfill.
+func (b *Backfiller) repositories(ctx context.Context) ([]*gh.Repository, error) {
+	var repos []*gh.Repository
+	for page := 1; page != 0; {
+		var list []*gh.Repository
+		resp, err := b.do(ctx, func() (resp *gh.Response, err error) {
+			opt := gh.ListOptions{Page: page, PerPage: b.PerPage}
+			if b.Org != "" {
+				list, resp, err = b.client.Repositories.ListByOrg(ctx, b.Org, &gh.RepositoryListByOrgOptions{ListOptions: opt})
+			} else {
+				list, resp, err = b.client.Apps.ListRepos(ctx, &opt)
+			}
+			return resp, err
+		})
+		if err != nil {
+			return nil, err
+		}
+		repos = append(repos, list...)
+		page = resp.NextPage
+	}
+
+	if len(b.Repositories) == 0 {
+		return repos, nil
+	}
+
+	byName := make(map[string]*gh.Repository, len(repos))
+	for _, repo := range repos {
+		byName[repo.GetFullName()] = repo
+	}
+	selected := make([]*gh.Repository, 0, len(b.Repositories))
+	for _, name := range b.Repositories {
+		repo, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("repository not found: %s", name)
+		}
+		selected = append(selected, repo)
+	}
+	return selected, nil
+}
+
+// stage calls the page function for every page of the stage (it returns the next page, 0 after the last one),
+// starting from the checkpoint.
+func (b *Backfiller) stage(ctx context.Context, repo *gh.Repository, stage string, fn func(ctx context.Context, repo *gh.Repository, page int) (int, error)) error {
+	name := repo.GetFullName()
+	page, done, err := b.store.BackfillCheckpoint(ctx, name, stage)
+	if err != nil {
+		return err
+	}
+	if done {
+		log.Printf("skipped %s of %s, already backfilled\n", stage, name)
+		return nil
+	}
+
+	for {
+		next, err := fn(ctx, repo, page)
+		if err != nil {
+			return fmt.Errorf("%s page %d: %v", stage, page, err)
+		}
+		if next == 0 {
+			return b.store.SaveBackfillCheckpoint(ctx, name, stage, page, true)
+		}
+		if err = b.store.SaveBackfillCheckpoint(ctx, name, stage, next, false); err != nil {
+			return err
+		}
+		page = next
+	}
+}
+
+// pullRequests backfills a page of pull requests with their reviews and review comments.
+// Pull requests are listed by creation, so pages don't shift when new ones are opened.
+func (b *Backfiller) pullRequests(ctx context.Context, repo *gh.Repository, page int) (int, error) {
+	owner, name := repo.GetOwner().GetLogin(), repo.GetName()
+	var prs []*gh.PullRequest
+	resp, err := b.do(ctx, func() (resp *gh.Response, err error) {
+		prs, resp, err = b.client.PullRequests.List(ctx, owner, name, &gh.PullRequestListOptions{
+			State:       "all",
+			Sort:        "created",
+			Direction:   "asc",
+			ListOptions: gh.ListOptions{Page: page, PerPage: b.PerPage},
+		})
+		return resp, err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, pr := range prs {
+		if err = b.pullRequest(ctx, repo, pr.GetNumber()); err != nil {
+			return 0, fmt.Errorf("pull request #%d: %v", pr.GetNumber(), err)
+		}
+	}
+	return resp.NextPage, nil
+}
+
+func (b *Backfiller) pullRequest(ctx context.Context, repo *gh.Repository, number int) error {
+	owner, name := repo.GetOwner().GetLogin(), repo.GetName()
+
+	// Listed pull requests lack some fields (e.g. merged_by, additions), so the full one is fetched.
+	var pr *gh.PullRequest
+	_, err := b.do(ctx, func() (resp *gh.Response, err error) {
+		pr, resp, err = b.client.PullRequests.Get(ctx, owner, name, number)
+		return resp, err
+	})
+	if err != nil {
+		return err
+	}
+	if err = b.store.UpsertPullRequest(ctx, repo, pr); err != nil {
+		return err
+	}
+	users := []*gh.User{pr.GetUser(), pr.GetMergedBy()}
+	users = append(users, pr.Assignees...)
+	users = append(users, pr.RequestedReviewers...)
+
+	for page := 1; page != 0; {
+		var reviews []*gh.PullRequestReview
+		resp, err := b.do(ctx, func() (resp *gh.Response, err error) {
+			reviews, resp, err = b.client.PullRequests.ListReviews(ctx, owner, name, number, &gh.ListOptions{Page: page, PerPage: b.PerPage})
+			return resp, err
+		})
+		if err != nil {
+			return err
+		}
+		for _, review := range reviews {
+			if err = b.store.UpsertPullRequestReview(ctx, repo, pr, review); err != nil {
+				return err
+			}
+			users = append(users, review.GetUser())
+		}
+		page = resp.NextPage
+	}
+
+	for page := 1; page != 0; {
+		var comments []*gh.PullRequestComment
+		resp, err := b.do(ctx, func() (resp *gh.Response, err error) {
+			comments, resp, err = b.client.PullRequests.ListComments(ctx, owner, name, number, &gh.PullRequestListCommentsOptions{
+				Sort:        "created",
+				Direction:   "asc",
+				ListOptions: gh.ListOptions{Page: page, PerPage: b.PerPage},
+			})
+			return resp, err
+		})
+		if err != nil {
+			return err
+		}
+		for _, comment := range comments {
+			if err = b.store.UpsertPullRequestReviewComment(ctx, repo, pr, comment); err != nil {
+				return err
+			}
+			users = append(users, comment.GetUser())
+		}
+		page = resp.NextPage
+	}
+
+	return b.insertUsers(ctx, repo, users)
+}
+
+// issues backfills a page of issues with their comments. Like the API, issues include pull requests,
+// which are backfilled by pullRequests, only comments of pull requests are backfilled here.
+func (b *Backfiller) issues(ctx context.Context, repo *gh.Repository, page int) (int, error) {
+	owner, name := repo.GetOwner().GetLogin(), repo.GetName()
+	var issues []*gh.Issue
+	resp, err := b.do(ctx, func() (resp *gh.Response, err error) {
+		issues, resp, err = b.client.Issues.ListByRepo(ctx, owner, name, &gh.IssueListByRepoOptions{
+			State:       "all",
+			Sort:        "created",
+			Direction:   "asc",
+			ListOptions: gh.ListOptions{Page: page, PerPage: b.PerPage},
+		})
+		return resp, err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, issue := range issues {
+		if err = b.issue(ctx, repo, issue); err != nil {
+			return 0, fmt.Errorf("issue #%d: %v", issue.GetNumber(), err)
+		}
+	}
+	return resp.NextPage, nil
+}
+
+func (b *Backfiller) issue(ctx context.Context, repo *gh.Repository, issue *gh.Issue) error {
+	owner, name := repo.GetOwner().GetLogin(), repo.GetName()
+
+	var users []*gh.User
+	if !issue.IsPullRequest() {
+		if err := b.store.UpsertIssues(ctx, repo, issue); err != nil {
+			return err
+		}
+		users = append(users, issue.GetUser(), issue.GetClosedBy())
+		users = append(users, issue.Assignees...)
+	}
+
+	// Don't list comments of issues without any.
+	if issue.Comments != nil && issue.GetComments() == 0 {
+		return b.insertUsers(ctx, repo, users)
+	}
+
+	for page := 1; page != 0; {
+		var comments []*gh.IssueComment
+		resp, err := b.do(ctx, func() (resp *gh.Response, err error) {
+			comments, resp, err = b.client.Issues.ListComments(ctx, owner, name, issue.GetNumber(), &gh.IssueListCommentsOptions{
+				ListOptions: gh.ListOptions{Page: page, PerPage: b.PerPage},
+			})
+			return resp, err
+		})
+		if err != nil {
+			return err
+		}
+		for _, comment := range comments {
+			if issue.IsPullRequest() {
+				err = b.store.UpsertIssueCommentAsPullRequest(ctx, repo, issue, comment)
+			} else {
+				err = b.store.UpsertIssueComment(ctx, repo, issue, comment)
+			}
+			if err != nil {
+				return err
+			}
+			users = append(users, comment.GetUser())
+		}
+		page = resp.NextPage
+	}
+
+	return b.insertUsers(ctx, repo, users)
+}
+
+// insertUsers stores users, who are not stored yet, in the context of the organization which owns the repository,
+// like the webhook events. Users listed with pull requests, issues and comments are minimal (no profile),
+// so they never overwrite the stored users.
+func (b *Backfiller) insertUsers(ctx context.Context, repo *gh.Repository, users []*gh.User) error {
+	org := github.OwnerOrganization(repo.GetOwner())
+	var actors []*gh.User
+	seen := make(map[int64]bool)
+	for _, user := range users {
+		if user.GetID() == 0 || seen[user.GetID()] {
+			continue
+		}
+		seen[user.GetID()] = true
+		actors = append(actors, user)
+	}
+	return b.store.InsertUsers(ctx, org, actors)
+}
+
+// do calls the API, waiting out rate limits.
+func (b *Backfiller) do(ctx context.Context, call func() (*gh.Response, error)) (*gh.Response, error) {
+	for {
+		resp, err := call()
+
+		var wait time.Duration
+		switch err := err.(type) {
+		case *gh.RateLimitError:
+			wait = time.Until(err.Rate.Reset.Time) + time.Second
+		case *gh.AbuseRateLimitError:
+			wait = time.Minute
+			if err.RetryAfter != nil {
+				wait = *err.RetryAfter
+			}
+		default:
+			return resp, err
+		}
+
+		log.Printf("rate limited, waiting %v\n", wait)
+		select {
+		case <-ctx.Done():
+			return resp, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
@@ -0,0 +1,438 @@
+package backfill
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	gh "github.com/google/go-github/v28/github"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeGitHub serves resources (JSON by path), arrays are filtered by since and paginated by per_page.
+type fakeGitHub struct {
+	key       *rsa.PublicKey
+	resources map[string]string
+
+	mu       sync.Mutex
+	requests map[string]int
+	// queries are the last query parameters by path.
+	queries map[string]url.Values
+	// failures is the number of times the request (path?page=N) fails.
+	failures map[string]int
+}
+
+const installationToken = "installation-token"
+
+// fakeResources are a single repository o/r with 2 pull requests and 1 issue.
+var fakeResources = map[string]string{
+	"/installation/repositories": `{"total_count": 1, "repositories": [
+		{"id": 1, "name": "r", "full_name": "o/r", "owner": {"id": 10, "login": "o", "type": "Organization"}}
+	]}`,
+	"/repos/o/r/pulls":            `[{"number": 1}, {"number": 2}]`,
+	"/repos/o/r/pulls/1":          `{"id": 101, "number": 1, "user": {"id": 20, "login": "alice"}, "merged_by": {"id": 21, "login": "bob"}}`,
+	"/repos/o/r/pulls/2":          `{"id": 102, "number": 2, "user": {"id": 22, "login": "carol"}}`,
+	"/repos/o/r/pulls/1/reviews":  `[{"id": 201, "user": {"id": 21, "login": "bob"}}]`,
+	"/repos/o/r/pulls/2/reviews":  `[]`,
+	"/repos/o/r/pulls/1/comments": `[{"id": 301, "user": {"id": 21, "login": "bob"}}, {"id": 302, "user": {"id": 20, "login": "alice"}}]`,
+	"/repos/o/r/pulls/2/comments": `[]`,
+	"/repos/o/r/issues": `[
+		{"id": 1001, "number": 1, "comments": 1, "pull_request": {"url": "https://api.github.com/repos/o/r/pulls/1"}},
+		{"id": 1002, "number": 2, "comments": 0, "pull_request": {"url": "https://api.github.com/repos/o/r/pulls/2"}},
+		{"id": 1003, "number": 3, "comments": 1, "user": {"id": 23, "login": "dave"}}
+	]`,
+	"/repos/o/r/issues/1/comments": `[{"id": 501, "user": {"id": 22, "login": "carol"}}]`,
+	"/repos/o/r/issues/3/comments": `[{"id": 502, "user": {"id": 20, "login": "alice"}}]`,
+}
+
+func (f *fakeGitHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/app/installations/1/access_tokens" {
+		if err := f.verifyJWT(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"token": %q, "expires_at": %q}`, installationToken, time.Now().Add(time.Hour).Format(time.RFC3339))
+		return
+	}
+	if r.Header.Get("Authorization") != "token "+installationToken {
+		http.Error(w, "bad credentials", http.StatusUnauthorized)
+		return
+	}
+
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page == 0 {
+		page = 1
+	}
+	perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+
+	key := fmt.Sprintf("%s?page=%d", r.URL.Path, page)
+	f.mu.Lock()
+	f.requests[key]++
+	f.queries[r.URL.Path] = r.URL.Query()
+	fail := f.failures[key] > 0
+	f.failures[key]--
+	f.mu.Unlock()
+	if fail {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	data, ok := f.resources[r.URL.Path]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if perPage > 0 && strings.HasPrefix(data, "[") {
+		var all []json.RawMessage
+		if err := json.Unmarshal([]byte(data), &all); err != nil {
+			panic(err)
+		}
+		since, _ := time.Parse(time.RFC3339, r.URL.Query().Get("since"))
+		var items []json.RawMessage
+		for _, item := range all {
+			var v struct {
+				UpdatedAt time.Time `json:"updated_at"`
+			}
+			if err := json.Unmarshal(item, &v); err != nil {
+				panic(err)
+			}
+			if !v.UpdatedAt.Before(since) {
+				items = append(items, item)
+			}
+		}
+		start, end := (page-1)*perPage, page*perPage
+		if end < len(items) {
+			next := *r.URL
+			q := next.Query()
+			q.Set("page", strconv.Itoa(page+1))
+			next.RawQuery = q.Encode()
+			w.Header().Set("Link", fmt.Sprintf(`<http://%s%s>; rel="next"`, r.Host, next.String()))
+		} else {
+			end = len(items)
+		}
+		if start > end {
+			start = end
+		}
+		b, _ := json.Marshal(items[start:end])
+		data = string(b)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprint(w, data)
+}
+
+func (f *fakeGitHub) verifyJWT(token string) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return fmt.Errorf("malformed jwt: %q", token)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return err
+	}
+	hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err = rsa.VerifyPKCS1v15(f.key, crypto.SHA256, hash[:], sig); err != nil {
+		return err
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return err
+	}
+	var claims struct {
+		Iss string `json:"iss"`
+		Exp int64  `json:"exp"`
+	}
+	if err = json.Unmarshal(data, &claims); err != nil {
+		return err
+	}
+	if claims.Iss != "42" || time.Unix(claims.Exp, 0).Before(time.Now()) {
+		return fmt.Errorf("invalid claims: %s", data)
+	}
+	return nil
+}
+
+func (f *fakeGitHub) count(key string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests[key]
+}
+
+func (f *fakeGitHub) query(path string) url.Values {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.queries[path]
+}
+
+// memoryStore records what was stored.
+type memoryStore struct {
+	rows        map[string]bool
+	updatedAt   map[string]time.Time
+	reviews     map[string]string
+	users       map[string]bool
+	checkpoints map[string]int
+	done        map[string]bool
+	reconciled  map[string]time.Time
+	repaired    map[string]int
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		rows:        make(map[string]bool),
+		updatedAt:   make(map[string]time.Time),
+		reviews:     make(map[string]string),
+		users:       make(map[string]bool),
+		checkpoints: make(map[string]int),
+		done:        make(map[string]bool),
+		reconciled:  make(map[string]time.Time),
+		repaired:    make(map[string]int),
+	}
+}
+
+func (s *memoryStore) put(key string, updatedAt time.Time) {
+	s.rows[key] = true
+	s.updatedAt[key] = updatedAt
+}
+
+func (s *memoryStore) isStale(key string, updatedAt time.Time) (bool, error) {
+	stored, ok := s.updatedAt[key]
+	return !ok || stored.Before(updatedAt), nil
+}
+
+func pullRequestKey(pr *gh.PullRequest) string {
+	return fmt.Sprintf("pull_request %d", pr.GetID())
+}
+
+func reviewKey(pr *gh.PullRequest, review *gh.PullRequestReview) string {
+	return fmt.Sprintf("review %d/%d", pr.GetID(), review.GetID())
+}
+
+func reviewCommentKey(pr *gh.PullRequest, comment *gh.PullRequestComment) string {
+	return fmt.Sprintf("review_comment %d/%d", pr.GetID(), comment.GetID())
+}
+
+func issueKey(issue *gh.Issue) string {
+	return fmt.Sprintf("issue %d", issue.GetID())
+}
+
+func issueCommentKey(issue *gh.Issue, comment *gh.IssueComment) string {
+	return fmt.Sprintf("issue_comment %d/%d", issue.GetID(), comment.GetID())
+}
+
+func pullRequestCommentKey(issue *gh.Issue, comment *gh.IssueComment) string {
+	return fmt.Sprintf("pull_request_comment %d/%d", issue.GetID(), comment.GetID())
+}
+
+func (s *memoryStore) UpsertRepository(ctx context.Context, repo *gh.Repository) error {
+	s.rows["repository "+repo.GetFullName()] = true
+	return nil
+}
+
+func (s *memoryStore) InsertUsers(ctx context.Context, org *gh.Organization, users []*gh.User) error {
+	for _, user := range users {
+		s.users[org.GetLogin()+"/"+user.GetLogin()] = true
+	}
+	return nil
+}
+
+func (s *memoryStore) UpsertPullRequest(ctx context.Context, repo *gh.Repository, pr *gh.PullRequest) error {
+	s.put(pullRequestKey(pr), pr.GetUpdatedAt())
+	return nil
+}
+
+func (s *memoryStore) UpsertPullRequestReview(ctx context.Context, repo *gh.Repository, pr *gh.PullRequest, review *gh.PullRequestReview) error {
+	s.put(reviewKey(pr, review), review.GetSubmittedAt())
+	s.reviews[reviewKey(pr, review)] = review.GetState() + " " + review.GetBody()
+	return nil
+}
+
+func (s *memoryStore) UpsertPullRequestReviewComment(ctx context.Context, repo *gh.Repository, pr *gh.PullRequest, comment *gh.PullRequestComment) error {
+	s.put(reviewCommentKey(pr, comment), comment.GetUpdatedAt())
+	return nil
+}
+
+func (s *memoryStore) UpsertIssues(ctx context.Context, repo *gh.Repository, issue *gh.Issue) error {
+	s.put(issueKey(issue), issue.GetUpdatedAt())
+	return nil
+}
+
+func (s *memoryStore) UpsertIssueComment(ctx context.Context, repo *gh.Repository, issue *gh.Issue, comment *gh.IssueComment) error {
+	s.put(issueCommentKey(issue, comment), comment.GetUpdatedAt())
+	return nil
+}
+
+func (s *memoryStore) UpsertIssueCommentAsPullRequest(ctx context.Context, repo *gh.Repository, issue *gh.Issue, comment *gh.IssueComment) error {
+	s.put(pullRequestCommentKey(issue, comment), comment.GetUpdatedAt())
+	return nil
+}
+
+func (s *memoryStore) BackfillCheckpoint(ctx context.Context, repo, stage string) (int, bool, error) {
+	page, ok := s.checkpoints[repo+" "+stage]
+	if !ok {
+		return 1, false, nil
+	}
+	return page, s.done[repo+" "+stage], nil
+}
+
+func (s *memoryStore) SaveBackfillCheckpoint(ctx context.Context, repo, stage string, page int, done bool) error {
+	s.checkpoints[repo+" "+stage] = page
+	s.done[repo+" "+stage] = done
+	return nil
+}
+
+func (s *memoryStore) IsPullRequestStale(ctx context.Context, repo *gh.Repository, pr *gh.PullRequest) (bool, error) {
+	return s.isStale(pullRequestKey(pr), pr.GetUpdatedAt())
+}
+
+func (s *memoryStore) IsPullRequestReviewStale(ctx context.Context, repo *gh.Repository, pr *gh.PullRequest, review *gh.PullRequestReview) (bool, error) {
+	if stale, err := s.isStale(reviewKey(pr, review), review.GetSubmittedAt()); stale || err != nil {
+		return stale, err
+	}
+	return s.reviews[reviewKey(pr, review)] != review.GetState()+" "+review.GetBody(), nil
+}
+
+func (s *memoryStore) IsPullRequestReviewCommentStale(ctx context.Context, repo *gh.Repository, pr *gh.PullRequest, comment *gh.PullRequestComment) (bool, error) {
+	return s.isStale(reviewCommentKey(pr, comment), comment.GetUpdatedAt())
+}
+
+func (s *memoryStore) IsIssueStale(ctx context.Context, repo *gh.Repository, issue *gh.Issue) (bool, error) {
+	return s.isStale(issueKey(issue), issue.GetUpdatedAt())
+}
+
+func (s *memoryStore) IsIssueCommentStale(ctx context.Context, repo *gh.Repository, issue *gh.Issue, comment *gh.IssueComment) (bool, error) {
+	return s.isStale(issueCommentKey(issue, comment), comment.GetUpdatedAt())
+}
+
+func (s *memoryStore) IsIssueCommentAsPullRequestStale(ctx context.Context, repo *gh.Repository, issue *gh.Issue, comment *gh.IssueComment) (bool, error) {
+	return s.isStale(pullRequestCommentKey(issue, comment), comment.GetUpdatedAt())
+}
+
+func (s *memoryStore) ReconcileCheckpoint(ctx context.Context, repo string) (time.Time, bool, error) {
+	since, ok := s.reconciled[repo]
+	return since, ok, nil
+}
+
+func (s *memoryStore) SaveReconcileCheckpoint(ctx context.Context, repo string, since time.Time, repaired int) error {
+	s.reconciled[repo] = since
+	s.repaired[repo] = repaired
+	return nil
+}
+
+// newTestClient returns the client of the fake GitHub API, authenticated as the installation 1 of the App 42.
+func newTestClient(t *testing.T, resources map[string]string, failures map[string]int) (*httptest.Server, *fakeGitHub, *gh.Client) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	pemKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	fake := &fakeGitHub{
+		key:       &key.PublicKey,
+		resources: resources,
+		requests:  make(map[string]int),
+		queries:   make(map[string]url.Values),
+		failures:  failures,
+	}
+	srv := httptest.NewServer(fake)
+
+	transport, err := NewAppTransport(42, 1, pemKey, srv.URL+"/")
+	require.NoError(t, err)
+	client := gh.NewClient(&http.Client{Transport: transport})
+	client.BaseURL, err = url.Parse(srv.URL + "/")
+	require.NoError(t, err)
+	return srv, fake, client
+}
+
+func newTestBackfiller(t *testing.T, failures map[string]int) (*httptest.Server, *fakeGitHub, *memoryStore, *Backfiller) {
+	srv, fake, client := newTestClient(t, fakeResources, failures)
+	store := newMemoryStore()
+	b := New(client, store)
+	b.PerPage = 1
+	return srv, fake, store, b
+}
+
+var expectedRows = map[string]bool{
+	"repository o/r":                true,
+	"pull_request 101":              true,
+	"pull_request 102":              true,
+	"review 101/201":                true,
+	"review_comment 101/301":        true,
+	"review_comment 101/302":        true,
+	"issue 1003":                    true,
+	"issue_comment 1003/502":        true,
+	"pull_request_comment 1001/501": true,
+}
+
+var expectedUsers = map[string]bool{
+	"o/alice": true,
+	"o/bob":   true,
+	"o/carol": true,
+	"o/dave":  true,
+}
+
+func TestBackfill(t *testing.T) {
+	require := require.New(t)
+
+	srv, fake, store, b := newTestBackfiller(t, map[string]int{})
+	defer srv.Close()
+	require.NoError(b.Run(context.Background()))
+	require.Equal(expectedRows, store.rows)
+	require.Equal(expectedUsers, store.users)
+	require.Equal(map[string]bool{"o/r pull_requests": true, "o/r issues": true}, store.done)
+
+	// pull requests without comments are not listed
+	require.Equal(0, fake.count("/repos/o/r/issues/2/comments?page=1"))
+
+	// done stages are skipped
+	require.NoError(b.Run(context.Background()))
+	require.Equal(1, fake.count("/repos/o/r/pulls?page=1"))
+	require.Equal(1, fake.count("/repos/o/r/issues?page=1"))
+}
+
+func TestBackfillResume(t *testing.T) {
+	require := require.New(t)
+
+	srv, fake, store, b := newTestBackfiller(t, map[string]int{"/repos/o/r/issues?page=3": 1})
+	defer srv.Close()
+	err := b.Run(context.Background())
+	require.Error(err)
+	require.Contains(err.Error(), "issues page 3")
+	require.Equal(3, store.checkpoints["o/r issues"])
+	require.False(store.done["o/r issues"])
+	require.False(store.rows["issue 1003"])
+
+	require.NoError(b.Run(context.Background()))
+	require.Equal(expectedRows, store.rows)
+	require.Equal(expectedUsers, store.users)
+
+	// finished pages are not requested again
+	require.Equal(1, fake.count("/repos/o/r/pulls?page=2"))
+	require.Equal(1, fake.count("/repos/o/r/issues?page=1"))
+	require.Equal(1, fake.count("/repos/o/r/issues?page=2"))
+	require.Equal(2, fake.count("/repos/o/r/issues?page=3"))
+}
+
+func TestBackfillRepositories(t *testing.T) {
+	require := require.New(t)
+
+	srv, _, _, b := newTestBackfiller(t, map[string]int{})
+	defer srv.Close()
+	b.Repositories = []string{"o/unknown"}
+	err := b.Run(context.Background())
+	require.Error(err)
+	require.Contains(err.Error(), "repository not found: o/unknown")
+}
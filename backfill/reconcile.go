@@ -0,0 +1,359 @@
+package backfill
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/athenianco/metadata/github"
+	gh "github.com/google/go-github/v28/github"
+)
+
+// clockSkew is subtracted from the checkpoint, so entities updated while the previous reconciliation ran
+// aren't missed if clocks of GitHub and the reconciler differ.
+const clockSkew = time.Minute
+
+// ReconcileStore is where the reconciler compares and writes to, implemented by github.Database.
+type ReconcileStore interface {
+	Store
+
+	IsPullRequestStale(ctx context.Context, repo *gh.Repository, pr *gh.PullRequest) (bool, error)
+	IsPullRequestReviewStale(ctx context.Context, repo *gh.Repository, pr *gh.PullRequest, review *gh.PullRequestReview) (bool, error)
+	IsPullRequestReviewCommentStale(ctx context.Context, repo *gh.Repository, pr *gh.PullRequest, comment *gh.PullRequestComment) (bool, error)
+	IsIssueStale(ctx context.Context, repo *gh.Repository, issue *gh.Issue) (bool, error)
+	IsIssueCommentStale(ctx context.Context, repo *gh.Repository, issue *gh.Issue, comment *gh.IssueComment) (bool, error)
+	IsIssueCommentAsPullRequestStale(ctx context.Context, repo *gh.Repository, issue *gh.Issue, comment *gh.IssueComment) (bool, error)
+
+	ReconcileCheckpoint(ctx context.Context, repo string) (time.Time, bool, error)
+	SaveReconcileCheckpoint(ctx context.Context, repo string, since time.Time, repaired int) error
+}
+
+var _ ReconcileStore = (*github.Database)(nil)
+
+// Reconciler repairs gaps left by dropped webhook events. It periodically lists pull requests and issues
+// updated since the last reconciliation of the repository, compares them (with their reviews and comments)
+// to the versioned tables and upserts the missing or stale ones.
+type Reconciler struct {
+	// Backfiller lists repositories (see Org and Repositories) and is configured the same way.
+	*Backfiller
+
+	// Interval between reconciliations, see Run.
+	Interval time.Duration
+	// Lookback is how far back a repository, which was never reconciled, is compared.
+	Lookback time.Duration
+
+	store ReconcileStore
+}
+
+// NewReconciler creates a new reconciler.
+func NewReconciler(client *gh.Client, store ReconcileStore, interval, lookback time.Duration) *Reconciler {
+	return &Reconciler{
+		Backfiller: New(client, store),
+		Interval:   interval,
+		Lookback:   lookback,
+		store:      store,
+	}
+}
+
+// Run reconciles all repositories every interval until the context is canceled.
+func (r *Reconciler) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		if err := r.Reconcile(ctx); err != nil {
+			log.Printf("reconcile: %v\n", err)
+		}
+	}
+}
+
+// Reconcile reconciles all repositories. A failed repository doesn't stop the others,
+// it's reconciled from the same checkpoint the next time.
+func (r *Reconciler) Reconcile(ctx context.Context) error {
+	repos, err := r.repositories(ctx)
+	if err != nil {
+		return err
+	}
+
+	var failed int
+	for _, repo := range repos {
+		if _, err = r.ReconcileRepository(ctx, repo); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			log.Printf("reconcile %s: %v\n", repo.GetFullName(), err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("reconciliation of %d of %d repositories failed", failed, len(repos))
+	}
+	return nil
+}
+
+// ReconcileRepository reconciles entities of the repository updated since its checkpoint
+// and returns the number of repaired ones.
+func (r *Reconciler) ReconcileRepository(ctx context.Context, repo *gh.Repository) (int, error) {
+	name := repo.GetFullName()
+	start := time.Now()
+	since, ok, err := r.store.ReconcileCheckpoint(ctx, name)
+	if err != nil {
+		return 0, err
+	}
+	if ok {
+		since = since.Add(-clockSkew)
+	} else {
+		since = start.Add(-r.Lookback)
+	}
+
+	var repaired int
+	n, err := r.pullRequests(ctx, repo, since)
+	repaired += n
+	if err != nil {
+		return repaired, err
+	}
+	n, err = r.issues(ctx, repo, since)
+	repaired += n
+	if err != nil {
+		return repaired, err
+	}
+
+	if repaired > 0 {
+		log.Printf("repaired %d entities of %s updated since %s\n", repaired, name, since.UTC().Format(time.RFC3339))
+	}
+	return repaired, r.store.SaveReconcileCheckpoint(ctx, name, start, repaired)
+}
+
+// pullRequests reconciles pull requests updated since, with their reviews and review comments.
+// The pull requests endpoint has no since parameter, so they are listed by the last update
+// until the first one updated before since.
+func (r *Reconciler) pullRequests(ctx context.Context, repo *gh.Repository, since time.Time) (int, error) {
+	owner, name := repo.GetOwner().GetLogin(), repo.GetName()
+
+	var repaired int
+	for page := 1; page != 0; {
+		var prs []*gh.PullRequest
+		resp, err := r.do(ctx, func() (resp *gh.Response, err error) {
+			prs, resp, err = r.client.PullRequests.List(ctx, owner, name, &gh.PullRequestListOptions{
+				State:       "all",
+				Sort:        "updated",
+				Direction:   "desc",
+				ListOptions: gh.ListOptions{Page: page, PerPage: r.PerPage},
+			})
+			return resp, err
+		})
+		if err != nil {
+			return repaired, err
+		}
+
+		for _, pr := range prs {
+			if pr.GetUpdatedAt().Before(since) {
+				return repaired, nil
+			}
+			n, err := r.pullRequest(ctx, repo, pr, since)
+			repaired += n
+			if err != nil {
+				return repaired, fmt.Errorf("pull request #%d: %v", pr.GetNumber(), err)
+			}
+		}
+		page = resp.NextPage
+	}
+	return repaired, nil
+}
+
+func (r *Reconciler) pullRequest(ctx context.Context, repo *gh.Repository, pr *gh.PullRequest, since time.Time) (int, error) {
+	owner, name, number := repo.GetOwner().GetLogin(), repo.GetName(), pr.GetNumber()
+
+	var (
+		repaired int
+		users    []*gh.User
+	)
+	stale, err := r.store.IsPullRequestStale(ctx, repo, pr)
+	if err != nil {
+		return repaired, err
+	}
+	if stale {
+		// Listed pull requests lack some fields, so the full one is fetched.
+		_, err = r.do(ctx, func() (resp *gh.Response, err error) {
+			pr, resp, err = r.client.PullRequests.Get(ctx, owner, name, number)
+			return resp, err
+		})
+		if err != nil {
+			return repaired, err
+		}
+		if err = r.store.UpsertPullRequest(ctx, repo, pr); err != nil {
+			return repaired, err
+		}
+		repaired++
+		users = append(users, pr.GetUser(), pr.GetMergedBy())
+		users = append(users, pr.Assignees...)
+		users = append(users, pr.RequestedReviewers...)
+	}
+
+	for page := 1; page != 0; {
+		var reviews []*gh.PullRequestReview
+		resp, err := r.do(ctx, func() (resp *gh.Response, err error) {
+			reviews, resp, err = r.client.PullRequests.ListReviews(ctx, owner, name, number, &gh.ListOptions{Page: page, PerPage: r.PerPage})
+			return resp, err
+		})
+		if err != nil {
+			return repaired, err
+		}
+		for _, review := range reviews {
+			stale, err := r.store.IsPullRequestReviewStale(ctx, repo, pr, review)
+			if err != nil {
+				return repaired, err
+			}
+			if !stale {
+				continue
+			}
+			if err = r.store.UpsertPullRequestReview(ctx, repo, pr, review); err != nil {
+				return repaired, err
+			}
+			repaired++
+			users = append(users, review.GetUser())
+		}
+		page = resp.NextPage
+	}
+
+	for page := 1; page != 0; {
+		var comments []*gh.PullRequestComment
+		resp, err := r.do(ctx, func() (resp *gh.Response, err error) {
+			comments, resp, err = r.client.PullRequests.ListComments(ctx, owner, name, number, &gh.PullRequestListCommentsOptions{
+				Since:       since,
+				ListOptions: gh.ListOptions{Page: page, PerPage: r.PerPage},
+			})
+			return resp, err
+		})
+		if err != nil {
+			return repaired, err
+		}
+		for _, comment := range comments {
+			stale, err := r.store.IsPullRequestReviewCommentStale(ctx, repo, pr, comment)
+			if err != nil {
+				return repaired, err
+			}
+			if !stale {
+				continue
+			}
+			if err = r.store.UpsertPullRequestReviewComment(ctx, repo, pr, comment); err != nil {
+				return repaired, err
+			}
+			repaired++
+			users = append(users, comment.GetUser())
+		}
+		page = resp.NextPage
+	}
+
+	return repaired, r.insertUsers(ctx, repo, users)
+}
+
+// issues reconciles issues updated since with their comments. Like the API, issues include pull requests,
+// whose conversation comments are reconciled here too.
+func (r *Reconciler) issues(ctx context.Context, repo *gh.Repository, since time.Time) (int, error) {
+	owner, name := repo.GetOwner().GetLogin(), repo.GetName()
+
+	var repaired int
+	for page := 1; page != 0; {
+		var issues []*gh.Issue
+		resp, err := r.do(ctx, func() (resp *gh.Response, err error) {
+			issues, resp, err = r.client.Issues.ListByRepo(ctx, owner, name, &gh.IssueListByRepoOptions{
+				State:       "all",
+				Sort:        "updated",
+				Direction:   "asc",
+				Since:       since,
+				ListOptions: gh.ListOptions{Page: page, PerPage: r.PerPage},
+			})
+			return resp, err
+		})
+		if err != nil {
+			return repaired, err
+		}
+
+		for _, issue := range issues {
+			n, err := r.issue(ctx, repo, issue, since)
+			repaired += n
+			if err != nil {
+				return repaired, fmt.Errorf("issue #%d: %v", issue.GetNumber(), err)
+			}
+		}
+		page = resp.NextPage
+	}
+	return repaired, nil
+}
+
+func (r *Reconciler) issue(ctx context.Context, repo *gh.Repository, issue *gh.Issue, since time.Time) (int, error) {
+	owner, name := repo.GetOwner().GetLogin(), repo.GetName()
+
+	var (
+		repaired int
+		users    []*gh.User
+	)
+	if !issue.IsPullRequest() {
+		stale, err := r.store.IsIssueStale(ctx, repo, issue)
+		if err != nil {
+			return repaired, err
+		}
+		if stale {
+			if err = r.store.UpsertIssues(ctx, repo, issue); err != nil {
+				return repaired, err
+			}
+			repaired++
+			users = append(users, issue.GetUser(), issue.GetClosedBy())
+			users = append(users, issue.Assignees...)
+		}
+	}
+
+	if issue.Comments != nil && issue.GetComments() == 0 {
+		return repaired, r.insertUsers(ctx, repo, users)
+	}
+
+	for page := 1; page != 0; {
+		var comments []*gh.IssueComment
+		resp, err := r.do(ctx, func() (resp *gh.Response, err error) {
+			comments, resp, err = r.client.Issues.ListComments(ctx, owner, name, issue.GetNumber(), &gh.IssueListCommentsOptions{
+				Since:       since,
+				ListOptions: gh.ListOptions{Page: page, PerPage: r.PerPage},
+			})
+			return resp, err
+		})
+		if err != nil {
+			return repaired, err
+		}
+		for _, comment := range comments {
+			var stale bool
+			if issue.IsPullRequest() {
+				stale, err = r.store.IsIssueCommentAsPullRequestStale(ctx, repo, issue, comment)
+			} else {
+				stale, err = r.store.IsIssueCommentStale(ctx, repo, issue, comment)
+			}
+			if err != nil {
+				return repaired, err
+			}
+			if !stale {
+				continue
+			}
+
+			if issue.IsPullRequest() {
+				err = r.store.UpsertIssueCommentAsPullRequest(ctx, repo, issue, comment)
+			} else {
+				err = r.store.UpsertIssueComment(ctx, repo, issue, comment)
+			}
+			if err != nil {
+				return repaired, err
+			}
+			repaired++
+			users = append(users, comment.GetUser())
+		}
+		page = resp.NextPage
+	}
+
+	return repaired, r.insertUsers(ctx, repo, users)
+}
@@ -0,0 +1,129 @@
+package backfill
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// reconcileResources are pull requests and issues of o/r, pull request #1 wasn't updated since 2020-01-01.
+var reconcileResources = map[string]string{
+	"/installation/repositories": fakeResources["/installation/repositories"],
+	"/repos/o/r/pulls": `[
+		{"id": 102, "number": 2, "updated_at": "2020-01-05T00:00:00Z"},
+		{"id": 101, "number": 1, "updated_at": "2019-12-01T00:00:00Z"}
+	]`,
+	"/repos/o/r/pulls/2/reviews":  `[{"id": 202, "submitted_at": "2020-01-04T00:00:00Z", "state": "DISMISSED", "body": "LGTM", "user": {"id": 21, "login": "bob"}}]`,
+	"/repos/o/r/pulls/2/comments": `[{"id": 303, "updated_at": "2020-01-04T00:00:00Z", "user": {"id": 20, "login": "alice"}}]`,
+	"/repos/o/r/issues": `[
+		{"id": 1003, "number": 3, "comments": 1, "updated_at": "2020-01-03T00:00:00Z", "user": {"id": 23, "login": "dave"}},
+		{"id": 1002, "number": 2, "comments": 1, "updated_at": "2020-01-05T00:00:00Z", "pull_request": {"url": "https://api.github.com/repos/o/r/pulls/2"}}
+	]`,
+	"/repos/o/r/issues/2/comments": `[{"id": 503, "updated_at": "2020-01-04T00:00:00Z", "user": {"id": 22, "login": "carol"}}]`,
+	"/repos/o/r/issues/3/comments": `[{"id": 502, "updated_at": "2020-01-03T00:00:00Z", "user": {"id": 20, "login": "alice"}}]`,
+}
+
+func TestReconcile(t *testing.T) {
+	require := require.New(t)
+
+	srv, fake, client := newTestClient(t, reconcileResources, map[string]int{})
+	defer srv.Close()
+
+	store := newMemoryStore()
+	at := func(s string) time.Time {
+		t, err := time.Parse(time.RFC3339, s)
+		require.NoError(err)
+		return t
+	}
+	// up to date
+	store.put("pull_request 102", at("2020-01-05T00:00:00Z"))
+	store.put("issue 1003", at("2020-01-03T00:00:00Z"))
+	store.put("issue_comment 1003/502", at("2020-01-03T00:00:00Z"))
+	// stale
+	store.put("review_comment 102/303", at("2020-01-01T00:00:00Z"))
+	// review 102/202 and pull_request_comment 1002/503 are missing
+	store.reconciled["o/r"] = at("2020-01-01T00:00:00Z")
+
+	r := NewReconciler(client, store, time.Hour, 0)
+	r.PerPage = 1
+	start := time.Now()
+	require.NoError(r.Reconcile(context.Background()))
+
+	require.Equal(3, store.repaired["o/r"])
+	require.True(store.rows["review 102/202"])
+	require.True(store.rows["pull_request_comment 1002/503"])
+	require.Equal(at("2020-01-04T00:00:00Z"), store.updatedAt["review_comment 102/303"])
+	require.Equal(map[string]bool{"o/alice": true, "o/bob": true, "o/carol": true}, store.users)
+	require.False(store.reconciled["o/r"].Before(start))
+
+	// up to date pull request isn't fetched, the listing stops at the first one updated before the checkpoint
+	require.Equal(0, fake.count("/repos/o/r/pulls/2?page=1"))
+	require.Equal(0, fake.count("/repos/o/r/pulls/1/reviews?page=1"))
+	require.Equal(1, fake.count("/repos/o/r/pulls?page=2"))
+	require.Equal(0, fake.count("/repos/o/r/pulls?page=3"))
+
+	// the checkpoint (minus the clock skew) is passed as since
+	require.Equal("2019-12-31T23:59:00Z", fake.query("/repos/o/r/issues").Get("since"))
+	require.Equal("updated", fake.query("/repos/o/r/issues").Get("sort"))
+}
+
+func TestReconcileDismissedReview(t *testing.T) {
+	require := require.New(t)
+
+	srv, _, client := newTestClient(t, reconcileResources, map[string]int{})
+	defer srv.Close()
+
+	store := newMemoryStore()
+	at := func(s string) time.Time {
+		t, err := time.Parse(time.RFC3339, s)
+		require.NoError(err)
+		return t
+	}
+	store.put("pull_request 102", at("2020-01-05T00:00:00Z"))
+	store.put("review_comment 102/303", at("2020-01-04T00:00:00Z"))
+	store.put("issue 1003", at("2020-01-03T00:00:00Z"))
+	store.put("issue_comment 1003/502", at("2020-01-03T00:00:00Z"))
+	store.put("pull_request_comment 1002/503", at("2020-01-04T00:00:00Z"))
+	// the review was dismissed after it was stored, submitted_at is the same
+	store.put("review 102/202", at("2020-01-04T00:00:00Z"))
+	store.reviews["review 102/202"] = "APPROVED LGTM"
+	store.reconciled["o/r"] = at("2020-01-01T00:00:00Z")
+
+	r := NewReconciler(client, store, time.Hour, 0)
+	require.NoError(r.Reconcile(context.Background()))
+
+	require.Equal(1, store.repaired["o/r"])
+	require.Equal("DISMISSED LGTM", store.reviews["review 102/202"])
+}
+
+func TestReconcileLookback(t *testing.T) {
+	require := require.New(t)
+
+	srv, fake, client := newTestClient(t, reconcileResources, map[string]int{"/repos/o/r/issues?page=1": 1})
+	defer srv.Close()
+
+	store := newMemoryStore()
+	r := NewReconciler(client, store, time.Hour, 24*time.Hour)
+	repos, err := r.repositories(context.Background())
+	require.NoError(err)
+	require.Len(repos, 1)
+
+	// the failed repository keeps its checkpoint
+	_, err = r.ReconcileRepository(context.Background(), repos[0])
+	require.Error(err)
+	_, ok := store.reconciled["o/r"]
+	require.False(ok)
+
+	since, err := time.Parse(time.RFC3339, fake.query("/repos/o/r/issues").Get("since"))
+	require.NoError(err)
+	require.WithinDuration(time.Now().Add(-24*time.Hour), since, time.Minute)
+
+	// nothing was updated in the last day
+	n, err := r.ReconcileRepository(context.Background(), repos[0])
+	require.NoError(err)
+	require.Equal(0, n)
+	require.Empty(store.rows)
+	require.Equal(0, fake.count("/repos/o/r/pulls/2/reviews?page=1"))
+}
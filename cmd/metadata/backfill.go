@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/athenianco/metadata/backfill"
+	gh "github.com/google/go-github/v28/github"
+)
+
+// backfillCmd loads existing repositories of the installation (or the organization) from the GitHub API
+// into the database. It resumes from checkpoints, so it can be simply run again after a failure.
+func backfillCmd(args []string) error {
+	flags := flag.NewFlagSet("backfill", flag.ExitOnError)
+	configPath := flags.String("config", "", "path to the YAML config file")
+	gf := addGitHubFlags(flags)
+	restart := flags.Bool("restart", false, "discard checkpoints of -repositories and backfill them from the beginning")
+	flags.Parse(args)
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return fmt.Errorf("config: %v", err)
+	}
+	gf.apply(cfg)
+	if *restart && len(cfg.GitHub.Repositories) == 0 {
+		return errors.New("-restart requires -repositories")
+	}
+	if cfg.GitHub.InstallationID == 0 {
+		return errors.New("github installation id is required, rows are written for the installation")
+	}
+	client, err := githubClient(cfg)
+	if err != nil {
+		return err
+	}
+	db, err := openDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	b := backfill.New(client, db.ForInstallation(cfg.GitHub.InstallationID))
+	b.Org = cfg.GitHub.Org
+	b.Repositories = cfg.GitHub.Repositories
+	if *restart {
+		for _, repo := range b.Repositories {
+			if err = db.ResetBackfillCheckpoints(ctx, repo); err != nil {
+				return err
+			}
+		}
+	}
+	return b.Run(ctx)
+}
+
+// reconcileCmd reconciles repositories with the GitHub API once (e.g. from cron),
+// the server does it periodically (see reconciler.interval).
+func reconcileCmd(args []string) error {
+	flags := flag.NewFlagSet("reconcile", flag.ExitOnError)
+	configPath := flags.String("config", "", "path to the YAML config file")
+	gf := addGitHubFlags(flags)
+	flags.Parse(args)
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return fmt.Errorf("config: %v", err)
+	}
+	gf.apply(cfg)
+	if cfg.GitHub.InstallationID == 0 {
+		return errors.New("github installation id is required, rows are written for the installation")
+	}
+	client, err := githubClient(cfg)
+	if err != nil {
+		return err
+	}
+	db, err := openDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	r := backfill.NewReconciler(client, db.ForInstallation(cfg.GitHub.InstallationID), cfg.Reconciler.Interval, cfg.Reconciler.Lookback)
+	r.Org = cfg.GitHub.Org
+	r.Repositories = cfg.GitHub.Repositories
+	return r.Reconcile(context.Background())
+}
+
+// githubFlags override the github section of the config.
+type githubFlags struct {
+	appID          *int64
+	installationID *int64
+	privateKey     *string
+	org            *string
+	repositories   *string
+}
+
+func addGitHubFlags(flags *flag.FlagSet) *githubFlags {
+	return &githubFlags{
+		appID:          flags.Int64("app-id", 0, "GitHub App ID"),
+		installationID: flags.Int64("installation", 0, "installation ID of the GitHub App (rows are written for it)"),
+		privateKey:     flags.String("private-key", "", "path to the PEM private key of the GitHub App"),
+		org:            flags.String("org", "", "repositories of the organization (with GITHUB_TOKEN and the organization's -installation) instead of the App"),
+		repositories:   flags.String("repositories", "", "comma separated repositories (full names), all repositories if empty"),
+	}
+}
+
+func (f *githubFlags) apply(cfg *config) {
+	if *f.appID != 0 {
+		cfg.GitHub.AppID = *f.appID
+	}
+	if *f.installationID != 0 {
+		cfg.GitHub.InstallationID = *f.installationID
+	}
+	setDefault(f.privateKey, cfg.GitHub.PrivateKey)
+	cfg.GitHub.PrivateKey = *f.privateKey
+	setDefault(f.org, cfg.GitHub.Org)
+	cfg.GitHub.Org = *f.org
+	if repos := splitList(*f.repositories); len(repos) > 0 {
+		cfg.GitHub.Repositories = repos
+	}
+}
+
+// githubClient returns the GitHub API client authenticated as the App's installation,
+// or with the personal access token if the organization is set.
+func githubClient(cfg *config) (*gh.Client, error) {
+	var transport http.RoundTripper
+	switch {
+	case cfg.GitHub.Org != "":
+		if cfg.GitHub.Token == "" {
+			return nil, errors.New("github token is not set")
+		}
+		transport = &backfill.TokenTransport{Token: cfg.GitHub.Token}
+	case cfg.GitHub.AppID != 0 || cfg.GitHub.InstallationID != 0:
+		if cfg.GitHub.AppID == 0 || cfg.GitHub.InstallationID == 0 || cfg.GitHub.PrivateKey == "" {
+			return nil, errors.New("github app id, installation id and private key are required")
+		}
+		key, err := ioutil.ReadFile(cfg.GitHub.PrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		if transport, err = backfill.NewAppTransport(cfg.GitHub.AppID, cfg.GitHub.InstallationID, key, ""); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.New("either github installation or organization is required")
+	}
+	return gh.NewClient(&http.Client{Transport: transport}), nil
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// config of the metadata server. Secrets may be left empty in the file
+// and passed in the same environment variables as for Cloud Functions (see Makefile).
+type config struct {
+	// Addr is the address the HTTP server listens on.
+	Addr string `yaml:"addr"`
+	// ShutdownTimeout is how long in-flight requests, and then in-flight (and buffered) messages, are awaited on shutdown.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+
+	Database struct {
+		URI          string `yaml:"uri"`
+		MaxOpenConns int    `yaml:"max_open_conns"`
+		MaxIdleConns int    `yaml:"max_idle_conns"`
+		// Migrate applies pending schema migrations on startup.
+		Migrate bool `yaml:"migrate"`
+	} `yaml:"database"`
+
+	Webhook struct {
+		// Path of the github webhook endpoint. Empty path disables the webhook.
+		Path      string `yaml:"path"`
+		SecretKey string `yaml:"secret_key"`
+	} `yaml:"webhook"`
+
+	Processor struct {
+		// Path of the Pub/Sub push endpoint. Empty path disables the endpoint.
+		Path string `yaml:"path"`
+		// Consume enables pulling messages from the bus by the server itself.
+		Consume bool `yaml:"consume"`
+	} `yaml:"processor"`
+
+	Bus struct {
+		// Type is one of: gcp, memory, filesystem.
+		Type string `yaml:"type"`
+		// Topic and Subscription are used by the gcp bus.
+		Topic        string `yaml:"topic"`
+		Subscription string `yaml:"subscription"`
+		// Dir is used by the filesystem bus.
+		Dir string `yaml:"dir"`
+		// Size is the buffer size of the memory bus.
+		Size int `yaml:"size"`
+	} `yaml:"bus"`
+
+	Metrics struct {
+		// Path of the metrics API. Empty path disables the API.
+		Path string `yaml:"path"`
+	} `yaml:"metrics"`
+
+	Refresher struct {
+		// Interval between refreshes of all materialized views, 0 disables the schedule.
+		Interval time.Duration `yaml:"interval"`
+		// Threshold is the number of changed rows which triggers the refresh of the view, 0 disables it.
+		Threshold int `yaml:"threshold"`
+		// Path of the endpoint with last refresh times. Empty path disables the endpoint.
+		Path string `yaml:"path"`
+	} `yaml:"refresher"`
+
+	Reconciler struct {
+		// Interval between reconciliations of repositories with the GitHub API, 0 disables the reconciler.
+		Interval time.Duration `yaml:"interval"`
+		// Lookback is how far back a repository, which was never reconciled, is compared.
+		Lookback time.Duration `yaml:"lookback"`
+	} `yaml:"reconciler"`
+
+	Purger struct {
+		// Interval between purges of uninstalled installations and removed repositories, 0 disables the purger.
+		Interval time.Duration `yaml:"interval"`
+		// Retention is how long rows are kept after the installation was deleted or the repository removed.
+		Retention time.Duration `yaml:"retention"`
+		// DeliveryRetention is how long processed deliveries are kept to skip their redeliveries.
+		DeliveryRetention time.Duration `yaml:"delivery_retention"`
+	} `yaml:"purger"`
+
+	// GitHub API access of the backfill and the reconciler.
+	GitHub struct {
+		// AppID, InstallationID and PrivateKey (path to the PEM file) authenticate as the App's installation.
+		// Events of other Apps than AppID are rejected by the webhook and ignored by the processor.
+		// Rows written by the backfill and the reconciler belong to InstallationID, so it's required with Org too.
+		AppID          int64  `yaml:"app_id"`
+		InstallationID int64  `yaml:"installation_id"`
+		PrivateKey     string `yaml:"private_key"`
+		// Org selects repositories of the organization with the personal access Token instead of the installation.
+		Org   string `yaml:"org"`
+		Token string `yaml:"token"`
+		// Repositories (full names) limits the repositories, all repositories if empty.
+		Repositories []string `yaml:"repositories"`
+	} `yaml:"github"`
+
+	Archive struct {
+		// URI of the raw events storage (see archive.OpenStorage). Empty URI disables the archive.
+		URI string `yaml:"uri"`
+		// BatchSize is the number of events written to the storage at once.
+		BatchSize int `yaml:"batch_size"`
+	} `yaml:"archive"`
+}
+
+// loadConfig reads the config file (optional) and fills missing values
+// from the environment and defaults.
+func loadConfig(path string) (*config, error) {
+	cfg := &config{}
+	if path != "" {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err = yaml.UnmarshalStrict(data, cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	setDefault(&cfg.Database.URI, os.Getenv("GITHUB_DATABASE_URI"))
+	setDefault(&cfg.Webhook.SecretKey, os.Getenv("GITHUB_WEBHOOK_SECRET_KEY"))
+	setDefault(&cfg.Bus.Topic, os.Getenv("GITHUB_WEBHOOK_TOPIC"))
+	setDefault(&cfg.Archive.URI, os.Getenv("GITHUB_ARCHIVE_URI"))
+	setDefault(&cfg.GitHub.Token, os.Getenv("GITHUB_TOKEN"))
+	if cfg.GitHub.AppID == 0 {
+		cfg.GitHub.AppID, _ = strconv.ParseInt(os.Getenv("GITHUB_APP_ID"), 10, 64)
+	}
+
+	setDefault(&cfg.Addr, ":8080")
+	setDefault(&cfg.Bus.Type, "memory")
+	if cfg.ShutdownTimeout == 0 {
+		cfg.ShutdownTimeout = 30 * time.Second
+	}
+	if cfg.Database.MaxOpenConns == 0 {
+		cfg.Database.MaxOpenConns = 4
+	}
+	if cfg.Database.MaxIdleConns == 0 {
+		cfg.Database.MaxIdleConns = 4
+	}
+	if cfg.Bus.Size == 0 {
+		cfg.Bus.Size = 1024
+	}
+	if cfg.Reconciler.Lookback == 0 {
+		cfg.Reconciler.Lookback = 7 * 24 * time.Hour
+	}
+	if cfg.Purger.Retention == 0 {
+		cfg.Purger.Retention = 30 * 24 * time.Hour
+	}
+	if cfg.Purger.DeliveryRetention == 0 {
+		cfg.Purger.DeliveryRetention = 14 * 24 * time.Hour
+	}
+	if cfg.Archive.BatchSize == 0 {
+		cfg.Archive.BatchSize = 100
+	}
+	return cfg, nil
+}
+
+func setDefault(v *string, def string) {
+	if *v == "" {
+		*v = def
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig(t *testing.T) {
+	require := require.New(t)
+
+	os.Setenv("GITHUB_WEBHOOK_SECRET_KEY", "secret-token")
+	defer os.Unsetenv("GITHUB_WEBHOOK_SECRET_KEY")
+
+	cfg, err := loadConfig("config.example.yaml")
+	require.NoError(err)
+	require.Equal(":8080", cfg.Addr)
+	require.Equal(30*time.Second, cfg.ShutdownTimeout)
+	require.Equal("/github", cfg.Webhook.Path)
+	require.Equal("secret-token", cfg.Webhook.SecretKey)
+	require.Equal("filesystem", cfg.Bus.Type)
+	require.True(cfg.Processor.Consume)
+	require.True(cfg.Database.Migrate)
+	require.Equal(15*time.Minute, cfg.Refresher.Interval)
+	require.Equal(1000, cfg.Refresher.Threshold)
+	require.Equal("/api/metrics", cfg.Metrics.Path)
+	require.Equal("/tmp/metadata-archive", cfg.Archive.URI)
+	require.Equal(100, cfg.Archive.BatchSize)
+	require.Equal(time.Duration(0), cfg.Reconciler.Interval)
+	require.Equal(168*time.Hour, cfg.Reconciler.Lookback)
+	require.Equal(24*time.Hour, cfg.Purger.Interval)
+	require.Equal(720*time.Hour, cfg.Purger.Retention)
+	require.Equal(336*time.Hour, cfg.Purger.DeliveryRetention)
+	require.Empty(cfg.GitHub.Repositories)
+
+	cfg, err = loadConfig("")
+	require.NoError(err)
+	require.Equal("memory", cfg.Bus.Type)
+	require.Equal(1024, cfg.Bus.Size)
+	require.Equal("", cfg.Webhook.Path)
+
+	f, err := ioutil.TempFile("", "config")
+	require.NoError(err)
+	defer os.Remove(f.Name())
+	_, err = f.WriteString("unknown: field\n")
+	require.NoError(err)
+	require.NoError(f.Close())
+
+	_, err = loadConfig(f.Name())
+	require.Error(err)
+}
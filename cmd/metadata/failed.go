@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/athenianco/metadata/github"
+)
+
+const failedUsage = `usage: metadata failed [-config config.yaml] <action>
+
+actions:
+  list [-all]               list parked (or all failed) events
+  show <delivery_id>        print the payload (encoded event) of the failed event
+  fix <delivery_id> <file>  replace the payload of the failed event by the file content
+  requeue <delivery_id>...  re-publish failed events to the message bus
+  delete <delivery_id>...   drop failed events without processing them`
+
+// failed inspects, fixes and requeues events which failed processing (failed_events).
+func failed(args []string) error {
+	flags := flag.NewFlagSet("failed", flag.ExitOnError)
+	flags.Usage = func() { fmt.Fprintln(flags.Output(), failedUsage) }
+	configPath := flags.String("config", "", "path to the YAML config file")
+	all := flags.Bool("all", false, "list also failed events which are still retried")
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("missing action")
+	}
+	action, args := flags.Arg(0), flags.Args()[1:]
+	// Flags may follow the action as well (e.g. failed list -all).
+	flags.Parse(args)
+	args = flags.Args()
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return fmt.Errorf("config: %v", err)
+	}
+	if cfg.Database.URI == "" {
+		return errors.New("database uri is not set")
+	}
+	db, err := github.OpenDatabase(cfg.Database.URI, 1, 1)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	switch action {
+	case "list":
+		events, err := db.FailedEvents(ctx, !*all)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "DELIVERY ID\tTYPE\tATTEMPTS\tPERMANENT\tFIRST SEEN\tLAST SEEN\tPARKED\tERROR")
+		for _, e := range events {
+			fmt.Fprintf(w, "%s\t%s\t%d\t%t\t%s\t%s\t%t\t%s\n",
+				e.DeliveryID, e.EventType, e.Attempts, e.Permanent,
+				e.FirstSeenAt.Format(time.RFC3339), e.LastSeenAt.Format(time.RFC3339), e.ParkedAt != nil, e.Error)
+		}
+		return w.Flush()
+
+	case "show":
+		if len(args) != 1 {
+			return errors.New("show requires the delivery id")
+		}
+		e, err := db.FailedEvent(ctx, args[0])
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(e.Payload)
+		return err
+
+	case "fix":
+		if len(args) != 2 {
+			return errors.New("fix requires the delivery id and the payload file")
+		}
+		payload, err := ioutil.ReadFile(args[1])
+		if err != nil {
+			return err
+		}
+		if _, err = github.UnmarshalEvent(payload); err != nil {
+			return fmt.Errorf("invalid payload: %v", err)
+		}
+		return db.UpdateFailedEventPayload(ctx, args[0], payload)
+
+	case "requeue":
+		publisher, err := openPublisher(cfg)
+		if err != nil {
+			return err
+		}
+		for _, id := range args {
+			if err = github.RequeueFailedEvent(ctx, db, publisher, id); err != nil {
+				return fmt.Errorf("%s: %v", id, err)
+			}
+		}
+		return nil
+
+	case "delete":
+		for _, id := range args {
+			if err = db.DeleteFailedEvent(ctx, id); err != nil {
+				return fmt.Errorf("%s: %v", id, err)
+			}
+		}
+		return nil
+	}
+
+	flags.Usage()
+	return fmt.Errorf("unknown action: %q", action)
+}
@@ -0,0 +1,330 @@
+// Command metadata is a standalone HTTP server which serves the github webhook,
+// the Pub/Sub push endpoint of the processor, the metrics API and health checks.
+// Unlike Cloud Functions, it can run the whole pipeline (webhook -> bus -> processor) in a single process.
+//
+// Usage:
+//
+//	metadata [serve] -config config.yaml
+//	metadata replay -config config.yaml -from 2020-01-01T00:00:00Z [-to ...] [-types push,issues] [-deliveries ...]
+//	metadata failed -config config.yaml list|show|fix|requeue|delete ...
+//	metadata migrate -config config.yaml up|goto|force|version ...
+//	metadata backfill -config config.yaml -app-id 1 -installation 2 -private-key key.pem [-repositories ...]
+//	metadata reconcile -config config.yaml [-repositories ...]
+//	metadata purge -config config.yaml [-retention 720h]
+//	metadata scope -config config.yaml -installation 2
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/athenianco/metadata/archive"
+	"github.com/athenianco/metadata/backfill"
+	"github.com/athenianco/metadata/github"
+	"github.com/athenianco/metadata/metrics"
+	"github.com/athenianco/metadata/pubsub"
+)
+
+func main() {
+	cmd, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		cmd, args = args[0], args[1:]
+	}
+
+	var err error
+	switch cmd {
+	case "serve":
+		err = serve(args)
+	case "replay":
+		err = replay(args)
+	case "failed":
+		err = failed(args)
+	case "migrate":
+		err = migrate(args)
+	case "backfill":
+		err = backfillCmd(args)
+	case "reconcile":
+		err = reconcileCmd(args)
+	case "purge":
+		err = purgeCmd(args)
+	case "scope":
+		err = scopeCmd(args)
+	default:
+		err = fmt.Errorf("unknown command: %q", cmd)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func serve(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	configPath := flags.String("config", "", "path to the YAML config file")
+	flags.Parse(args)
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return fmt.Errorf("config: %v", err)
+	}
+	return run(cfg)
+}
+
+func run(cfg *config) error {
+	db, err := openDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	publisher, consumer, err := openBus(cfg)
+	if err != nil {
+		return err
+	}
+
+	var shuttingDown int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&shuttingDown) != 0 {
+			http.Error(w, "shutting down", http.StatusServiceUnavailable)
+			return
+		}
+		if err := db.PingContext(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	var (
+		storage  archive.Storage
+		archiver *archive.Archiver
+	)
+	if cfg.Archive.URI != "" {
+		if storage, err = archive.OpenStorage(cfg.Archive.URI); err != nil {
+			return err
+		}
+		archiver = archive.NewArchiver(storage, cfg.Archive.BatchSize)
+	}
+
+	if cfg.Webhook.Path != "" {
+		if cfg.Webhook.SecretKey == "" {
+			return errors.New("webhook secret key is not set")
+		}
+		onEvent := github.Publisher(publisher)
+		if archiver != nil {
+			onEvent = archiver.OnEvent(onEvent)
+		}
+		mux.Handle(cfg.Webhook.Path, &github.Webhook{
+			SecretKey: []byte(cfg.Webhook.SecretKey),
+			AppID:     cfg.GitHub.AppID,
+			OnEvent:   onEvent,
+		})
+	}
+
+	var refresher *github.Refresher
+	if cfg.Refresher.Interval > 0 || cfg.Refresher.Threshold > 0 {
+		refresher = github.NewRefresher(db, cfg.Refresher.Interval, cfg.Refresher.Threshold)
+		db.OnChange = refresher.Changed
+		if cfg.Refresher.Path != "" {
+			mux.Handle(cfg.Refresher.Path, refresher)
+		}
+	}
+
+	var reconciler *backfill.Reconciler
+	if cfg.Reconciler.Interval > 0 {
+		if cfg.GitHub.InstallationID == 0 {
+			return errors.New("reconciler: github installation id is required")
+		}
+		client, err := githubClient(cfg)
+		if err != nil {
+			return fmt.Errorf("reconciler: %v", err)
+		}
+		reconciler = backfill.NewReconciler(client, db.ForInstallation(cfg.GitHub.InstallationID), cfg.Reconciler.Interval, cfg.Reconciler.Lookback)
+		reconciler.Org = cfg.GitHub.Org
+		reconciler.Repositories = cfg.GitHub.Repositories
+	}
+
+	var purger *github.Purger
+	if cfg.Purger.Interval > 0 {
+		purger = github.NewPurger(db, cfg.Purger.Interval, cfg.Purger.Retention)
+		purger.DeliveryRetention = cfg.Purger.DeliveryRetention
+		if storage != nil {
+			purger.PurgeArchive = purgeArchive(storage)
+		}
+	}
+
+	if cfg.Metrics.Path != "" {
+		mux.Handle(cfg.Metrics.Path, &metrics.Handler{DB: db})
+	}
+
+	processor := github.Processor(db)
+	if cfg.Processor.Path != "" {
+		mux.Handle(cfg.Processor.Path, &pubsub.PushHandler{Subscriber: processor})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// Consumed messages are processed with their own context, so the ones in flight (and the ones buffered
+	// in the memory bus) are finished after the consumer stopped receiving, until the shutdown timeout.
+	processCtx, processCancel := context.WithCancel(context.Background())
+	defer processCancel()
+
+	var consumers sync.WaitGroup
+	if cfg.Processor.Consume {
+		consumers.Add(1)
+		go func() {
+			defer consumers.Done()
+			err := consumer.Consume(ctx, func(_ context.Context, msg pubsub.Message) error {
+				return processor(processCtx, msg)
+			})
+			if err != nil {
+				log.Printf("consume: %v\n", err)
+			}
+		}()
+	}
+
+	var wg sync.WaitGroup
+	if refresher != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			refresher.Run(ctx)
+		}()
+	}
+	if reconciler != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			reconciler.Run(ctx)
+		}()
+	}
+	if purger != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			purger.Run(ctx)
+		}()
+	}
+
+	srv := &http.Server{Addr: cfg.Addr, Handler: mux}
+	errc := make(chan error, 1)
+	go func() {
+		log.Printf("listening on %s\n", cfg.Addr)
+		errc <- srv.ListenAndServe()
+	}()
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+	select {
+	case err = <-errc:
+		return err
+	case s := <-sig:
+		log.Printf("received %s, shutting down\n", s)
+	}
+
+	atomic.StoreInt32(&shuttingDown, 1)
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer shutdownCancel()
+	err = srv.Shutdown(shutdownCtx)
+	if archiver != nil {
+		if ferr := archiver.Flush(shutdownCtx); ferr != nil {
+			log.Printf("archive: %v\n", ferr)
+		}
+	}
+
+	// Stop receiving only after the webhook stopped publishing, then drain the in-flight messages
+	// with a separate timeout, before the database is closed.
+	cancel()
+	drainCtx, drainCancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer drainCancel()
+	drained := make(chan struct{})
+	go func() {
+		consumers.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-drainCtx.Done():
+		log.Printf("in-flight messages not processed in %v, canceling them\n", cfg.ShutdownTimeout)
+		processCancel()
+		<-drained
+	}
+	wg.Wait()
+	return err
+}
+
+// openDatabase opens the configured database, applying migrations if enabled.
+func openDatabase(cfg *config) (*github.Database, error) {
+	if cfg.Database.URI == "" {
+		return nil, errors.New("database uri is not set")
+	}
+	var opts []github.DatabaseOption
+	if cfg.Database.Migrate {
+		opts = append(opts, github.WithMigrations())
+	}
+	db, err := github.OpenDatabase(cfg.Database.URI, cfg.Database.MaxOpenConns, cfg.Database.MaxIdleConns, opts...)
+	if err != nil {
+		return nil, err
+	}
+	db.AppID = cfg.GitHub.AppID
+	return db, nil
+}
+
+// openBus returns the publisher and the consumer of the configured message bus.
+func openBus(cfg *config) (pubsub.Publisher, pubsub.Consumer, error) {
+	switch cfg.Bus.Type {
+	case "gcp":
+		publisher, err := pubsub.NewGCPPublisher(cfg.Bus.Topic)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !cfg.Processor.Consume {
+			return publisher, nil, nil
+		}
+		consumer, err := pubsub.NewGCPConsumer(cfg.Bus.Topic, cfg.Bus.Subscription)
+		if err != nil {
+			return nil, nil, err
+		}
+		return publisher, consumer, nil
+
+	case "memory":
+		if !cfg.Processor.Consume {
+			return nil, nil, errors.New("memory bus requires processor.consume")
+		}
+		bus := pubsub.NewMemory(cfg.Bus.Size)
+		return bus, bus, nil
+
+	case "filesystem":
+		bus, err := pubsub.NewFilesystem(cfg.Bus.Dir)
+		if err != nil {
+			return nil, nil, err
+		}
+		return bus, bus, nil
+	}
+
+	return nil, nil, fmt.Errorf("unknown bus type: %q", cfg.Bus.Type)
+}
+
+// openPublisher returns the publisher of the configured message bus for commands
+// which run next to the server (e.g. replay), so the in-process memory bus can't be used.
+func openPublisher(cfg *config) (pubsub.Publisher, error) {
+	if cfg.Bus.Type == "memory" {
+		return nil, errors.New("memory bus can not be published to from another process")
+	}
+	cfg.Processor.Consume = false
+	publisher, _, err := openBus(cfg)
+	return publisher, err
+}
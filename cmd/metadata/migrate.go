@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+
+	"github.com/athenianco/metadata/migrations"
+)
+
+const migrateUsage = `usage: metadata migrate [-config config.yaml] <action>
+
+actions:
+  up                 apply all pending migrations
+  goto <version>     migrate up or down to the version (0 reverts all migrations)
+  force <version>    set the version and clear the dirty flag without migrating
+  version            print the current version`
+
+// migrate applies schema migrations to the configured database.
+func migrate(args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	flags.Usage = func() { fmt.Fprintln(flags.Output(), migrateUsage) }
+	configPath := flags.String("config", "", "path to the YAML config file")
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("missing action")
+	}
+	action, args := flags.Arg(0), flags.Args()[1:]
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return fmt.Errorf("config: %v", err)
+	}
+	if cfg.Database.URI == "" {
+		return errors.New("database uri is not set")
+	}
+	db, err := sql.Open("postgres", cfg.Database.URI)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	switch action {
+	case "up":
+		return migrations.Up(ctx, db)
+
+	case "goto", "force":
+		if len(args) != 1 {
+			return fmt.Errorf("%s requires the version", action)
+		}
+		version, err := strconv.ParseUint(args[0], 10, 32)
+		if err != nil {
+			return err
+		}
+		if action == "force" {
+			return migrations.Force(ctx, db, uint(version))
+		}
+		return migrations.Migrate(ctx, db, uint(version))
+
+	case "version":
+		version, dirty, err := migrations.Version(ctx, db)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("version: %d, dirty: %t, latest: %d\n", version, dirty, migrations.Latest())
+		return nil
+	}
+
+	flags.Usage()
+	return fmt.Errorf("unknown action: %q", action)
+}
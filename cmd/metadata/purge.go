@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/athenianco/metadata/archive"
+	"github.com/athenianco/metadata/github"
+)
+
+// purgeCmd hard-deletes rows (and archived events) of uninstalled installations and removed repositories
+// and old processed deliveries once (e.g. from cron), the server does it periodically (see purger.interval).
+func purgeCmd(args []string) error {
+	flags := flag.NewFlagSet("purge", flag.ExitOnError)
+	configPath := flags.String("config", "", "path to the YAML config file")
+	retention := flags.Duration("retention", 0, "how long rows are kept after the uninstall or the removal (overrides purger.retention)")
+	flags.Parse(args)
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return fmt.Errorf("config: %v", err)
+	}
+	if *retention > 0 {
+		cfg.Purger.Retention = *retention
+	}
+	db, err := openDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	purger := github.NewPurger(db, cfg.Purger.Interval, cfg.Purger.Retention)
+	purger.DeliveryRetention = cfg.Purger.DeliveryRetention
+	if cfg.Archive.URI != "" {
+		storage, err := archive.OpenStorage(cfg.Archive.URI)
+		if err != nil {
+			return err
+		}
+		purger.PurgeArchive = purgeArchive(storage)
+	}
+	return purger.Purge(context.Background())
+}
+
+// purgeArchive returns the purger's PurgeArchive, which deletes events of purged installations
+// and repositories from the archive.
+func purgeArchive(storage archive.Storage) func(ctx context.Context, since time.Time, purged func(event *github.Event) bool) error {
+	return func(ctx context.Context, since time.Time, purged func(event *github.Event) bool) error {
+		n, err := archive.Purge(ctx, storage, since, purged)
+		if n > 0 {
+			log.Printf("purged %d archived events\n", n)
+		}
+		return err
+	}
+}
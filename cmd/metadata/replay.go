@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/athenianco/metadata/archive"
+	"github.com/athenianco/metadata/github"
+)
+
+// replay re-publishes archived events to the configured message bus.
+// Replayed events bypass the deliveries ledger, so they are processed again.
+func replay(args []string) error {
+	flags := flag.NewFlagSet("replay", flag.ExitOnError)
+	configPath := flags.String("config", "", "path to the YAML config file")
+	from := flags.String("from", "", "replay events received at or after this time (RFC3339)")
+	to := flags.String("to", "", "replay events received before this time (RFC3339), defaults to now")
+	types := flags.String("types", "", "comma separated event types, all types if empty")
+	deliveries := flags.String("deliveries", "", "comma separated delivery IDs, all deliveries if empty")
+	flags.Parse(args)
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return fmt.Errorf("config: %v", err)
+	}
+	filter, err := parseFilter(*from, *to, *types, *deliveries)
+	if err != nil {
+		return err
+	}
+	if cfg.Archive.URI == "" {
+		return errors.New("archive uri is not set")
+	}
+
+	storage, err := archive.OpenStorage(cfg.Archive.URI)
+	if err != nil {
+		return err
+	}
+	publisher, err := openPublisher(cfg)
+	if err != nil {
+		return err
+	}
+
+	n, err := archive.Replay(context.Background(), storage, filter, github.Replayer(publisher))
+	log.Printf("replayed %d events\n", n)
+	return err
+}
+
+func parseFilter(from, to, types, deliveries string) (archive.Filter, error) {
+	var (
+		filter archive.Filter
+		err    error
+	)
+	if from == "" {
+		return filter, errors.New("-from is required")
+	}
+	if filter.From, err = time.Parse(time.RFC3339, from); err != nil {
+		return filter, err
+	}
+	filter.To = time.Now()
+	if to != "" {
+		if filter.To, err = time.Parse(time.RFC3339, to); err != nil {
+			return filter, err
+		}
+	}
+	filter.Types = splitList(types)
+	filter.DeliveryIDs = splitList(deliveries)
+	return filter, nil
+}
+
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/athenianco/metadata/github"
+)
+
+// scopeCmd sets the installation on rows written before they were scoped by installations (migration 14) once,
+// so they are included in the installation's metrics and purged with it, and refreshes materialized views.
+func scopeCmd(args []string) error {
+	flags := flag.NewFlagSet("scope", flag.ExitOnError)
+	configPath := flags.String("config", "", "path to the YAML config file")
+	installationID := flags.Int64("installation", 0, "installation ID of the GitHub App which the rows belong to")
+	flags.Parse(args)
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return fmt.Errorf("config: %v", err)
+	}
+	if *installationID != 0 {
+		cfg.GitHub.InstallationID = *installationID
+	}
+	if cfg.GitHub.InstallationID == 0 {
+		return errors.New("github installation id is required")
+	}
+	db, err := openDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	tabs, err := db.ForInstallation(cfg.GitHub.InstallationID).ScopeRows(ctx)
+	if err != nil {
+		return err
+	}
+	log.Printf("scoped rows of %d tables by installation %d\n", len(tabs), cfg.GitHub.InstallationID)
+	if len(tabs) == 0 {
+		return nil
+	}
+	for _, view := range github.Views() {
+		if err = db.RefreshMaterializedView(ctx, view); err != nil {
+			return err
+		}
+	}
+	return nil
+}
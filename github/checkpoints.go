@@ -0,0 +1,47 @@
+package github
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// BackfillCheckpoint returns the next page of the repository's backfill stage, and whether the stage is done.
+// A stage which was never started begins at page 1.
+func (db *Database) BackfillCheckpoint(ctx context.Context, repo, stage string) (int, bool, error) {
+	var (
+		page int
+		done bool
+	)
+	err := db.QueryRowContext(ctx,
+		`SELECT page, done FROM backfill_checkpoints WHERE repository_fullname = $1 AND stage = $2`,
+		repo, stage,
+	).Scan(&page, &done)
+	if err == sql.ErrNoRows {
+		return 1, false, nil
+	}
+	return page, done, err
+}
+
+// SaveBackfillCheckpoint records the next page of the repository's backfill stage.
+func (db *Database) SaveBackfillCheckpoint(ctx context.Context, repo, stage string, page int, done bool) error {
+	const upsert = `
+	INSERT INTO backfill_checkpoints (repository_fullname, stage, page, done, updated_at)
+	VALUES ($1, $2, $3, $4, $5)
+	ON CONFLICT (repository_fullname, stage)
+	DO UPDATE SET page = EXCLUDED.page, done = EXCLUDED.done, updated_at = EXCLUDED.updated_at`
+	_, err := db.ExecContext(ctx, upsert,
+		repo,             // repository_fullname text NOT NULL,
+		stage,            // stage text NOT NULL,
+		page,             // page integer NOT NULL,
+		done,             // done boolean NOT NULL,
+		time.Now().UTC(), // updated_at timestamptz NOT NULL,
+	)
+	return err
+}
+
+// ResetBackfillCheckpoints deletes checkpoints of the repository, so its next backfill starts over.
+func (db *Database) ResetBackfillCheckpoints(ctx context.Context, repo string) error {
+	_, err := db.ExecContext(ctx, `DELETE FROM backfill_checkpoints WHERE repository_fullname = $1`, repo)
+	return err
+}
@@ -0,0 +1,44 @@
+package github
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackfillCheckpoints(t *testing.T) {
+	require := require.New(t)
+	ctx := context.TODO()
+
+	db, err := OpenDatabase(dbURI, 0, 0)
+	require.NoError(err)
+	defer db.Close()
+
+	const repo = "athenianco/checkpoints"
+	require.NoError(db.ResetBackfillCheckpoints(ctx, repo))
+
+	page, done, err := db.BackfillCheckpoint(ctx, repo, "issues")
+	require.NoError(err)
+	require.Equal(1, page)
+	require.False(done)
+
+	require.NoError(db.SaveBackfillCheckpoint(ctx, repo, "issues", 3, false))
+	require.NoError(db.SaveBackfillCheckpoint(ctx, repo, "pull_requests", 5, true))
+
+	page, done, err = db.BackfillCheckpoint(ctx, repo, "issues")
+	require.NoError(err)
+	require.Equal(3, page)
+	require.False(done)
+
+	page, done, err = db.BackfillCheckpoint(ctx, repo, "pull_requests")
+	require.NoError(err)
+	require.Equal(5, page)
+	require.True(done)
+
+	require.NoError(db.ResetBackfillCheckpoints(ctx, repo))
+	page, done, err = db.BackfillCheckpoint(ctx, repo, "pull_requests")
+	require.NoError(err)
+	require.Equal(1, page)
+	require.False(done)
+}
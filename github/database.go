@@ -9,13 +9,16 @@ import (
 	"encoding/hex"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
+	"github.com/athenianco/metadata/migrations"
 	gh "github.com/google/go-github/v28/github"
 	"github.com/lib/pq"
 )
 
 var tables = map[string]string{
+	"github_commits_versioned":               "added, after, author_email, author_name, before, committed_at, committer_email, committer_name, forced, htmlurl, message, modified, ref, removed, repository_name, repository_owner, repository_fullname, sha, tree_id",
 	"github_organizations_versioned":         "avatar_url, collaborators, created_at, description, email, htmlurl, id, login, name, node_id, owned_private_repos, public_repos, total_private_repos, updated_at",
 	"github_users_versioned":                 "avatar_url, bio, company, created_at, email, followers, following, hireable, htmlurl, id, location, login, name, node_id, organization_id, organization_login, owned_private_repos, private_gists, public_gists, public_repos, total_private_repos, updated_at",
 	"github_repositories_versioned":          "allow_merge_commit, allow_rebase_merge, allow_squash_merge, archived, created_at, default_branch, description, disabled, fork, forks_count, fullname, has_issues, has_wiki, homepage, htmlurl, id, language, name, node_id, open_issues_count, owner_id, owner_login, owner_type, private, pushed_at, sshurl, stargazers_count, topics, updated_at, watchers_count",
@@ -24,15 +27,121 @@ var tables = map[string]string{
 	"github_pull_requests_versioned":         "additions, assignees, author_association, base_ref, base_repository_name, base_repository_owner, base_repository_fullname, base_sha, base_user, body, changed_files, closed_at, comments, commits, created_at, deletions, head_ref, head_repository_name, head_repository_owner, head_repository_fullname, head_sha, head_user, htmlurl, id, labels, maintainer_can_modify, merge_commit_sha, mergeable, merged, merged_at, merged_by_id, merged_by_login, milestone_id, milestone_title, node_id, number, repository_name, repository_owner, repository_fullname, review_comments, state, title, updated_at, user_id, user_login",
 	"github_pull_request_reviews_versioned":  "body, commit_id, htmlurl, id, node_id, pull_request_number, repository_name, repository_owner, repository_fullname, state, submitted_at, user_id, user_login",
 	"github_pull_request_comments_versioned": "author_association, body, commit_id, created_at, diff_hunk, htmlurl, id, in_reply_to, node_id, original_commit_id, original_position, path, position, pull_request_number, pull_request_review_id, repository_name, repository_owner, repository_fullname, updated_at, user_id, user_login",
+	"pull_request_state_transitions":         "action, changed_at, pull_request_id, pull_request_number, repository_name, repository_owner, repository_fullname, sender_id, sender_login, state, installation_id",
+	"github_pull_request_pushes":             "after, before, pull_request_id, pull_request_number, pull_request_sum256, pushed_at, repository_name, repository_owner, repository_fullname, sender_id, sender_login, installation_id",
+	"issue_changes":                          "action, assignee_id, assignee_login, changed_at, issue_id, issue_number, label, milestone_id, milestone_title, pull_request, repository_name, repository_owner, repository_fullname, sender_id, sender_login, user_id, user_login, installation_id",
+	"github_refs_versioned":                  "created_at, created_by_id, created_by_login, deleted_at, deleted_by_id, deleted_by_login, master_branch, pusher_type, ref, ref_type, repository_name, repository_owner, repository_fullname",
+	"github_releases_versioned":              "author_id, author_login, body, created_at, draft, htmlurl, id, name, node_id, prerelease, published_at, repository_name, repository_owner, repository_fullname, tag_name, target_commitish",
+	"github_check_runs_versioned":            "app_id, app_name, check_suite_id, completed_at, conclusion, details_url, external_id, head_sha, htmlurl, id, name, node_id, repository_name, repository_owner, repository_fullname, started_at, status",
+	"github_check_suites_versioned":          "after, app_id, app_name, before, conclusion, head_branch, head_sha, id, node_id, repository_name, repository_owner, repository_fullname, status",
+	"github_statuses_versioned":              "context, created_at, description, id, repository_name, repository_owner, repository_fullname, sha, state, target_url, updated_at",
+	"github_deployments_versioned":           "created_at, creator_id, creator_login, description, environment, id, node_id, payload, ref, repository_name, repository_owner, repository_fullname, sha, task, updated_at",
+	"github_deployment_statuses_versioned":   "created_at, creator_id, creator_login, deployment_id, description, environment, id, node_id, repository_name, repository_owner, repository_fullname, state, target_url, updated_at",
+	"github_labels_versioned":                "color, description, id, name, node_id, repository_name, repository_owner, repository_fullname, updated_at",
+	"github_milestones_versioned":            "closed_at, closed_issues, created_at, creator_id, creator_login, description, due_on, htmlurl, id, node_id, number, open_issues, repository_name, repository_owner, repository_fullname, state, title, updated_at",
+}
+
+// orderedBy maps versioned tables to the payload's column which grows monotonically with every change of the entity.
+// Pub/Sub does not guarantee FIFO order, so a row is never overwritten by a payload where this column is older.
+var orderedBy = map[string]string{
+	"github_organizations_versioned":         "updated_at",
+	"github_users_versioned":                 "updated_at",
+	"github_repositories_versioned":          "updated_at",
+	"github_issues_versioned":                "updated_at",
+	"github_issue_comments_versioned":        "updated_at",
+	"github_pull_requests_versioned":         "updated_at",
+	"github_pull_request_reviews_versioned":  "submitted_at",
+	"github_pull_request_comments_versioned": "updated_at",
+	"github_check_runs_versioned":            "completed_at",
+	"github_statuses_versioned":              "updated_at",
+	"github_deployments_versioned":           "updated_at",
+	"github_deployment_statuses_versioned":   "updated_at",
+	"github_labels_versioned":                "updated_at",
+	"github_milestones_versioned":            "updated_at",
 }
 
 // Database is a postgres database where github metadata are stored.
 type Database struct {
 	*sql.DB
+
+	// OnChange (optional) is called with the name of the versioned table (or issue_changes) after its rows were changed.
+	// It may be called concurrently.
+	OnChange func(tab string)
+
+	// AppID (optional) is the ID of our GitHub App. Events of other Apps' installations are ignored.
+	AppID int64
+
+	// installationID is the installation (the tenant) rows are written for, see ForInstallation.
+	installationID int64
+
+	// receivedAt is when the event being processed was received, see eventTime.
+	receivedAt time.Time
+	// deliveryID is the webhook delivery of the event being processed (empty if unknown).
+	deliveryID string
+
+	// tx is the transaction of the delivery being processed (see ProcessDelivery), all queries run in it.
+	tx *sql.Tx
+	// changes are tables changed in tx, OnChange is called with them once it's committed.
+	changes *[]string
+}
+
+// ForInstallation returns the database which writes rows for the GitHub App's installation (the tenant).
+// Rows written without the installation (0) keep the one they had.
+func (db *Database) ForInstallation(id int64) *Database {
+	scoped := *db
+	scoped.installationID = id
+	return &scoped
+}
+
+// InstallationID returns the installation rows are written for (0 if none), see ForInstallation.
+func (db *Database) InstallationID() int64 {
+	return db.installationID
+}
+
+// installation returns the installation_id column value.
+func (db *Database) installation() sql.NullInt64 {
+	return sql.NullInt64{Int64: db.installationID, Valid: db.installationID != 0}
+}
+
+// forEvent returns the database which processes the event.
+func (db *Database) forEvent(e *Event) *Database {
+	scoped := *db
+	scoped.receivedAt = e.ReceivedAt
+	scoped.deliveryID = e.DeliveryID
+	return &scoped
+}
+
+// eventTime returns when the event being processed happened, for changes its payload has no timestamp of.
+// It's when the webhook received the event, or now for events processed without it.
+func (db *Database) eventTime() time.Time {
+	if db.receivedAt.IsZero() {
+		return time.Now().UTC()
+	}
+	return db.receivedAt.UTC()
+}
+
+// DatabaseOption configures OpenDatabase.
+type DatabaseOption func(*databaseOptions)
+
+type databaseOptions struct {
+	migrate bool
+}
+
+// WithMigrations applies pending schema migrations when the database is opened.
+// Opening fails if the schema is dirty or newer than the known migrations.
+func WithMigrations() DatabaseOption {
+	return func(o *databaseOptions) {
+		o.migrate = true
+	}
 }
 
 // OpenDatabase opens postgres connection
-func OpenDatabase(dbURI string, maxOpenConns, maxIdleConns int) (*Database, error) {
+func OpenDatabase(dbURI string, maxOpenConns, maxIdleConns int, opts ...DatabaseOption) (*Database, error) {
+	var o databaseOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	db, err := sql.Open("postgres", dbURI)
 	if err != nil {
 		return nil, err
@@ -44,43 +153,240 @@ func OpenDatabase(dbURI string, maxOpenConns, maxIdleConns int) (*Database, erro
 		db.Close()
 		return nil, err
 	}
-	return &Database{db}, nil
+	if o.migrate {
+		if err = migrations.Up(context.Background(), db); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	return &Database{DB: db}, nil
 }
 
-func (db *Database) txExecContext(ctx context.Context, query string, args ...interface{}) error {
-	tx, err := db.Begin()
+// ExecContext executes the query in the transaction of the processed delivery, if any (see ProcessDelivery).
+func (db *Database) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if db.tx != nil {
+		return db.tx.ExecContext(ctx, query, args...)
+	}
+	return db.DB.ExecContext(ctx, query, args...)
+}
+
+// QueryContext executes the query in the transaction of the processed delivery, if any (see ProcessDelivery).
+func (db *Database) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	if db.tx != nil {
+		return db.tx.QueryContext(ctx, query, args...)
+	}
+	return db.DB.QueryContext(ctx, query, args...)
+}
+
+// QueryRowContext executes the query in the transaction of the processed delivery, if any (see ProcessDelivery).
+func (db *Database) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	if db.tx != nil {
+		return db.tx.QueryRowContext(ctx, query, args...)
+	}
+	return db.DB.QueryRowContext(ctx, query, args...)
+}
+
+// withTx runs fn with the database, which runs all queries in a new transaction, or in the transaction
+// of the processed delivery (see ProcessDelivery). OnChange is called once the transaction is committed.
+func (db *Database) withTx(ctx context.Context, fn func(db *Database) error) error {
+	if db.tx != nil {
+		return fn(db)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
+	var changes []string
+	txdb := *db
+	txdb.tx = tx
+	txdb.changes = &changes
+	if err = fn(&txdb); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	for _, tab := range changes {
+		db.changed(tab)
+	}
+	return nil
+}
 
-	if res, err := tx.ExecContext(ctx, query, args...); err != nil {
+// inTx runs fn in a new transaction, or in the transaction of the processed delivery (see ProcessDelivery),
+// which is committed together with the other writes of the event.
+func (db *Database) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	if db.tx != nil {
+		return fn(db.tx)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	if err = fn(tx); err != nil {
 		tx.Rollback()
-		log.Printf("query: %s, args: %v, result: %s, error: %v\n", query, args, gh.Stringify(res), err)
 		return err
 	}
 	return tx.Commit()
 }
 
+func (db *Database) txExecContext(ctx context.Context, query string, args ...interface{}) error {
+	return db.inTx(ctx, func(tx *sql.Tx) error {
+		if res, err := tx.ExecContext(ctx, query, args...); err != nil {
+			log.Printf("query: %s, args: %v, result: %s, error: %v\n", query, args, gh.Stringify(res), err)
+			return err
+		}
+		return nil
+	})
+}
+
+// txUpsertContext executes the upsert query (see upsertQuery) in a transaction.
+// If the stored row is newer than the payload, nothing is changed
+// and the skipped version is recorded in github_skipped_versions.
+func (db *Database) txUpsertContext(ctx context.Context, tab, query string, args ...interface{}) error {
+	var n int64
+	err := db.inTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, query, append(args[:len(args):len(args)], db.installation())...)
+		if err != nil {
+			log.Printf("query: %s, args: %v, result: %s, error: %v\n", query, args, gh.Stringify(res), err)
+			return err
+		}
+
+		if n, err = res.RowsAffected(); err == nil && n == 0 {
+			return skipVersion(ctx, tx, tab, args...)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		db.changed(tab)
+	}
+	return nil
+}
+
+func (db *Database) changed(tab string) {
+	if db.changes != nil {
+		*db.changes = append(*db.changes, tab)
+		return
+	}
+	if db.OnChange != nil {
+		db.OnChange(tab)
+	}
+}
+
+// skipVersion records the upsert which was refused, because the stored row is newer.
+func skipVersion(ctx context.Context, tx *sql.Tx, tab string, args ...interface{}) error {
+	var orderedAt interface{}
+	for i, c := range strings.Split(tables[tab], ", ") {
+		if c == orderedBy[tab] {
+			orderedAt = args[i+2]
+			break
+		}
+	}
+
+	const query = `
+	INSERT INTO github_skipped_versions
+	(table_name, sum256, version, ordered_by, ordered_at, skipped_at)
+	VALUES ($1, $2, $3, $4, $5, $6)`
+	if res, err := tx.ExecContext(ctx, query,
+		tab,               // table_name text NOT NULL,
+		args[0],           // sum256 character varying(64) NOT NULL,
+		args[len(args)-1], // version integer NOT NULL,
+		orderedBy[tab],    // ordered_by text NOT NULL,
+		orderedAt,         // ordered_at timestamptz,
+		time.Now().UTC(),  // skipped_at timestamptz NOT NULL,
+	); err != nil {
+		log.Printf("query: %s, table: %s, result: %s, error: %v\n", query, tab, gh.Stringify(res), err)
+		return err
+	}
+
+	log.Printf("skipped out-of-order version: %v of %s(%s), %s: %v\n", args[len(args)-1], tab, args[0], orderedBy[tab], orderedAt)
+	return nil
+}
+
+// IsDeliveryProcessed checks in the ledger (github_deliveries) if the webhook delivery was already processed.
+func (db *Database) IsDeliveryProcessed(ctx context.Context, deliveryID string) (bool, error) {
+	var processed bool
+	err := db.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM github_deliveries WHERE delivery_id = $1)`,
+		deliveryID,
+	).Scan(&processed)
+	return processed, err
+}
+
+// ProcessDelivery claims the webhook delivery in the ledger (github_deliveries) and processes the event
+// in the same transaction, so the delivery is recorded if and only if all writes of the event are committed.
+// Concurrent redeliveries wait for the claim. It returns false (and processes nothing) if the delivery
+// was already processed, unless the event is replayed.
+func (db *Database) ProcessDelivery(ctx context.Context, event *Event, replay bool) (bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	const query = `
+	INSERT INTO github_deliveries (delivery_id, event_type, processed_at)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (delivery_id)
+	DO NOTHING`
+	res, err := tx.ExecContext(ctx, query,
+		event.DeliveryID, // delivery_id text NOT NULL,
+		event.Type,       // event_type text NOT NULL,
+		time.Now().UTC(), // processed_at timestamptz NOT NULL,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if n == 0 && !replay {
+		return false, nil
+	}
+
+	var changes []string
+	txdb := *db
+	txdb.tx = tx
+	txdb.changes = &changes
+	if err = event.Process(ctx, &txdb); err != nil {
+		return false, err
+	}
+	if err = tx.Commit(); err != nil {
+		return false, err
+	}
+	for _, tab := range changes {
+		db.changed(tab)
+	}
+	return true, nil
+}
+
+// PurgeDeliveries deletes deliveries processed before from the ledger (github_deliveries).
+// Redeliveries of them are not recognized anymore.
+func (db *Database) PurgeDeliveries(ctx context.Context, before time.Time) (int64, error) {
+	res, err := db.ExecContext(ctx, `DELETE FROM github_deliveries WHERE processed_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
 // UpsertRepository (github_repositories_versioned)
 func (db *Database) UpsertRepository(ctx context.Context, repo *gh.Repository) error {
 	const tab = "github_repositories_versioned"
-	cols := tables[tab]
 	ver := version()
 
 	topics := make([]string, len(repo.Topics))
 	for i, t := range repo.Topics {
 		topics[i] = t
 	}
-	query := fmt.Sprintf(`
-	INSERT INTO %s
-	(sum256, versions, %s)
-	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14,
-		$15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29,
-		$30, $31, $32)
-	ON CONFLICT (sum256)
-	DO UPDATE
-	SET versions = array_append(%s.versions, $33)`, tab, cols, tab)
-	return db.txExecContext(ctx, query,
+	query := upsertQuery(tab)
+	return db.txUpsertContext(ctx, tab, query,
 		sum256(repo.GetID()),       // sum256,
 		pq.Array([]int64{ver}),     // versions,
 		repo.GetAllowMergeCommit(), // allow_merge_commit boolean
@@ -120,18 +426,10 @@ func (db *Database) UpsertRepository(ctx context.Context, repo *gh.Repository) e
 // UpsertOrganization (github_organizations_versioned)
 func (db *Database) UpsertOrganization(ctx context.Context, org *gh.Organization) error {
 	const tab = "github_organizations_versioned"
-	cols := tables[tab]
 	ver := version()
 
-	query := fmt.Sprintf(`
-		INSERT INTO %s
-		(sum256, versions, %s)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14,
-			$15, $16)
-		ON CONFLICT (sum256)
-		DO UPDATE
-		SET versions = array_append(%s.versions, $17)`, tab, cols, tab)
-	return db.txExecContext(ctx, query,
+	query := upsertQuery(tab)
+	return db.txUpsertContext(ctx, tab, query,
 		sum256(org.GetID()),        // sum256,
 		pq.Array([]int64{ver}),     // versions,
 		org.GetAvatarURL(),         // avatar_url text,
@@ -152,10 +450,105 @@ func (db *Database) UpsertOrganization(ctx context.Context, org *gh.Organization
 	)
 }
 
+// UpsertUser (github_users_versioned)
+// The user is stored in the context of the organization (can be nil) which owns the event's repository.
+func (db *Database) UpsertUser(ctx context.Context, org *gh.Organization, user *gh.User) error {
+	const tab = "github_users_versioned"
+	ver := version()
+
+	query := upsertQuery(tab)
+	args := []interface{}{
+		db.userKey(org, user.GetID()), // sum256,
+		pq.Array([]int64{ver}),        // versions,
+	}
+	args = append(args, userColumns(org, user)...)
+	return db.txUpsertContext(ctx, tab, query, append(args, ver)...)
+}
+
+// InsertUsers (github_users_versioned) inserts the users, who are not stored yet, by a single statement.
+// The users are stored in the context of the organization (can be nil) which owns the event's repository.
+// Users in event payloads have no profile and no updated_at, so they never overwrite the stored users.
+func (db *Database) InsertUsers(ctx context.Context, org *gh.Organization, users []*gh.User) error {
+	const tab = "github_users_versioned"
+	if len(users) == 0 {
+		return nil
+	}
+	ver := version()
+
+	var (
+		values []string
+		args   []interface{}
+	)
+	for _, user := range users {
+		row := []interface{}{
+			db.userKey(org, user.GetID()), // sum256,
+			pq.Array([]int64{ver}),        // versions,
+		}
+		row = append(row, userColumns(org, user)...)
+		row = append(row, db.installation()) // installation_id bigint,
+
+		placeholders := make([]string, len(row))
+		for i := range row {
+			placeholders[i] = fmt.Sprintf("$%d", len(args)+i+1)
+		}
+		values = append(values, "("+strings.Join(placeholders, ", ")+")")
+		args = append(args, row...)
+	}
+
+	query := withHistory(tab, fmt.Sprintf(`
+	INSERT INTO %s (sum256, versions, %s, installation_id)
+	VALUES %s
+	ON CONFLICT (sum256)
+	DO NOTHING`, tab, tables[tab], strings.Join(values, ",\n\t\t")))
+	res, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("query: %s, args: %v, error: %v\n", query, args, err)
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n > 0 {
+		db.changed(tab)
+	}
+	return nil
+}
+
+// userKey returns the sum256 of the user stored in the context of the organization (can be nil).
+// It includes the installation, so users of personal accounts (without the organization) aren't shared
+// by installations and purged with one of them.
+func (db *Database) userKey(org *gh.Organization, userID int64) string {
+	return sum256(db.installationID, org.GetID(), userID)
+}
+
+// userColumns returns the values of the columns of github_users_versioned (see tables).
+func userColumns(org *gh.Organization, user *gh.User) []interface{} {
+	return []interface{}{
+		user.GetAvatarURL(),         // avatar_url text,
+		user.GetBio(),               // bio text,
+		user.GetCompany(),           // company text,
+		user.GetCreatedAt().UTC(),   // created_at timestamptz,
+		user.GetEmail(),             // email text,
+		user.GetFollowers(),         // followers bigint,
+		user.GetFollowing(),         // following bigint,
+		user.GetHireable(),          // hireable boolean,
+		user.GetHTMLURL(),           // htmlurl text,
+		user.GetID(),                // id bigint,
+		user.GetLocation(),          // location text,
+		user.GetLogin(),             // login text,
+		user.GetName(),              // name text,
+		user.GetNodeID(),            // node_id text,
+		org.GetID(),                 // organization_id bigint NOT NULL,
+		org.GetLogin(),              // organization_login text NOT NULL,
+		user.GetOwnedPrivateRepos(), // owned_private_repos bigint,
+		user.GetPrivateGists(),      // private_gists bigint,
+		user.GetPublicGists(),       // public_gists bigint,
+		user.GetPublicRepos(),       // public_repos bigint,
+		user.GetTotalPrivateRepos(), // total_private_repos bigint,
+		user.GetUpdatedAt().UTC(),   // updated_at timestamptz,
+	}
+}
+
 // UpsertPullRequest (github_pull_requests_versioned)
 func (db *Database) UpsertPullRequest(ctx context.Context, repo *gh.Repository, pr *gh.PullRequest) error {
 	const tab = "github_pull_requests_versioned"
-	cols := tables[tab]
 	ver := version()
 
 	var assignees []string = make([]string, len(pr.Assignees))
@@ -168,17 +561,8 @@ func (db *Database) UpsertPullRequest(ctx context.Context, repo *gh.Repository,
 		labels[i] = l.GetName()
 	}
 
-	query := fmt.Sprintf(
-		`INSERT INTO %s
-		(sum256, versions, %s)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14,
-			$15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29,
-			$30, $31, $32, $33, $34, $35, $36, $37, $38, $39, $40, $41, $42, $43, $44,
-			$45, $46, $47)
-		ON CONFLICT (sum256)
-		DO UPDATE
-		SET versions = array_append(%s.versions, $48)`, tab, cols, tab)
-	return db.txExecContext(ctx, query,
+	query := upsertQuery(tab)
+	return db.txUpsertContext(ctx, tab, query,
 		sum256(
 			repo.GetID(),
 			pr.GetID(),
@@ -236,17 +620,10 @@ func (db *Database) UpsertPullRequest(ctx context.Context, repo *gh.Repository,
 // UpsertPullRequestReviewComment (github_pull_request_comments_versioned)
 func (db *Database) UpsertPullRequestReviewComment(ctx context.Context, repo *gh.Repository, pr *gh.PullRequest, comment *gh.PullRequestComment) error {
 	const tab = "github_pull_request_comments_versioned"
-	cols := tables[tab]
 	ver := version()
 
-	query := fmt.Sprintf(`
-	INSERT INTO %s
-	(sum256, versions, %s)
-	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23)
-	ON CONFLICT (sum256)
-	DO UPDATE
-	SET versions = array_append(%s.versions, $24)`, tab, cols, tab)
-	return db.txExecContext(ctx, query,
+	query := upsertQuery(tab)
+	return db.txUpsertContext(ctx, tab, query,
 		sum256(
 			repo.GetID(),
 			pr.GetID(),
@@ -281,17 +658,10 @@ func (db *Database) UpsertPullRequestReviewComment(ctx context.Context, repo *gh
 // UpsertPullRequestReview (github_pull_request_reviews_versioned)
 func (db *Database) UpsertPullRequestReview(ctx context.Context, repo *gh.Repository, pr *gh.PullRequest, review *gh.PullRequestReview) error {
 	const tab = "github_pull_request_reviews_versioned"
-	cols := tables[tab]
 	ver := version()
 
-	query := fmt.Sprintf(`
-	INSERT INTO %s
-	(sum256, versions, %s)
-	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
-	ON CONFLICT (sum256)
-	DO UPDATE
-	SET versions = array_append(%s.versions, $16)`, tab, cols, tab)
-	return db.txExecContext(ctx, query,
+	query := upsertQuery(tab)
+	return db.txUpsertContext(ctx, tab, query,
 		sum256(
 			repo.GetID(),
 			pr.GetID(),
@@ -318,7 +688,6 @@ func (db *Database) UpsertPullRequestReview(ctx context.Context, repo *gh.Reposi
 // UpsertIssues (github_issues_versioned)
 func (db *Database) UpsertIssues(ctx context.Context, repo *gh.Repository, issue *gh.Issue) error {
 	const tab = "github_issues_versioned"
-	cols := tables[tab]
 	ver := version()
 
 	var assignees []string = make([]string, len(issue.Assignees))
@@ -331,14 +700,8 @@ func (db *Database) UpsertIssues(ctx context.Context, repo *gh.Repository, issue
 		labels[i] = l.GetName()
 	}
 
-	query := fmt.Sprintf(`
-	INSERT INTO %s (sum256, versions, %s)
-	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14,
-		$15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25)
-	ON CONFLICT (sum256)
-	DO UPDATE
-	SET versions = array_append(%s.versions, $26)`, tab, cols, tab)
-	return db.txExecContext(ctx, query,
+	query := upsertQuery(tab)
+	return db.txUpsertContext(ctx, tab, query,
 		sum256(
 			repo.GetID(),
 			issue.GetID(),
@@ -374,16 +737,10 @@ func (db *Database) UpsertIssues(ctx context.Context, repo *gh.Repository, issue
 // UpsertIssueComment (github_issue_comments_versioned)
 func (db *Database) UpsertIssueComment(ctx context.Context, repo *gh.Repository, issue *gh.Issue, comment *gh.IssueComment) error {
 	const tab = "github_issue_comments_versioned"
-	cols := tables[tab]
 	ver := version()
 
-	query := fmt.Sprintf(`
-	INSERT INTO %s (sum256, versions, %s)
-	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
-	ON CONFLICT (sum256)
-	DO UPDATE
-	SET versions = array_append(%s.versions, $16)`, tab, cols, tab)
-	return db.txExecContext(ctx, query,
+	query := upsertQuery(tab)
+	return db.txUpsertContext(ctx, tab, query,
 		sum256(
 			repo.GetID(),
 			issue.GetID(),
@@ -410,17 +767,10 @@ func (db *Database) UpsertIssueComment(ctx context.Context, repo *gh.Repository,
 // UpsertIssueCommentAsPullRequest (github_issue_comments_versioned)
 func (db *Database) UpsertIssueCommentAsPullRequest(ctx context.Context, repo *gh.Repository, issue *gh.Issue, comment *gh.IssueComment) error {
 	const tab = "github_pull_request_comments_versioned"
-	cols := tables[tab]
 	ver := version()
 
-	query := fmt.Sprintf(`
-		INSERT INTO %s
-		(sum256, versions, %s)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23)
-		ON CONFLICT (sum256)
-		DO UPDATE
-		SET versions = array_append(%s.versions, $24)`, tab, cols, tab)
-	return db.txExecContext(ctx, query,
+	query := upsertQuery(tab)
+	return db.txUpsertContext(ctx, tab, query,
 		sum256(
 			repo.GetID(),
 			issue.GetID(),
@@ -452,11 +802,853 @@ func (db *Database) UpsertIssueCommentAsPullRequest(ctx context.Context, repo *g
 	)
 }
 
-func sum256(ids ...int64) string {
+// UpsertCommit (github_commits_versioned)
+func (db *Database) UpsertCommit(ctx context.Context, repo *gh.PushEventRepository, push *gh.PushEvent, commit *gh.PushEventCommit) error {
+	const tab = "github_commits_versioned"
+	ver := version()
+
+	added := make([]string, len(commit.Added))
+	copy(added, commit.Added)
+	removed := make([]string, len(commit.Removed))
+	copy(removed, commit.Removed)
+	modified := make([]string, len(commit.Modified))
+	copy(modified, commit.Modified)
+
+	query := upsertQuery(tab)
+	return db.txUpsertContext(ctx, tab, query,
+		sum256(
+			repo.GetID(),
+			push.GetRef(),
+			commit.GetID(),
+		), // sum256,
+		pq.Array([]int64{ver}),           // versions,
+		pq.Array(added),                  // added text[] NOT NULL,
+		push.GetAfter(),                  // after text NOT NULL,
+		commit.GetAuthor().GetEmail(),    // author_email text,
+		commit.GetAuthor().GetName(),     // author_name text,
+		push.GetBefore(),                 // before text NOT NULL,
+		commit.GetTimestamp().UTC(),      // committed_at timestamptz,
+		commit.GetCommitter().GetEmail(), // committer_email text,
+		commit.GetCommitter().GetName(),  // committer_name text,
+		push.GetForced(),                 // forced boolean,
+		commit.GetURL(),                  // htmlurl text,
+		commit.GetMessage(),              // message text,
+		pq.Array(modified),               // modified text[] NOT NULL,
+		push.GetRef(),                    // ref text NOT NULL,
+		pq.Array(removed),                // removed text[] NOT NULL,
+		repo.GetName(),                   // repository_name text NOT NULL,
+		repo.GetOwner().GetLogin(),       // repository_owner text NOT NULL,
+		repo.GetFullName(),               // repository_fullname text NOT NULL,
+		commit.GetID(),                   // sha text NOT NULL,
+		commit.GetTreeID(),               // tree_id text,
+		ver,
+	)
+}
+
+// InsertPullRequestStateTransition (pull_request_state_transitions) records who opened, closed, merged or reopened
+// the pull request and when. Redelivered transitions are ignored.
+func (db *Database) InsertPullRequestStateTransition(ctx context.Context, repo *gh.Repository, pr *gh.PullRequest, action string, sender *gh.User) error {
+	const tab = "pull_request_state_transitions"
+	cols := tables[tab]
+
+	var changedAt time.Time
+	state := pr.GetState()
+	switch action {
+	case "opened":
+		changedAt = pr.GetCreatedAt()
+	case "closed":
+		changedAt = pr.GetClosedAt()
+		if pr.GetMerged() {
+			action, state = "merged", "merged"
+			changedAt = pr.GetMergedAt()
+		}
+	default:
+		changedAt = pr.GetUpdatedAt()
+	}
+
+	query := fmt.Sprintf(`
+	INSERT INTO %s (sum256, %s)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	ON CONFLICT (sum256)
+	DO NOTHING`, tab, cols)
+	return db.txExecContext(ctx, query,
+		sum256(
+			repo.GetID(),
+			pr.GetID(),
+			action,
+			changedAt.Unix(),
+		), // sum256,
+		action,                     // action text NOT NULL,
+		changedAt.UTC(),            // changed_at timestamptz,
+		pr.GetID(),                 // pull_request_id bigint NOT NULL,
+		pr.GetNumber(),             // pull_request_number bigint NOT NULL,
+		repo.GetName(),             // repository_name text NOT NULL,
+		repo.GetOwner().GetLogin(), // repository_owner text NOT NULL,
+		repo.GetFullName(),         // repository_fullname text NOT NULL,
+		sender.GetID(),             // sender_id bigint NOT NULL,
+		sender.GetLogin(),          // sender_login text NOT NULL,
+		state,                      // state text NOT NULL,
+		db.installation(),          // installation_id bigint,
+	)
+}
+
+// IssueChange is a change of labels, assignees or the milestone of an issue or a pull request.
+type IssueChange struct {
+	// Action is labeled, unlabeled, assigned, unassigned, milestoned or demilestoned.
+	Action string
+	// Label, Assignee or Milestone is the subject of the action.
+	Label     *gh.Label
+	Assignee  *gh.User
+	Milestone *gh.Milestone
+	// Sender made the change.
+	Sender *gh.User
+}
+
+// InsertIssueChange (issue_changes) records the change of labels, assignees or the milestone of the issue.
+// Redelivered changes are ignored.
+func (db *Database) InsertIssueChange(ctx context.Context, repo *gh.Repository, issue *gh.Issue, change *IssueChange) error {
+	return db.insertIssueChange(ctx, repo, issue.GetID(), issue.GetNumber(), false, issue.GetUser(), issue.GetUpdatedAt(), change)
+}
+
+// InsertPullRequestChange (issue_changes) records the change of labels, assignees or the milestone of the pull request.
+// Redelivered changes are ignored.
+func (db *Database) InsertPullRequestChange(ctx context.Context, repo *gh.Repository, pr *gh.PullRequest, change *IssueChange) error {
+	return db.insertIssueChange(ctx, repo, pr.GetID(), pr.GetNumber(), true, pr.GetUser(), pr.GetUpdatedAt(), change)
+}
+
+// insertIssueChange records the change made when the issue or the pull request was updated (changedAt).
+// GitHub timestamps have the precision of a second, so the same change of the subject (e.g. a label added, removed
+// and added again) may be made several times with the same changed_at. The row is keyed by the webhook delivery
+// of the change, so only its redeliveries (and replays) are ignored. Changes processed without the delivery
+// are keyed by changed_at.
+func (db *Database) insertIssueChange(ctx context.Context, repo *gh.Repository, id int64, number int, pullRequest bool, user *gh.User, changedAt time.Time, change *IssueChange) error {
+	const tab = "issue_changes"
+	cols := tables[tab]
+
+	var changeKey interface{} = changedAt.Unix()
+	if db.deliveryID != "" {
+		changeKey = db.deliveryID
+	}
+
+	query := fmt.Sprintf(`
+	INSERT INTO %s (sum256, %s)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
+	ON CONFLICT (sum256)
+	DO NOTHING`, tab, cols)
+	return db.txExecContext(ctx, query,
+		sum256(
+			repo.GetID(),
+			id,
+			change.Action,
+			change.Label.GetName(),
+			change.Assignee.GetID(),
+			change.Milestone.GetID(),
+			changeKey,
+		), // sum256,
+		change.Action,               // action text NOT NULL,
+		change.Assignee.GetID(),     // assignee_id bigint,
+		change.Assignee.GetLogin(),  // assignee_login text,
+		changedAt.UTC(),             // changed_at timestamptz,
+		id,                          // issue_id bigint NOT NULL,
+		number,                      // issue_number bigint NOT NULL,
+		change.Label.GetName(),      // label text,
+		change.Milestone.GetID(),    // milestone_id bigint,
+		change.Milestone.GetTitle(), // milestone_title text,
+		pullRequest,                 // pull_request boolean NOT NULL,
+		repo.GetName(),              // repository_name text NOT NULL,
+		repo.GetOwner().GetLogin(),  // repository_owner text NOT NULL,
+		repo.GetFullName(),          // repository_fullname text NOT NULL,
+		change.Sender.GetID(),       // sender_id bigint NOT NULL,
+		change.Sender.GetLogin(),    // sender_login text NOT NULL,
+		user.GetID(),                // user_id bigint NOT NULL,
+		user.GetLogin(),             // user_login text NOT NULL,
+		db.installation(),           // installation_id bigint,
+	)
+}
+
+// InsertPullRequestPush (github_pull_request_pushes) records new commits pushed to the pull request's head.
+// Redelivered pushes are ignored.
+func (db *Database) InsertPullRequestPush(ctx context.Context, repo *gh.Repository, pr *gh.PullRequest, before, after string, sender *gh.User) error {
+	const tab = "github_pull_request_pushes"
+	cols := tables[tab]
+
+	query := fmt.Sprintf(`
+	INSERT INTO %s (sum256, %s)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+	ON CONFLICT (sum256)
+	DO NOTHING`, tab, cols)
+	return db.txExecContext(ctx, query,
+		sum256(
+			repo.GetID(),
+			pr.GetID(),
+			before,
+			after,
+		), // sum256,
+		after,                            // after text NOT NULL,
+		before,                           // before text NOT NULL,
+		pr.GetID(),                       // pull_request_id bigint NOT NULL,
+		pr.GetNumber(),                   // pull_request_number bigint NOT NULL,
+		sum256(repo.GetID(), pr.GetID()), // pull_request_sum256 character varying(64) NOT NULL,
+		pr.GetUpdatedAt(),                // pushed_at timestamptz,
+		repo.GetName(),                   // repository_name text NOT NULL,
+		repo.GetOwner().GetLogin(),       // repository_owner text NOT NULL,
+		repo.GetFullName(),               // repository_fullname text NOT NULL,
+		sender.GetID(),                   // sender_id bigint NOT NULL,
+		sender.GetLogin(),                // sender_login text NOT NULL,
+		db.installation(),                // installation_id bigint,
+	)
+}
+
+// DeleteRepository (github_repositories_versioned)
+func (db *Database) DeleteRepository(ctx context.Context, repo *gh.Repository) error {
+	return db.markDeleted(ctx, "github_repositories_versioned",
+		sum256(repo.GetID()),
+		func() error {
+			return db.UpsertRepository(ctx, repo)
+		},
+	)
+}
+
+// DeleteOrganization (github_organizations_versioned)
+func (db *Database) DeleteOrganization(ctx context.Context, org *gh.Organization) error {
+	return db.markDeleted(ctx, "github_organizations_versioned",
+		sum256(org.GetID()),
+		func() error {
+			return db.UpsertOrganization(ctx, org)
+		},
+	)
+}
+
+// DeleteIssue (github_issues_versioned)
+func (db *Database) DeleteIssue(ctx context.Context, repo *gh.Repository, issue *gh.Issue) error {
+	return db.markDeleted(ctx, "github_issues_versioned",
+		sum256(
+			repo.GetID(),
+			issue.GetID(),
+		),
+		func() error {
+			return db.UpsertIssues(ctx, repo, issue)
+		},
+	)
+}
+
+// DeleteIssueComment (github_issue_comments_versioned)
+func (db *Database) DeleteIssueComment(ctx context.Context, repo *gh.Repository, issue *gh.Issue, comment *gh.IssueComment) error {
+	return db.markDeleted(ctx, "github_issue_comments_versioned",
+		sum256(
+			repo.GetID(),
+			issue.GetID(),
+			comment.GetID(),
+		),
+		func() error {
+			return db.UpsertIssueComment(ctx, repo, issue, comment)
+		},
+	)
+}
+
+// DeleteIssueCommentAsPullRequest (github_pull_request_comments_versioned)
+func (db *Database) DeleteIssueCommentAsPullRequest(ctx context.Context, repo *gh.Repository, issue *gh.Issue, comment *gh.IssueComment) error {
+	return db.markDeleted(ctx, "github_pull_request_comments_versioned",
+		sum256(
+			repo.GetID(),
+			issue.GetID(),
+			comment.GetID(),
+		),
+		func() error {
+			return db.UpsertIssueCommentAsPullRequest(ctx, repo, issue, comment)
+		},
+	)
+}
+
+// DeletePullRequestReviewComment (github_pull_request_comments_versioned)
+func (db *Database) DeletePullRequestReviewComment(ctx context.Context, repo *gh.Repository, pr *gh.PullRequest, comment *gh.PullRequestComment) error {
+	return db.markDeleted(ctx, "github_pull_request_comments_versioned",
+		sum256(
+			repo.GetID(),
+			pr.GetID(),
+			comment.GetID(),
+		),
+		func() error {
+			return db.UpsertPullRequestReviewComment(ctx, repo, pr, comment)
+		},
+	)
+}
+
+// markDeleted appends a tombstone version to the row identified by sum256 and sets its deleted_at.
+// Deleted rows are kept in the versioned tables, but the views do not show them.
+// Deletion payloads have no timestamp of the deletion, so deleted_at is when the event was received.
+// A row which wasn't seen (e.g. created before the App was installed) is inserted by upsert
+// from the payload of the deleted entity first, so its tombstone is kept too.
+func (db *Database) markDeleted(ctx context.Context, tab, sum string, upsert func() error) error {
+	query := withHistory(tab, fmt.Sprintf(`
+	UPDATE %s
+	SET versions = array_append(%s.versions, $2),
+		deleted_at = $3
+	WHERE sum256 = $1`, tab, tab))
+	deleted := func() (int64, error) {
+		args := []interface{}{
+			sum,            // sum256,
+			version(),      // versions,
+			db.eventTime(), // deleted_at timestamptz,
+		}
+		res, err := db.ExecContext(ctx, query, args...)
+		if err != nil {
+			log.Printf("query: %s, args: %v, result: %s, error: %v\n", query, args, gh.Stringify(res), err)
+			return 0, err
+		}
+		return res.RowsAffected()
+	}
+
+	n, err := deleted()
+	if err == nil && n == 0 {
+		if err = upsert(); err == nil {
+			_, err = deleted()
+		}
+	}
+	if err == nil {
+		db.changed(tab)
+	}
+	return err
+}
+
+// UpsertRef (github_refs_versioned) records a created branch or tag.
+// The create event has no timestamp, so the ref is created when the event was received.
+// The deletion of a ref, which was received first, but happened later, is kept.
+func (db *Database) UpsertRef(ctx context.Context, repo *gh.Repository, event *gh.CreateEvent) error {
+	const tab = "github_refs_versioned"
+	ver := version()
+
+	query := withHistory(tab, fmt.Sprintf(`
+	INSERT INTO %[1]s (sum256, versions, %[2]s, installation_id)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $17)
+	ON CONFLICT (sum256)
+	DO UPDATE
+	SET versions = array_append(%[1]s.versions, $16),
+		created_at = EXCLUDED.created_at,
+		created_by_id = EXCLUDED.created_by_id,
+		created_by_login = EXCLUDED.created_by_login,
+		deleted_at = CASE WHEN %[1]s.deleted_at > EXCLUDED.created_at THEN %[1]s.deleted_at END,
+		deleted_by_id = CASE WHEN %[1]s.deleted_at > EXCLUDED.created_at THEN %[1]s.deleted_by_id END,
+		deleted_by_login = CASE WHEN %[1]s.deleted_at > EXCLUDED.created_at THEN %[1]s.deleted_by_login END,
+		master_branch = EXCLUDED.master_branch,
+		pusher_type = EXCLUDED.pusher_type,
+		installation_id = COALESCE(EXCLUDED.installation_id, %[1]s.installation_id)`, tab, tables[tab]))
+	return db.txUpsertContext(ctx, tab, query,
+		sum256(
+			repo.GetID(),
+			event.GetRefType(),
+			event.GetRef(),
+		), // sum256,
+		pq.Array([]int64{ver}),       // versions,
+		db.eventTime(),               // created_at timestamptz,
+		event.GetSender().GetID(),    // created_by_id bigint,
+		event.GetSender().GetLogin(), // created_by_login text,
+		nil,                          // deleted_at timestamptz,
+		nil,                          // deleted_by_id bigint,
+		nil,                          // deleted_by_login text,
+		event.GetMasterBranch(),      // master_branch text,
+		event.GetPusherType(),        // pusher_type text,
+		event.GetRef(),               // ref text NOT NULL,
+		event.GetRefType(),           // ref_type text NOT NULL,
+		repo.GetName(),               // repository_name text NOT NULL,
+		repo.GetOwner().GetLogin(),   // repository_owner text NOT NULL,
+		repo.GetFullName(),           // repository_fullname text NOT NULL,
+		ver,
+	)
+}
+
+// DeleteRef (github_refs_versioned) marks a branch or tag as deleted.
+// The row is kept as a tombstone, so the lifetime of the ref can be measured.
+// The delete event has no timestamp, so the ref is deleted when the event was received.
+// A ref, which wasn't seen created (e.g. created before the App was installed), is inserted as a tombstone-only row
+// with NULL creation columns (created_at, created_by_id, created_by_login and master_branch).
+// A ref created again after the deletion (its create event was received first) is not deleted.
+func (db *Database) DeleteRef(ctx context.Context, repo *gh.Repository, event *gh.DeleteEvent) error {
+	const tab = "github_refs_versioned"
+	ver := version()
+
+	query := withHistory(tab, fmt.Sprintf(`
+	INSERT INTO %[1]s (sum256, versions, %[2]s, installation_id)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $17)
+	ON CONFLICT (sum256)
+	DO UPDATE
+	SET versions = array_append(%[1]s.versions, $16),
+		deleted_at = EXCLUDED.deleted_at,
+		deleted_by_id = EXCLUDED.deleted_by_id,
+		deleted_by_login = EXCLUDED.deleted_by_login,
+		pusher_type = EXCLUDED.pusher_type,
+		installation_id = COALESCE(EXCLUDED.installation_id, %[1]s.installation_id)
+	WHERE %[1]s.created_at IS NULL OR %[1]s.created_at <= EXCLUDED.deleted_at`, tab, tables[tab]))
+	return db.txExecContext(ctx, query,
+		sum256(
+			repo.GetID(),
+			event.GetRefType(),
+			event.GetRef(),
+		), // sum256,
+		pq.Array([]int64{ver}),       // versions,
+		nil,                          // created_at timestamptz,
+		nil,                          // created_by_id bigint,
+		nil,                          // created_by_login text,
+		db.eventTime(),               // deleted_at timestamptz,
+		event.GetSender().GetID(),    // deleted_by_id bigint,
+		event.GetSender().GetLogin(), // deleted_by_login text,
+		nil,                          // master_branch text,
+		event.GetPusherType(),        // pusher_type text,
+		event.GetRef(),               // ref text NOT NULL,
+		event.GetRefType(),           // ref_type text NOT NULL,
+		repo.GetName(),               // repository_name text NOT NULL,
+		repo.GetOwner().GetLogin(),   // repository_owner text NOT NULL,
+		repo.GetFullName(),           // repository_fullname text NOT NULL,
+		ver,
+		db.installation(), // installation_id bigint,
+	)
+}
+
+// UpsertRelease (github_releases_versioned) records a published (or drafted, prereleased, edited) release.
+func (db *Database) UpsertRelease(ctx context.Context, repo *gh.Repository, release *gh.RepositoryRelease) error {
+	const tab = "github_releases_versioned"
+	ver := version()
+
+	query := upsertQuery(tab)
+	return db.txUpsertContext(ctx, tab, query,
+		sum256(
+			repo.GetID(),
+			release.GetID(),
+		), // sum256,
+		pq.Array([]int64{ver}),         // versions,
+		release.GetAuthor().GetID(),    // author_id bigint,
+		release.GetAuthor().GetLogin(), // author_login text,
+		release.GetBody(),              // body text,
+		release.GetCreatedAt().UTC(),   // created_at timestamptz,
+		release.GetDraft(),             // draft boolean,
+		release.GetHTMLURL(),           // htmlurl text,
+		release.GetID(),                // id bigint,
+		release.GetName(),              // name text,
+		release.GetNodeID(),            // node_id text,
+		release.GetPrerelease(),        // prerelease boolean,
+		release.GetPublishedAt().UTC(), // published_at timestamptz,
+		repo.GetName(),                 // repository_name text NOT NULL,
+		repo.GetOwner().GetLogin(),     // repository_owner text NOT NULL,
+		repo.GetFullName(),             // repository_fullname text NOT NULL,
+		release.GetTagName(),           // tag_name text NOT NULL,
+		release.GetTargetCommitish(),   // target_commitish text,
+		ver,
+	)
+}
+
+// DeleteRelease (github_releases_versioned)
+func (db *Database) DeleteRelease(ctx context.Context, repo *gh.Repository, release *gh.RepositoryRelease) error {
+	return db.markDeleted(ctx, "github_releases_versioned",
+		sum256(
+			repo.GetID(),
+			release.GetID(),
+		),
+		func() error {
+			return db.UpsertRelease(ctx, repo, release)
+		},
+	)
+}
+
+// UpsertCheckRun (github_check_runs_versioned)
+func (db *Database) UpsertCheckRun(ctx context.Context, repo *gh.Repository, run *gh.CheckRun) error {
+	const tab = "github_check_runs_versioned"
+	ver := version()
+
+	query := upsertQuery(tab)
+	return db.txUpsertContext(ctx, tab, query,
+		sum256(
+			repo.GetID(),
+			run.GetHeadSHA(),
+			run.GetID(),
+		), // sum256,
+		pq.Array([]int64{ver}),      // versions,
+		run.GetApp().GetID(),        // app_id bigint,
+		run.GetApp().GetName(),      // app_name text,
+		run.GetCheckSuite().GetID(), // check_suite_id bigint,
+		run.GetCompletedAt().UTC(),  // completed_at timestamptz,
+		run.GetConclusion(),         // conclusion text,
+		run.GetDetailsURL(),         // details_url text,
+		run.GetExternalID(),         // external_id text,
+		run.GetHeadSHA(),            // head_sha text NOT NULL,
+		run.GetHTMLURL(),            // htmlurl text,
+		run.GetID(),                 // id bigint,
+		run.GetName(),               // name text NOT NULL,
+		run.GetNodeID(),             // node_id text,
+		repo.GetName(),              // repository_name text NOT NULL,
+		repo.GetOwner().GetLogin(),  // repository_owner text NOT NULL,
+		repo.GetFullName(),          // repository_fullname text NOT NULL,
+		run.GetStartedAt().UTC(),    // started_at timestamptz,
+		run.GetStatus(),             // status text,
+		ver,
+	)
+}
+
+// UpsertCheckSuite (github_check_suites_versioned)
+func (db *Database) UpsertCheckSuite(ctx context.Context, repo *gh.Repository, suite *gh.CheckSuite) error {
+	const tab = "github_check_suites_versioned"
+	ver := version()
+
+	query := upsertQuery(tab)
+	return db.txUpsertContext(ctx, tab, query,
+		sum256(
+			repo.GetID(),
+			suite.GetHeadSHA(),
+			suite.GetID(),
+		), // sum256,
+		pq.Array([]int64{ver}),     // versions,
+		suite.GetAfterSHA(),        // after text,
+		suite.GetApp().GetID(),     // app_id bigint,
+		suite.GetApp().GetName(),   // app_name text,
+		suite.GetBeforeSHA(),       // before text,
+		suite.GetConclusion(),      // conclusion text,
+		suite.GetHeadBranch(),      // head_branch text,
+		suite.GetHeadSHA(),         // head_sha text NOT NULL,
+		suite.GetID(),              // id bigint,
+		suite.GetNodeID(),          // node_id text,
+		repo.GetName(),             // repository_name text NOT NULL,
+		repo.GetOwner().GetLogin(), // repository_owner text NOT NULL,
+		repo.GetFullName(),         // repository_fullname text NOT NULL,
+		suite.GetStatus(),          // status text,
+		ver,
+	)
+}
+
+// UpsertStatus (github_statuses_versioned) records the commit status.
+// Every change of the status' state is a new status (with a new ID) of the same context.
+func (db *Database) UpsertStatus(ctx context.Context, repo *gh.Repository, status *gh.StatusEvent) error {
+	const tab = "github_statuses_versioned"
+	ver := version()
+
+	query := upsertQuery(tab)
+	return db.txUpsertContext(ctx, tab, query,
+		sum256(
+			repo.GetID(),
+			status.GetSHA(),
+			status.GetID(),
+		), // sum256,
+		pq.Array([]int64{ver}),      // versions,
+		status.GetContext(),         // context text NOT NULL,
+		status.GetCreatedAt().UTC(), // created_at timestamptz,
+		status.GetDescription(),     // description text,
+		status.GetID(),              // id bigint,
+		repo.GetName(),              // repository_name text NOT NULL,
+		repo.GetOwner().GetLogin(),  // repository_owner text NOT NULL,
+		repo.GetFullName(),          // repository_fullname text NOT NULL,
+		status.GetSHA(),             // sha text NOT NULL,
+		status.GetState(),           // state text NOT NULL,
+		status.GetTargetURL(),       // target_url text,
+		status.GetUpdatedAt().UTC(), // updated_at timestamptz,
+		ver,
+	)
+}
+
+// UpsertDeployment (github_deployments_versioned)
+func (db *Database) UpsertDeployment(ctx context.Context, repo *gh.Repository, deployment *gh.Deployment) error {
+	const tab = "github_deployments_versioned"
+	ver := version()
+
+	// payload is any JSON the deployment was created with
+	var payload interface{}
+	if len(deployment.Payload) > 0 {
+		payload = string(deployment.Payload)
+	}
+
+	query := upsertQuery(tab)
+	return db.txUpsertContext(ctx, tab, query,
+		sum256(
+			repo.GetID(),
+			deployment.GetID(),
+		), // sum256,
+		pq.Array([]int64{ver}),             // versions,
+		deployment.GetCreatedAt().UTC(),    // created_at timestamptz,
+		deployment.GetCreator().GetID(),    // creator_id bigint,
+		deployment.GetCreator().GetLogin(), // creator_login text,
+		deployment.GetDescription(),        // description text,
+		deployment.GetEnvironment(),        // environment text,
+		deployment.GetID(),                 // id bigint,
+		deployment.GetNodeID(),             // node_id text,
+		payload,                            // payload jsonb,
+		deployment.GetRef(),                // ref text,
+		repo.GetName(),                     // repository_name text NOT NULL,
+		repo.GetOwner().GetLogin(),         // repository_owner text NOT NULL,
+		repo.GetFullName(),                 // repository_fullname text NOT NULL,
+		deployment.GetSHA(),                // sha text NOT NULL,
+		deployment.GetTask(),               // task text,
+		deployment.GetUpdatedAt().UTC(),    // updated_at timestamptz,
+		ver,
+	)
+}
+
+// UpsertDeploymentStatus (github_deployment_statuses_versioned)
+func (db *Database) UpsertDeploymentStatus(ctx context.Context, repo *gh.Repository, deployment *gh.Deployment, status *gh.DeploymentStatus) error {
+	const tab = "github_deployment_statuses_versioned"
+	ver := version()
+
+	query := upsertQuery(tab)
+	return db.txUpsertContext(ctx, tab, query,
+		sum256(
+			repo.GetID(),
+			deployment.GetID(),
+			status.GetID(),
+		), // sum256,
+		pq.Array([]int64{ver}),         // versions,
+		status.GetCreatedAt().UTC(),    // created_at timestamptz,
+		status.GetCreator().GetID(),    // creator_id bigint,
+		status.GetCreator().GetLogin(), // creator_login text,
+		deployment.GetID(),             // deployment_id bigint NOT NULL,
+		status.GetDescription(),        // description text,
+		deployment.GetEnvironment(),    // environment text,
+		status.GetID(),                 // id bigint,
+		status.GetNodeID(),             // node_id text,
+		repo.GetName(),                 // repository_name text NOT NULL,
+		repo.GetOwner().GetLogin(),     // repository_owner text NOT NULL,
+		repo.GetFullName(),             // repository_fullname text NOT NULL,
+		status.GetState(),              // state text NOT NULL,
+		status.GetTargetURL(),          // target_url text,
+		status.GetUpdatedAt().UTC(),    // updated_at timestamptz,
+		ver,
+	)
+}
+
+// UpsertLabel (github_labels_versioned)
+// Label payloads have no timestamps, so the label is updated when the event was received.
+func (db *Database) UpsertLabel(ctx context.Context, repo *gh.Repository, label *gh.Label) error {
+	const tab = "github_labels_versioned"
+	ver := version()
+
+	query := upsertQuery(tab)
+	return db.txUpsertContext(ctx, tab, query,
+		sum256(
+			repo.GetID(),
+			label.GetID(),
+		), // sum256,
+		pq.Array([]int64{ver}),     // versions,
+		label.GetColor(),           // color text,
+		label.GetDescription(),     // description text,
+		label.GetID(),              // id bigint,
+		label.GetName(),            // name text NOT NULL,
+		label.GetNodeID(),          // node_id text,
+		repo.GetName(),             // repository_name text NOT NULL,
+		repo.GetOwner().GetLogin(), // repository_owner text NOT NULL,
+		repo.GetFullName(),         // repository_fullname text NOT NULL,
+		db.eventTime(),             // updated_at timestamptz,
+		ver,
+	)
+}
+
+// DeleteLabel (github_labels_versioned)
+func (db *Database) DeleteLabel(ctx context.Context, repo *gh.Repository, label *gh.Label) error {
+	return db.markDeleted(ctx, "github_labels_versioned",
+		sum256(
+			repo.GetID(),
+			label.GetID(),
+		),
+		func() error {
+			return db.UpsertLabel(ctx, repo, label)
+		},
+	)
+}
+
+// RenameLabel (github_issues_versioned, github_pull_requests_versioned, issue_changes) replaces the old name
+// of the renamed label in labels and label changes of all issues and pull requests of the repository,
+// so they are grouped by the current name. Issues and pull requests, which already have the current name,
+// keep a single one. Renames may be received out of order, so the old name is replaced by the latest name
+// of the label (see UpsertLabel), not by the name in the payload. All rows are renamed in one transaction.
+func (db *Database) RenameLabel(ctx context.Context, repo *gh.Repository, label *gh.Label, from string) error {
+	return db.withTx(ctx, func(db *Database) error {
+		var to string
+		if err := db.QueryRowContext(ctx,
+			`SELECT name FROM github_labels_versioned WHERE sum256 = $1`,
+			sum256(
+				repo.GetID(),
+				label.GetID(),
+			),
+		).Scan(&to); err != nil {
+			return err
+		}
+		if to == from {
+			return nil
+		}
+
+		for _, tab := range []string{"github_issues_versioned", "github_pull_requests_versioned"} {
+			if err := db.updateVersioned(ctx, tab,
+				`labels = array(
+					SELECT l FROM unnest(array_replace(labels, $3::text, $4::text)) WITH ORDINALITY AS t(l, i)
+					GROUP BY l
+					ORDER BY min(i))`,
+				`repository_fullname = $2 AND $3::text = ANY(labels)`,
+				repo.GetFullName(),
+				from,
+				to,
+			); err != nil {
+				return err
+			}
+		}
+
+		const query = `UPDATE issue_changes SET label = $3 WHERE repository_fullname = $1 AND label = $2`
+		res, err := db.ExecContext(ctx, query,
+			repo.GetFullName(),
+			from,
+			to,
+		)
+		if err != nil {
+			log.Printf("query: %s, error: %v\n", query, err)
+			return err
+		}
+		if n, err := res.RowsAffected(); err == nil && n > 0 {
+			db.changed("issue_changes")
+		}
+		return nil
+	})
+}
+
+// UpsertMilestone (github_milestones_versioned)
+func (db *Database) UpsertMilestone(ctx context.Context, repo *gh.Repository, milestone *gh.Milestone) error {
+	const tab = "github_milestones_versioned"
+	ver := version()
+
+	query := upsertQuery(tab)
+	return db.txUpsertContext(ctx, tab, query,
+		sum256(
+			repo.GetID(),
+			milestone.GetID(),
+		), // sum256,
+		pq.Array([]int64{ver}),            // versions,
+		milestone.GetClosedAt(),           // closed_at timestamptz,
+		milestone.GetClosedIssues(),       // closed_issues bigint,
+		milestone.GetCreatedAt(),          // created_at timestamptz,
+		milestone.GetCreator().GetID(),    // creator_id bigint,
+		milestone.GetCreator().GetLogin(), // creator_login text,
+		milestone.GetDescription(),        // description text,
+		milestone.GetDueOn(),              // due_on timestamptz,
+		milestone.GetHTMLURL(),            // htmlurl text,
+		milestone.GetID(),                 // id bigint,
+		milestone.GetNodeID(),             // node_id text,
+		milestone.GetNumber(),             // number bigint,
+		milestone.GetOpenIssues(),         // open_issues bigint,
+		repo.GetName(),                    // repository_name text NOT NULL,
+		repo.GetOwner().GetLogin(),        // repository_owner text NOT NULL,
+		repo.GetFullName(),                // repository_fullname text NOT NULL,
+		milestone.GetState(),              // state text,
+		milestone.GetTitle(),              // title text NOT NULL,
+		milestone.GetUpdatedAt(),          // updated_at timestamptz,
+		ver,
+	)
+}
+
+// DeleteMilestone (github_milestones_versioned)
+func (db *Database) DeleteMilestone(ctx context.Context, repo *gh.Repository, milestone *gh.Milestone) error {
+	return db.markDeleted(ctx, "github_milestones_versioned",
+		sum256(
+			repo.GetID(),
+			milestone.GetID(),
+		),
+		func() error {
+			return db.UpsertMilestone(ctx, repo, milestone)
+		},
+	)
+}
+
+// RenameMilestone (github_issues_versioned, github_pull_requests_versioned) sets the current title of the renamed
+// milestone to all issues and pull requests of the repository in the milestone.
+func (db *Database) RenameMilestone(ctx context.Context, repo *gh.Repository, milestone *gh.Milestone) error {
+	for _, tab := range []string{"github_issues_versioned", "github_pull_requests_versioned"} {
+		if err := db.updateVersioned(ctx, tab,
+			`milestone_title = $4`,
+			`repository_fullname = $2 AND milestone_id = $3 AND milestone_title <> $4`,
+			repo.GetFullName(),
+			milestone.GetID(),
+			milestone.GetTitle(),
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateVersioned sets columns (placeholders from $2) of all rows of the versioned table matching the predicate
+// and appends the new version ($1) to them.
+func (db *Database) updateVersioned(ctx context.Context, tab, set, where string, args ...interface{}) error {
+	query := withHistory(tab, fmt.Sprintf(`
+	UPDATE %s
+	SET versions = array_append(%s.versions, $1),
+		%s
+	WHERE %s`, tab, tab, set, where))
+	res, err := db.ExecContext(ctx, query, append([]interface{}{version()}, args...)...)
+	if err != nil {
+		log.Printf("query: %s, args: %v, error: %v\n", query, args, err)
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n > 0 {
+		db.changed(tab)
+	}
+	return nil
+}
+
+// upsertQuery returns the upsert statement for the given versioned table.
+// On conflict the head row is overwritten with the new values and the new version is appended,
+// unless the stored row is newer (see orderedBy).
+// Placeholders are: $1 sum256, $2 versions, then one per column (in order of tables[tab]),
+// the appended version and the installation_id (added by txUpsertContext).
+func upsertQuery(tab string) string {
+	cols := strings.Split(tables[tab], ", ")
+
+	values := make([]string, len(cols)+2)
+	for i := range values {
+		values[i] = fmt.Sprintf("$%d", i+1)
+	}
+	values = append(values, fmt.Sprintf("$%d", len(values)+2))
+
+	set := make([]string, len(cols))
+	for i, c := range cols {
+		set[i] = fmt.Sprintf("%s = EXCLUDED.%s", c, c)
+	}
+
+	var where string
+	if col, ok := orderedBy[tab]; ok {
+		where = fmt.Sprintf(`
+	WHERE %s.%s IS NULL OR %s.%s <= EXCLUDED.%s`, tab, col, tab, col, col)
+	}
+
+	return withHistory(tab, fmt.Sprintf(`
+	INSERT INTO %s (sum256, versions, %s, installation_id)
+	VALUES (%s)
+	ON CONFLICT (sum256)
+	DO UPDATE
+	SET versions = array_append(%s.versions, $%d),
+		%s,
+		installation_id = COALESCE(EXCLUDED.installation_id, %s.installation_id)%s`,
+		tab, tables[tab], strings.Join(values, ", "), tab, len(values), strings.Join(set, ",\n\t\t"), tab, where))
+}
+
+// withHistory wraps a statement, which changes a single row of the versioned table,
+// so the whole changed row is also copied to the table's history, keyed by (sum256, version).
+func withHistory(tab, stmt string) string {
+	return fmt.Sprintf(`
+	WITH changed AS (%s
+	RETURNING *)
+	INSERT INTO %s
+	SELECT changed.versions[array_length(changed.versions, 1)], changed.*
+	FROM changed`, stmt, historyTable(tab))
+}
+
+// historyTable returns the name of the history table of the given versioned table,
+// e.g. github_issues_versioned -> github_issues_history.
+func historyTable(tab string) string {
+	return strings.TrimSuffix(tab, "_versioned") + "_history"
+}
+
+// sum256 hashes the given identifiers (int64 or string) into a hex encoded key.
+func sum256(ids ...interface{}) string {
 	buf := new(bytes.Buffer)
 	hash := sha256.New()
 	for _, id := range ids {
-		binary.Write(buf, binary.LittleEndian, id)
+		switch id := id.(type) {
+		case string:
+			buf.WriteString(id)
+		default:
+			binary.Write(buf, binary.LittleEndian, id)
+		}
 		hash.Write(buf.Bytes())
 		buf.Reset()
 	}
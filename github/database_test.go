@@ -2,10 +2,14 @@ package github
 
 import (
 	"context"
+	"encoding/json"
 	"io/ioutil"
 	"os"
 	"testing"
+	"time"
 
+	gh "github.com/google/go-github/v28/github"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/require"
 )
 
@@ -30,6 +34,18 @@ func TestProcess(t *testing.T) {
 			)`,
 			expected: []interface{}{int64(85718512)},
 		},
+		{
+			name:     "installation",
+			fixture:  "testdata/installation_event.json",
+			query:    `select app_id from installations where id=6094607 and account_login='kuba--' and deleted_at is null`,
+			expected: []interface{}{int64(49039)},
+		},
+		{
+			name:     "installation",
+			fixture:  "testdata/installation_event.json",
+			query:    `select installation_id from github_repositories_versioned where id=85718512`,
+			expected: []interface{}{int64(6094607)},
+		},
 		{
 			name:    "repository",
 			fixture: "testdata/repository_event.json",
@@ -64,6 +80,16 @@ func TestProcess(t *testing.T) {
 			)`,
 			expected: []interface{}{"You are totally right! I'll get this fixed right away."},
 		},
+		{
+			name:    "issue_comment",
+			fixture: "testdata/issue_comment_deleted_event.json",
+			query: `select count(*) from github_issue_comments where (
+				id=2 and
+				issue_number=1 and
+				user_login='Codertocat'
+			)`,
+			expected: []interface{}{int64(0)},
+		},
 		{
 			name:    "issues",
 			fixture: "testdata/issues_event.json",
@@ -74,6 +100,15 @@ func TestProcess(t *testing.T) {
 			)`,
 			expected: []interface{}{"Hello-World"},
 		},
+		{
+			name:    "issues",
+			fixture: "testdata/issues_stale_event.json",
+			query: `select title from github_issues_versioned where (
+				id=10 and
+				number=1
+			)`,
+			expected: []interface{}{"Spelling error in the README file"},
+		},
 		{
 			name:    "pull_request",
 			fixture: "testdata/pull_request_event.json",
@@ -84,6 +119,47 @@ func TestProcess(t *testing.T) {
 				)`,
 			expected: []interface{}{"Update the README with new information."},
 		},
+		{
+			name:    "pull_request",
+			fixture: "testdata/pull_request_edited_event.json",
+			query: `select distinct title from github_pull_requests_history where (
+				number=2 and
+				head_sha='14977a7b5485400124827221a04bfb474bcd72d1'
+				) order by title`,
+			expected: []interface{}{"Update the README with new information.", "Update the README with the latest information."},
+		},
+		{
+			name:    "pull_request",
+			fixture: "testdata/pull_request_synchronize_event.json",
+			query: `select after from github_pull_request_pushes where (
+				pull_request_number=2 and
+				before='14977a7b5485400124827221a04bfb474bcd72d1' and
+				sender_login='Codertocat'
+				)`,
+			expected: []interface{}{"ec26c3e57ca3a959ca5aad62de7213c562f8c821"},
+		},
+		{
+			name:    "pull_request",
+			fixture: "testdata/pull_request_closed_event.json",
+			query: `select merged_by_login from github_pull_requests_versioned where (
+				number=2 and
+				merged=true and
+				state='closed' and
+				merge_commit_sha='c4295bd74fb0f4fda03689c3df3f2803b658fd85'
+				)`,
+			expected: []interface{}{"Codertocat"},
+		},
+		{
+			name:    "pull_request",
+			fixture: "testdata/pull_request_closed_event.json",
+			query: `select sender_login from pull_request_state_transitions where (
+				pull_request_number=2 and
+				repository_fullname='Codertocat/Hello-World' and
+				action='merged' and
+				state='merged'
+				)`,
+			expected: []interface{}{"Codertocat"},
+		},
 		{
 			name:    "pull_request_review",
 			fixture: "testdata/pull_request_review_event.json",
@@ -93,6 +169,16 @@ func TestProcess(t *testing.T) {
 				)`,
 			expected: []interface{}{int64(2)},
 		},
+		{
+			name:    "pull_request_review",
+			fixture: "testdata/pull_request_review_event.json",
+			query: `select login from github_users_versioned where (
+				id=4 and
+				organization_id=0 and
+				node_id='MDQ6VXNlcjQ='
+				)`,
+			expected: []interface{}{"Codertocat"},
+		},
 		{
 			name:    "pull_request_review_comment",
 			fixture: "testdata/pull_request_review_comment_event.json",
@@ -103,6 +189,181 @@ func TestProcess(t *testing.T) {
 			)`,
 			expected: []interface{}{"@@ -1 +1 @@\n-# Hello-World"},
 		},
+		{
+			name:    "push",
+			fixture: "testdata/push_event.json",
+			query: `select message from github_commits_versioned where (
+				sha='7cf7562f57236a0429a007d6b5f3a0bc6e758464' and
+				ref='refs/heads/kuba---patch-1' and
+				repository_fullname='kuba--/cuckoo' and
+				modified='{.travis.yml}'
+			)`,
+			expected: []interface{}{"Update .travis.yml"},
+		},
+		{
+			name:    "create",
+			fixture: "testdata/create_event.json",
+			query: `select master_branch from github_refs_versioned where (
+				ref='kuba---patch-1' and
+				ref_type='branch' and
+				repository_fullname='kuba--/cuckoo' and
+				deleted_at is null
+			)`,
+			expected: []interface{}{"master"},
+		},
+		{
+			name:    "delete",
+			fixture: "testdata/delete_event.json",
+			query: `select deleted_by_login from github_refs_versioned where (
+				ref='kuba---patch-1' and
+				ref_type='branch' and
+				master_branch='master' and
+				deleted_at is not null
+			)`,
+			expected: []interface{}{"kuba--"},
+		},
+		{
+			name:    "release",
+			fixture: "testdata/release_event.json",
+			query: `select tag_name from github_releases where (
+				id=23216788 and
+				repository_fullname='kuba--/cuckoo' and
+				target_commitish='master' and
+				author_login='kuba--' and
+				draft=false and
+				installation_id=6094607
+			)`,
+			expected: []interface{}{"v1.1.0"},
+		},
+		{
+			name:    "check_run",
+			fixture: "testdata/check_run_event.json",
+			query: `select conclusion from github_check_runs where (
+				id=398237523 and
+				repository_fullname='kuba--/cuckoo' and
+				head_sha='6dcb09b5b57875f334f61aebed695e2e4193db5e' and
+				name='build' and
+				app_name='GitHub Actions' and
+				check_suite_id=403766813 and
+				installation_id=6094607
+			)`,
+			expected: []interface{}{"success"},
+		},
+		{
+			name:    "check_suite",
+			fixture: "testdata/check_suite_event.json",
+			query: `select status from github_check_suites where (
+				id=403766813 and
+				repository_fullname='kuba--/cuckoo' and
+				head_sha='6dcb09b5b57875f334f61aebed695e2e4193db5e' and
+				head_branch='master' and
+				installation_id=6094607
+			)`,
+			expected: []interface{}{"completed"},
+		},
+		{
+			name:    "status",
+			fixture: "testdata/status_event.json",
+			query: `select state from github_statuses where (
+				id=8542513291 and
+				repository_fullname='kuba--/cuckoo' and
+				sha='6dcb09b5b57875f334f61aebed695e2e4193db5e' and
+				context='continuous-integration/travis-ci/push' and
+				installation_id=6094607
+			)`,
+			expected: []interface{}{"success"},
+		},
+		{
+			name:    "deployment",
+			fixture: "testdata/deployment_event.json",
+			query: `select environment from github_deployments where (
+				id=205911876 and
+				repository_fullname='kuba--/cuckoo' and
+				sha='6dcb09b5b57875f334f61aebed695e2e4193db5e' and
+				ref='master' and
+				creator_login='kuba--' and
+				payload->>'region'='eu-west-1' and
+				installation_id=6094607
+			)`,
+			expected: []interface{}{"production"},
+		},
+		{
+			name:    "deployment_status",
+			fixture: "testdata/deployment_status_event.json",
+			query: `select state from github_deployment_statuses where (
+				id=291853921 and
+				deployment_id=205911876 and
+				repository_fullname='kuba--/cuckoo' and
+				environment='production' and
+				installation_id=6094607
+			)`,
+			expected: []interface{}{"success"},
+		},
+		{
+			name:    "issues",
+			fixture: "testdata/issues_labeled_event.json",
+			query: `select label from issue_changes where (
+				issue_id=10 and
+				issue_number=1 and
+				action='labeled' and
+				not pull_request and
+				sender_login='Codertocat' and
+				user_login='Codertocat' and
+				changed_at='2019-05-15T19:38:20Z' and
+				installation_id=5
+			)`,
+			expected: []interface{}{"bug"},
+		},
+		{
+			name:    "pull_request",
+			fixture: "testdata/pull_request_assigned_event.json",
+			query: `select assignee_login from issue_changes where (
+				issue_id=2 and
+				issue_number=2 and
+				action='assigned' and
+				pull_request and
+				changed_at='2019-05-15T19:40:30Z' and
+				installation_id=5
+			)`,
+			expected: []interface{}{"Codertocat"},
+		},
+		{
+			name:    "label",
+			fixture: "testdata/label_event.json",
+			query: `select array_to_string(i.labels, ',') from github_issues_versioned i, github_labels l where (
+				i.id=10 and
+				l.id=941 and
+				l.name='type: bug' and
+				l.color='d73a4a' and
+				l.repository_fullname=i.repository_fullname and
+				l.installation_id=5
+			)`,
+			expected: []interface{}{"type: bug"},
+		},
+		{
+			name:    "label",
+			fixture: "testdata/label_event.json",
+			query: `select login from github_users_versioned where (
+				id=4 and
+				organization_id=0 and
+				installation_id=5
+			)`,
+			expected: []interface{}{"Codertocat"},
+		},
+		{
+			name:    "milestone",
+			fixture: "testdata/milestone_event.json",
+			query: `select i.milestone_title from github_issues_versioned i, github_milestones m where (
+				i.id=10 and
+				m.id=2 and
+				m.number=1 and
+				m.title='v1.0.0' and
+				m.creator_login='Codertocat' and
+				m.repository_fullname=i.repository_fullname and
+				m.installation_id=5
+			)`,
+			expected: []interface{}{"v1.0.0"},
+		},
 		{
 			name:    "repository",
 			fixture: "testdata/empty_event.json",
@@ -155,3 +416,264 @@ func TestProcess(t *testing.T) {
 		})
 	}
 }
+
+// TestProcessPullRequestEdited checks that the edited title overwrites the head row,
+// whichever newer versions of the pull request other tests processed before.
+func TestProcessPullRequestEdited(t *testing.T) {
+	require := require.New(t)
+	ctx := context.TODO()
+
+	db, err := OpenDatabase(dbURI, 0, 0)
+	require.NoError(err)
+	defer db.Close()
+
+	sum := sum256(int64(118), int64(2))
+	for _, tab := range []string{"github_pull_requests_versioned", "github_pull_requests_history"} {
+		_, err = db.ExecContext(ctx, `DELETE FROM `+tab+` WHERE sum256 = $1`, sum)
+		require.NoError(err)
+	}
+
+	for _, fixture := range []string{"testdata/pull_request_event.json", "testdata/pull_request_edited_event.json"} {
+		payload, err := ioutil.ReadFile(fixture)
+		require.NoError(err)
+		require.NoError((&Event{Type: "pull_request", Payload: payload}).Process(ctx, db))
+	}
+
+	var title string
+	require.NoError(db.QueryRowContext(ctx,
+		`SELECT title FROM github_pull_requests_versioned WHERE sum256 = $1`, sum,
+	).Scan(&title))
+	require.Equal("Update the README with the latest information.", title)
+
+	var versions int
+	require.NoError(db.QueryRowContext(ctx,
+		`SELECT count(*) FROM github_pull_requests_history WHERE sum256 = $1`, sum,
+	).Scan(&versions))
+	require.Equal(2, versions)
+}
+
+// TestProcessRefs checks that refs are timed by when their events were received,
+// even if the delete event is received before the create event.
+func TestProcessRefs(t *testing.T) {
+	require := require.New(t)
+	ctx := context.TODO()
+
+	db, err := OpenDatabase(dbURI, 0, 0)
+	require.NoError(err)
+	defer db.Close()
+
+	sum := sum256(int64(85718512), "branch", "kuba---patch-1")
+	for _, tab := range []string{"github_refs_versioned", "github_refs_history"} {
+		_, err = db.ExecContext(ctx, `DELETE FROM `+tab+` WHERE sum256 = $1`, sum)
+		require.NoError(err)
+	}
+
+	process := func(typ, fixture string, receivedAt time.Time) {
+		payload, err := ioutil.ReadFile(fixture)
+		require.NoError(err)
+		require.NoError((&Event{Type: typ, Payload: payload, ReceivedAt: receivedAt}).Process(ctx, db))
+	}
+	ref := func() (createdAt, deletedAt *time.Time, masterBranch *string) {
+		require.NoError(db.QueryRowContext(ctx,
+			`SELECT created_at, deleted_at, master_branch FROM github_refs_versioned WHERE sum256 = $1`, sum,
+		).Scan(&createdAt, &deletedAt, &masterBranch))
+		return
+	}
+
+	created := time.Date(2020, 3, 1, 10, 0, 0, 0, time.UTC)
+	deleted := created.Add(time.Hour)
+
+	// the ref deleted before its creation was seen is a tombstone-only row.
+	process("delete", "testdata/delete_event.json", deleted)
+	createdAt, deletedAt, masterBranch := ref()
+	require.Nil(createdAt)
+	require.Nil(masterBranch)
+	require.NotNil(deletedAt)
+	require.True(deleted.Equal(*deletedAt))
+
+	// the earlier creation, received later, keeps the deletion.
+	process("create", "testdata/create_event.json", created)
+	createdAt, deletedAt, masterBranch = ref()
+	require.NotNil(createdAt)
+	require.True(created.Equal(*createdAt))
+	require.NotNil(deletedAt)
+	require.True(deleted.Equal(*deletedAt))
+	require.Equal("master", *masterBranch)
+
+	// the ref created again.
+	recreated := deleted.Add(time.Hour)
+	process("create", "testdata/create_event.json", recreated)
+	createdAt, deletedAt, _ = ref()
+	require.True(recreated.Equal(*createdAt))
+	require.Nil(deletedAt)
+
+	var versions int
+	require.NoError(db.QueryRowContext(ctx,
+		`SELECT count(*) FROM github_refs_history WHERE sum256 = $1`, sum,
+	).Scan(&versions))
+	require.Equal(3, versions)
+}
+
+// TestProcessDeletedUnseen checks that the deletion of an entity, which wasn't seen before, is kept as a tombstone
+// deleted when the event was received.
+func TestProcessDeletedUnseen(t *testing.T) {
+	require := require.New(t)
+	ctx := context.TODO()
+
+	db, err := OpenDatabase(dbURI, 0, 0)
+	require.NoError(err)
+	defer db.Close()
+
+	sum := sum256(int64(118), int64(10), int64(2))
+	for _, tab := range []string{"github_issue_comments_versioned", "github_issue_comments_history"} {
+		_, err = db.ExecContext(ctx, `DELETE FROM `+tab+` WHERE sum256 = $1`, sum)
+		require.NoError(err)
+	}
+
+	payload, err := ioutil.ReadFile("testdata/issue_comment_deleted_event.json")
+	require.NoError(err)
+	receivedAt := time.Date(2020, 3, 1, 10, 0, 0, 0, time.UTC)
+	require.NoError((&Event{Type: "issue_comment", Payload: payload, ReceivedAt: receivedAt}).Process(ctx, db))
+
+	var deletedAt time.Time
+	require.NoError(db.QueryRowContext(ctx,
+		`SELECT deleted_at FROM github_issue_comments_versioned WHERE sum256 = $1`, sum,
+	).Scan(&deletedAt))
+	require.True(receivedAt.Equal(deletedAt))
+
+	var count int
+	require.NoError(db.QueryRowContext(ctx,
+		`SELECT count(*) FROM github_issue_comments_history WHERE sum256 = $1 AND deleted_at IS NOT NULL`, sum,
+	).Scan(&count))
+	require.Equal(1, count)
+}
+
+// TestRenameLabel checks renames of labels to the name the issue already has and renames received out of order.
+func TestRenameLabel(t *testing.T) {
+	require := require.New(t)
+	ctx := context.TODO()
+
+	db, err := OpenDatabase(dbURI, 0, 0)
+	require.NoError(err)
+	defer db.Close()
+
+	payload, err := ioutil.ReadFile("testdata/issues_event.json")
+	require.NoError(err)
+	require.NoError((&Event{Type: "issues", Payload: payload}).Process(ctx, db))
+
+	issue, label := sum256(int64(118), int64(10)), sum256(int64(118), int64(941))
+	for _, tab := range []string{"github_labels_versioned", "github_labels_history"} {
+		_, err = db.ExecContext(ctx, `DELETE FROM `+tab+` WHERE sum256 = $1`, label)
+		require.NoError(err)
+	}
+	setLabels := func(labels ...string) {
+		_, err := db.ExecContext(ctx, `UPDATE github_issues_versioned SET labels = $2 WHERE sum256 = $1`, issue, pq.Array(labels))
+		require.NoError(err)
+	}
+	labels := func() []string {
+		var labels []string
+		require.NoError(db.QueryRowContext(ctx,
+			`SELECT labels FROM github_issues_versioned WHERE sum256 = $1`, issue,
+		).Scan(pq.Array(&labels)))
+		return labels
+	}
+	rename := func(from, to string, receivedAt time.Time) {
+		payload, err := ioutil.ReadFile("testdata/label_event.json")
+		require.NoError(err)
+		var event map[string]interface{}
+		require.NoError(json.Unmarshal(payload, &event))
+		event["label"].(map[string]interface{})["name"] = to
+		event["changes"] = map[string]interface{}{"name": map[string]interface{}{"from": from}}
+		payload, err = json.Marshal(event)
+		require.NoError(err)
+		require.NoError((&Event{Type: "label", Payload: payload, ReceivedAt: receivedAt}).Process(ctx, db))
+	}
+
+	renamedAt := time.Date(2020, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	// the issue already has the new name.
+	setLabels("bug", "type: bug", "wontfix")
+	rename("bug", "type: bug", renamedAt)
+	require.Equal([]string{"type: bug", "wontfix"}, labels())
+
+	// "type: bug" -> "bug" -> "crash", received in the reverse order.
+	setLabels("type: bug")
+	rename("bug", "crash", renamedAt.Add(2*time.Hour))
+	rename("type: bug", "bug", renamedAt.Add(time.Hour))
+	require.Equal([]string{"crash"}, labels())
+
+	var name string
+	require.NoError(db.QueryRowContext(ctx,
+		`SELECT name FROM github_labels_versioned WHERE sum256 = $1`, label,
+	).Scan(&name))
+	require.Equal("crash", name)
+}
+
+// TestProcessUsers checks that minimal users of event payloads don't overwrite the stored users.
+func TestProcessUsers(t *testing.T) {
+	require := require.New(t)
+	ctx := context.TODO()
+
+	db, err := OpenDatabase(dbURI, 0, 0)
+	require.NoError(err)
+	defer db.Close()
+
+	// the event is of the installation 5
+	idb := db.ForInstallation(5)
+	sum := idb.userKey(nil, 4)
+	for _, tab := range []string{"github_users_versioned", "github_users_history"} {
+		_, err = db.ExecContext(ctx, `DELETE FROM `+tab+` WHERE sum256 = $1`, sum)
+		require.NoError(err)
+	}
+	require.NoError(idb.UpsertUser(ctx, nil, &gh.User{
+		ID:        gh.Int64(4),
+		Login:     gh.String("Codertocat"),
+		Name:      gh.String("The Codertocat"),
+		Company:   gh.String("GitHub"),
+		UpdatedAt: &gh.Timestamp{Time: time.Date(2020, 3, 1, 10, 0, 0, 0, time.UTC)},
+	}))
+
+	payload, err := ioutil.ReadFile("testdata/pull_request_review_event.json")
+	require.NoError(err)
+	require.NoError((&Event{Type: "pull_request_review", Payload: payload}).Process(ctx, db))
+
+	var name, company string
+	require.NoError(db.QueryRowContext(ctx,
+		`SELECT name, company FROM github_users_versioned WHERE sum256 = $1`, sum,
+	).Scan(&name, &company))
+	require.Equal("The Codertocat", name)
+	require.Equal("GitHub", company)
+
+	var versions int
+	require.NoError(db.QueryRowContext(ctx,
+		`SELECT count(*) FROM github_users_history WHERE sum256 = $1`, sum,
+	).Scan(&versions))
+	require.Equal(1, versions)
+}
+
+// TestInsertIssueChange checks that changes made within the same second are kept apart by their deliveries,
+// and that redeliveries are ignored.
+func TestInsertIssueChange(t *testing.T) {
+	require := require.New(t)
+	ctx := context.TODO()
+
+	db, err := OpenDatabase(dbURI, 0, 0)
+	require.NoError(err)
+	defer db.Close()
+
+	payload, err := ioutil.ReadFile("testdata/issues_labeled_event.json")
+	require.NoError(err)
+
+	const deliveryPrefix = "test-insert-issue-change-"
+	_, err = db.ExecContext(ctx, `DELETE FROM issue_changes WHERE issue_id = 10 AND changed_at = '2019-05-15T19:38:20Z'`)
+	require.NoError(err)
+	for _, deliveryID := range []string{"1", "2", "2"} {
+		require.NoError((&Event{Type: "issues", DeliveryID: deliveryPrefix + deliveryID, Payload: payload}).Process(ctx, db))
+	}
+
+	var count int
+	require.NoError(db.QueryRowContext(ctx,
+		`SELECT count(*) FROM issue_changes WHERE issue_id = 10 AND action = 'labeled' AND changed_at = '2019-05-15T19:38:20Z'`,
+	).Scan(&count))
+	require.Equal(2, count)
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log"
+	"time"
 
 	gh "github.com/google/go-github/v28/github"
 )
@@ -13,6 +15,16 @@ type Event struct {
 	// Event types: https://developer.github.com/v3/activity/events/types/
 	Type string `json:"type"`
 
+	// DeliveryID is the unique ID (GUID) of the webhook delivery (X-GitHub-Delivery header).
+	// Redeliveries of the same event carry the same ID.
+	DeliveryID string `json:"delivery_id,omitempty"`
+
+	// Headers are github's (X-GitHub-*, X-Hub-*) and a few standard headers of the webhook request.
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// ReceivedAt is when the webhook received the event.
+	ReceivedAt time.Time `json:"received_at"`
+
 	// Payload should be json.RawMessage (for optimization and what's expected),
 	// but for safety and fuzzy testing we use []byte,
 	// because based on doc. "[]byte encodes as a base64-encoded string".
@@ -35,10 +47,59 @@ func MarshalEvent(event *Event) ([]byte, error) {
 	return json.Marshal(event)
 }
 
+// Scope returns the installation the event was delivered to and the full name of its repository
+// (zero values if the payload has none or can't be parsed).
+func (e *Event) Scope() (installationID int64, repository string) {
+	var payload struct {
+		Installation struct {
+			ID int64 `json:"id"`
+		} `json:"installation"`
+		Repository struct {
+			FullName string `json:"full_name"`
+		} `json:"repository"`
+	}
+	if err := json.Unmarshal(e.Payload, &payload); err != nil {
+		return 0, ""
+	}
+	return payload.Installation.ID, payload.Repository.FullName
+}
+
 // Process parses the event payload and upserts/alters it to the given database.
 func (e *Event) Process(ctx context.Context, db *Database) error {
 	event, err := gh.ParseWebHook(e.Type, e.Payload)
 	if err != nil {
+		return Permanent(err)
+	}
+
+	db = db.forEvent(e)
+
+	// Rows are written for the installation the event was delivered to.
+	if ie, ok := event.(interface{ GetInstallation() *gh.Installation }); ok && ie.GetInstallation() != nil {
+		inst := ie.GetInstallation()
+		foreign, err := db.isForeignInstallation(ctx, inst)
+		if err != nil {
+			return err
+		}
+		if foreign {
+			log.Printf("ignoring %s event of installation %d of app %d\n", e.Type, inst.GetID(), inst.GetAppID())
+			return nil
+		}
+		// Installation events may install the App again, the others of uninstalled installations
+		// and of repositories removed from the installation are ignored.
+		if _, ok := event.(*gh.InstallationEvent); !ok {
+			inactive, err := db.isInactive(ctx, inst, eventRepositoryID(event))
+			if err != nil {
+				return err
+			}
+			if inactive {
+				log.Printf("ignoring %s event of inactive installation %d or its removed repository\n", e.Type, inst.GetID())
+				return nil
+			}
+		}
+		db = db.ForInstallation(inst.GetID())
+	}
+
+	if err := processUsers(ctx, db, event, e.Payload); err != nil {
 		return err
 	}
 
@@ -73,13 +134,13 @@ func (e *Event) Process(ctx context.Context, db *Database) error {
 		// IssuesEvent is triggered when an issue is opened, edited, deleted, transferred,
 		// pinned, unpinned, closed, reopened, assigned, unassigned, labeled, unlabeled,
 		// locked, unlocked, milestoned, or demilestoned.
-		return processIssuesEvent(ctx, db, event)
+		return processIssuesEvent(ctx, db, event, e.Payload)
 
 	case *gh.PullRequestEvent:
 		// Triggered when a pull request is assigned, unassigned, labeled, unlabeled,
 		// opened, edited, closed, reopened, synchronize, ready_for_review,
 		// locked, unlocked or when a pull request review is requested or removed.
-		return processPullRequestEvent(ctx, db, event)
+		return processPullRequestEvent(ctx, db, event, e.Payload)
 
 	case *gh.PullRequestReviewEvent:
 		// PullRequestReviewEvent is triggered when a review is submitted on a pull
@@ -90,22 +151,84 @@ func (e *Event) Process(ctx context.Context, db *Database) error {
 		// Triggered when a comment on a pull request's unified diff is created,
 		// edited, or deleted (in the Files Changed tab).
 		return processPullRequestReviewCommentEvent(ctx, db, event)
+
+	case *gh.PushEvent:
+		// Triggered on a push to a repository branch. Branch pushes and repository tag pushes
+		// also trigger webhook push events.
+		return processPushEvent(ctx, db, event)
+
+	case *gh.CreateEvent:
+		// Represents a created repository, branch, or tag.
+		return processCreateEvent(ctx, db, event)
+
+	case *gh.DeleteEvent:
+		// Represents a deleted branch or tag.
+		return processDeleteEvent(ctx, db, event)
+
+	case *gh.ReleaseEvent:
+		// Triggered when a release is published, unpublished, created, edited, deleted, or prereleased.
+		return processReleaseEvent(ctx, db, event)
+
+	case *gh.CheckRunEvent:
+		// Triggered when a check run is created, rerequested, completed, or has a requested_action.
+		return processCheckRunEvent(ctx, db, event)
+
+	case *gh.CheckSuiteEvent:
+		// Triggered when a check suite is completed, requested, or rerequested.
+		return processCheckSuiteEvent(ctx, db, event)
+
+	case *gh.StatusEvent:
+		// Triggered when the status of a Git commit changes.
+		return processStatusEvent(ctx, db, event)
+
+	case *gh.DeploymentEvent:
+		// Represents a deployment.
+		return processDeploymentEvent(ctx, db, event)
+
+	case *gh.DeploymentStatusEvent:
+		// Represents a deployment status.
+		return processDeploymentStatusEvent(ctx, db, event)
+
+	case *gh.LabelEvent:
+		// Triggered when a repository's label is created, edited, or deleted.
+		return processLabelEvent(ctx, db, event, e.Payload)
+
+	case *gh.MilestoneEvent:
+		// Triggered when a milestone is created, closed, opened, edited, or deleted.
+		return processMilestoneEvent(ctx, db, event)
 	}
 
 	return nil
 }
 
+// eventRepositoryID returns the ID of the event's repository, 0 if the event has none.
+func eventRepositoryID(event interface{}) int64 {
+	switch event := event.(type) {
+	case *gh.PushEvent:
+		return event.GetRepo().GetID()
+	case interface{ GetRepo() *gh.Repository }:
+		return event.GetRepo().GetID()
+	}
+	return 0
+}
+
 func processInstallationEvent(ctx context.Context, db *Database, event *gh.InstallationEvent) (err error) {
 	defer errRecover(event, &err)
 
 	switch event.GetAction() {
 	case "deleted":
-		break
+		return db.DeleteInstallation(ctx, event.GetInstallation())
+
+	case "suspend", "unsuspend":
+		return db.SuspendInstallation(ctx, event.GetInstallation(), event.GetAction() == "suspend")
+
+	case "new_permissions_accepted":
+		return db.UpsertInstallation(ctx, event.GetInstallation())
 
 	case "created":
-		// TODO(@kuba--): Probably, somehow we should check
-		// if event.GetInstallation().GetAppID() is our App!
-		// And ignore other installations.
+		if err = db.UpsertInstallation(ctx, event.GetInstallation()); err != nil {
+			break
+		}
 		for _, repo := range event.Repositories {
 			err = db.UpsertRepository(ctx, repo)
 			if err != nil {
@@ -122,10 +245,22 @@ func processInstallationRepositoriesEvent(ctx context.Context, db *Database, eve
 
 	switch event.GetAction() {
 	case "removed":
-		break
+		for _, repo := range event.RepositoriesRemoved {
+			err = db.RemoveRepository(ctx, event.GetInstallation(), repo)
+			if err != nil {
+				break
+			}
+		}
 
 	case "added":
+		if err = db.UpsertInstallation(ctx, event.GetInstallation()); err != nil {
+			break
+		}
 		for _, repo := range event.RepositoriesAdded {
+			err = db.RestoreRepository(ctx, event.GetInstallation(), repo)
+			if err != nil {
+				break
+			}
 			err = db.UpsertRepository(ctx, repo)
 			if err != nil {
 				break
@@ -141,7 +276,7 @@ func processRepositoryEvent(ctx context.Context, db *Database, event *gh.Reposit
 
 	switch event.GetAction() {
 	case "deleted":
-		break
+		return db.DeleteRepository(ctx, event.GetRepo())
 
 	case "anonymous_access_enabled", "anonymous_access_disabled":
 		break
@@ -157,7 +292,10 @@ func processOrganizationEvent(ctx context.Context, db *Database, event *gh.Organ
 	defer errRecover(event, &err)
 
 	switch event.GetAction() {
-	case "deleted", "member_removed":
+	case "deleted":
+		return db.DeleteOrganization(ctx, event.GetOrganization())
+
+	case "member_removed":
 		break
 
 	case "created", "renamed", "member_added", "member_invited":
@@ -172,7 +310,11 @@ func processIssueCommentEvent(ctx context.Context, db *Database, event *gh.Issue
 
 	switch event.GetAction() {
 	case "deleted":
-		break
+		if event.GetIssue().IsPullRequest() {
+			err = db.DeleteIssueCommentAsPullRequest(ctx, event.GetRepo(), event.GetIssue(), event.GetComment())
+		} else {
+			err = db.DeleteIssueComment(ctx, event.GetRepo(), event.GetIssue(), event.GetComment())
+		}
 
 	case "created", "edited":
 		if event.GetIssue().IsPullRequest() {
@@ -185,50 +327,121 @@ func processIssueCommentEvent(ctx context.Context, db *Database, event *gh.Issue
 	return err
 }
 
-func processIssuesEvent(ctx context.Context, db *Database, event *gh.IssuesEvent) (err error) {
+func processIssuesEvent(ctx context.Context, db *Database, event *gh.IssuesEvent, payload []byte) (err error) {
 	defer errRecover(event, &err)
 
 	switch event.GetAction() {
-	case "deleted", "unpinned", "unassigned", "unlocked", "demilestoned":
+	case "deleted":
+		return db.DeleteIssue(ctx, event.GetRepo(), event.GetIssue())
+
+	case "unpinned", "unlocked":
 		break
 
 	case "opened",
 		"edited",
 		"reopened",
 		"transferred",
-		"labeled",
-		"assigned",
 		"closed",
 		"locked",
-		"pinned",
-		"milestoned":
+		"pinned":
 		return db.UpsertIssues(ctx, event.GetRepo(), event.GetIssue())
+
+	case "labeled",
+		"unlabeled",
+		"assigned",
+		"unassigned",
+		"milestoned",
+		"demilestoned":
+		err = db.UpsertIssues(ctx, event.GetRepo(), event.GetIssue())
+		if err != nil {
+			break
+		}
+		var change *IssueChange
+		if change, err = newIssueChange(event.GetAction(), event.GetLabel(), event.GetAssignee(), event.GetSender(), payload); err != nil {
+			break
+		}
+		err = db.InsertIssueChange(ctx, event.GetRepo(), event.GetIssue(), change)
 	}
 
 	return err
 }
 
-func processPullRequestEvent(ctx context.Context, db *Database, event *gh.PullRequestEvent) (err error) {
+func processPullRequestEvent(ctx context.Context, db *Database, event *gh.PullRequestEvent, payload []byte) (err error) {
 	defer errRecover(event, &err)
 
 	switch event.GetAction() {
-	case "closed", "synchronize", "locked", "unlocked":
+	case "locked", "unlocked":
 		break
 
+	case "synchronize":
+		// gh.PullRequestEvent does not carry the pushed range, so we take it from the raw payload.
+		var push pullRequestPush
+		if err = json.Unmarshal(payload, &push); err != nil {
+			break
+		}
+		err = db.UpsertPullRequest(ctx, event.GetRepo(), event.GetPullRequest())
+		if err != nil {
+			break
+		}
+		err = db.InsertPullRequestPush(ctx, event.GetRepo(), event.GetPullRequest(), push.Before, push.After, event.GetSender())
+
+	case "opened", "closed", "reopened":
+		err = db.UpsertPullRequest(ctx, event.GetRepo(), event.GetPullRequest())
+		if err != nil {
+			break
+		}
+		err = db.InsertPullRequestStateTransition(ctx, event.GetRepo(), event.GetPullRequest(), event.GetAction(), event.GetSender())
+
+	case "edited", "ready_for_review":
+		return db.UpsertPullRequest(ctx, event.GetRepo(), event.GetPullRequest())
+
 	case "assigned",
 		"unassigned",
 		"labeled",
 		"unlabeled",
-		"opened",
-		"edited",
-		"reopened",
-		"ready_for_review":
-		return db.UpsertPullRequest(ctx, event.GetRepo(), event.GetPullRequest())
+		"milestoned",
+		"demilestoned":
+		err = db.UpsertPullRequest(ctx, event.GetRepo(), event.GetPullRequest())
+		if err != nil {
+			break
+		}
+		var change *IssueChange
+		if change, err = newIssueChange(event.GetAction(), event.GetLabel(), event.GetAssignee(), event.GetSender(), payload); err != nil {
+			break
+		}
+		err = db.InsertPullRequestChange(ctx, event.GetRepo(), event.GetPullRequest(), change)
 	}
 
 	return err
 }
 
+// newIssueChange returns the change of labels, assignees or the milestone made by the action.
+// gh.IssuesEvent and gh.PullRequestEvent do not carry the milestone, so we take it from the raw payload.
+func newIssueChange(action string, label *gh.Label, assignee, sender *gh.User, payload []byte) (*IssueChange, error) {
+	change := &IssueChange{
+		Action:   action,
+		Label:    label,
+		Assignee: assignee,
+		Sender:   sender,
+	}
+	if action == "milestoned" || action == "demilestoned" {
+		var milestone struct {
+			Milestone *gh.Milestone `json:"milestone"`
+		}
+		if err := json.Unmarshal(payload, &milestone); err != nil {
+			return nil, err
+		}
+		change.Milestone = milestone.Milestone
+	}
+	return change, nil
+}
+
+// pullRequestPush is the range of commits pushed to the pull request's head (synchronize action).
+type pullRequestPush struct {
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
 func processPullRequestReviewEvent(ctx context.Context, db *Database, event *gh.PullRequestReviewEvent) (err error) {
 	defer errRecover(event, &err)
 
@@ -248,7 +461,7 @@ func processPullRequestReviewCommentEvent(ctx context.Context, db *Database, eve
 
 	switch event.GetAction() {
 	case "deleted":
-		break
+		return db.DeletePullRequestReviewComment(ctx, event.GetRepo(), event.GetPullRequest(), event.GetComment())
 
 	case "created", "edited":
 		return db.UpsertPullRequestReviewComment(ctx, event.GetRepo(), event.GetPullRequest(), event.GetComment())
@@ -257,8 +470,304 @@ func processPullRequestReviewCommentEvent(ctx context.Context, db *Database, eve
 	return err
 }
 
+func processPushEvent(ctx context.Context, db *Database, event *gh.PushEvent) (err error) {
+	defer errRecover(event, &err)
+
+	if event.GetDeleted() {
+		return nil
+	}
+
+	for i := range event.Commits {
+		err = db.UpsertCommit(ctx, event.GetRepo(), event, &event.Commits[i])
+		if err != nil {
+			break
+		}
+	}
+
+	return err
+}
+
+func processCreateEvent(ctx context.Context, db *Database, event *gh.CreateEvent) (err error) {
+	defer errRecover(event, &err)
+
+	switch event.GetRefType() {
+	case "repository":
+		break
+
+	case "branch", "tag":
+		return db.UpsertRef(ctx, event.GetRepo(), event)
+	}
+
+	return err
+}
+
+func processDeleteEvent(ctx context.Context, db *Database, event *gh.DeleteEvent) (err error) {
+	defer errRecover(event, &err)
+
+	switch event.GetRefType() {
+	case "branch", "tag":
+		return db.DeleteRef(ctx, event.GetRepo(), event)
+	}
+
+	return err
+}
+
+func processReleaseEvent(ctx context.Context, db *Database, event *gh.ReleaseEvent) (err error) {
+	defer errRecover(event, &err)
+
+	switch event.GetAction() {
+	case "deleted":
+		return db.DeleteRelease(ctx, event.GetRepo(), event.GetRelease())
+
+	case "published", "unpublished", "created", "edited", "prereleased", "released":
+		return db.UpsertRelease(ctx, event.GetRepo(), event.GetRelease())
+	}
+
+	return err
+}
+
+func processCheckRunEvent(ctx context.Context, db *Database, event *gh.CheckRunEvent) (err error) {
+	defer errRecover(event, &err)
+
+	switch event.GetAction() {
+	case "created", "updated", "completed", "rerequested":
+		return db.UpsertCheckRun(ctx, event.GetRepo(), event.GetCheckRun())
+	}
+
+	return err
+}
+
+func processCheckSuiteEvent(ctx context.Context, db *Database, event *gh.CheckSuiteEvent) (err error) {
+	defer errRecover(event, &err)
+
+	switch event.GetAction() {
+	case "completed", "requested", "rerequested":
+		return db.UpsertCheckSuite(ctx, event.GetRepo(), event.GetCheckSuite())
+	}
+
+	return err
+}
+
+func processStatusEvent(ctx context.Context, db *Database, event *gh.StatusEvent) (err error) {
+	defer errRecover(event, &err)
+
+	return db.UpsertStatus(ctx, event.GetRepo(), event)
+}
+
+func processDeploymentEvent(ctx context.Context, db *Database, event *gh.DeploymentEvent) (err error) {
+	defer errRecover(event, &err)
+
+	return db.UpsertDeployment(ctx, event.GetRepo(), event.GetDeployment())
+}
+
+func processDeploymentStatusEvent(ctx context.Context, db *Database, event *gh.DeploymentStatusEvent) (err error) {
+	defer errRecover(event, &err)
+
+	if err = db.UpsertDeployment(ctx, event.GetRepo(), event.GetDeployment()); err != nil {
+		return err
+	}
+	return db.UpsertDeploymentStatus(ctx, event.GetRepo(), event.GetDeployment(), event.GetDeploymentStatus())
+}
+
+func processLabelEvent(ctx context.Context, db *Database, event *gh.LabelEvent, payload []byte) (err error) {
+	defer errRecover(event, &err)
+
+	switch event.GetAction() {
+	case "deleted":
+		return db.DeleteLabel(ctx, event.GetRepo(), event.GetLabel())
+
+	case "created":
+		return db.UpsertLabel(ctx, event.GetRepo(), event.GetLabel())
+
+	case "edited":
+		if err = db.UpsertLabel(ctx, event.GetRepo(), event.GetLabel()); err != nil {
+			break
+		}
+		// gh.EditChange does not carry the renamed label's name, so we take it from the raw payload.
+		var changes labelChanges
+		if err = json.Unmarshal(payload, &changes); err != nil {
+			break
+		}
+		if from := changes.Changes.Name; from != nil && from.From != event.GetLabel().GetName() {
+			err = db.RenameLabel(ctx, event.GetRepo(), event.GetLabel(), from.From)
+		}
+	}
+
+	return err
+}
+
+// labelChanges are changes of the edited label.
+type labelChanges struct {
+	Changes struct {
+		Name *struct {
+			From string `json:"from"`
+		} `json:"name"`
+	} `json:"changes"`
+}
+
+func processMilestoneEvent(ctx context.Context, db *Database, event *gh.MilestoneEvent) (err error) {
+	defer errRecover(event, &err)
+
+	switch event.GetAction() {
+	case "deleted":
+		return db.DeleteMilestone(ctx, event.GetRepo(), event.GetMilestone())
+
+	case "created", "closed", "opened":
+		return db.UpsertMilestone(ctx, event.GetRepo(), event.GetMilestone())
+
+	case "edited":
+		if err = db.UpsertMilestone(ctx, event.GetRepo(), event.GetMilestone()); err != nil {
+			break
+		}
+		if event.GetChanges().Title != nil {
+			err = db.RenameMilestone(ctx, event.GetRepo(), event.GetMilestone())
+		}
+	}
+
+	return err
+}
+
+// processUsers inserts all actors (sender, authors, assignees, reviewers, ...) of the event, who are not stored yet.
+func processUsers(ctx context.Context, db *Database, event interface{}, payload []byte) (err error) {
+	defer errRecover(event, &err)
+
+	var (
+		org   *gh.Organization
+		users []*gh.User
+	)
+	switch event := event.(type) {
+	case *gh.InstallationEvent:
+		users = append(users, event.GetSender())
+
+	case *gh.InstallationRepositoriesEvent:
+		users = append(users, event.GetSender())
+
+	case *gh.RepositoryEvent:
+		org = OwnerOrganization(event.GetRepo().GetOwner())
+		users = append(users, event.GetSender())
+
+	case *gh.OrganizationEvent:
+		org = event.GetOrganization()
+		users = append(users, event.GetSender(), event.GetMembership().GetUser())
+
+	case *gh.IssueCommentEvent:
+		org = OwnerOrganization(event.GetRepo().GetOwner())
+		users = append(users, event.GetSender(), event.GetComment().GetUser())
+		users = append(users, issueUsers(event.GetIssue())...)
+
+	case *gh.IssuesEvent:
+		org = OwnerOrganization(event.GetRepo().GetOwner())
+		users = append(users, event.GetSender(), event.GetAssignee())
+		users = append(users, issueUsers(event.GetIssue())...)
+
+	case *gh.PullRequestEvent:
+		org = OwnerOrganization(event.GetRepo().GetOwner())
+		users = append(users, event.GetSender(), event.GetAssignee(), event.GetRequestedReviewer())
+		users = append(users, pullRequestUsers(event.GetPullRequest())...)
+
+	case *gh.PullRequestReviewEvent:
+		org = OwnerOrganization(event.GetRepo().GetOwner())
+		users = append(users, event.GetSender(), event.GetReview().GetUser())
+		users = append(users, pullRequestUsers(event.GetPullRequest())...)
+
+	case *gh.PullRequestReviewCommentEvent:
+		org = OwnerOrganization(event.GetRepo().GetOwner())
+		users = append(users, event.GetSender(), event.GetComment().GetUser())
+		users = append(users, pullRequestUsers(event.GetPullRequest())...)
+
+	case *gh.PushEvent:
+		org = OwnerOrganization(event.GetRepo().GetOwner())
+		users = append(users, event.GetSender())
+
+	case *gh.CreateEvent:
+		org = OwnerOrganization(event.GetRepo().GetOwner())
+		users = append(users, event.GetSender())
+
+	case *gh.DeleteEvent:
+		org = OwnerOrganization(event.GetRepo().GetOwner())
+		users = append(users, event.GetSender())
+
+	case *gh.ReleaseEvent:
+		org = OwnerOrganization(event.GetRepo().GetOwner())
+		users = append(users, event.GetSender(), event.GetRelease().GetAuthor())
+
+	case *gh.CheckRunEvent:
+		org = OwnerOrganization(event.GetRepo().GetOwner())
+		users = append(users, event.GetSender())
+
+	case *gh.CheckSuiteEvent:
+		org = OwnerOrganization(event.GetRepo().GetOwner())
+		users = append(users, event.GetSender())
+
+	case *gh.StatusEvent:
+		org = OwnerOrganization(event.GetRepo().GetOwner())
+		users = append(users, event.GetSender())
+
+	case *gh.DeploymentEvent:
+		org = OwnerOrganization(event.GetRepo().GetOwner())
+		users = append(users, event.GetSender(), event.GetDeployment().GetCreator())
+
+	case *gh.DeploymentStatusEvent:
+		org = OwnerOrganization(event.GetRepo().GetOwner())
+		users = append(users, event.GetSender(), event.GetDeployment().GetCreator(), event.GetDeploymentStatus().GetCreator())
+
+	case *gh.LabelEvent:
+		org = OwnerOrganization(event.GetRepo().GetOwner())
+		// gh.LabelEvent does not carry the sender, so we take it from the raw payload.
+		var sender eventSender
+		if err = json.Unmarshal(payload, &sender); err != nil {
+			return err
+		}
+		users = append(users, sender.Sender)
+
+	case *gh.MilestoneEvent:
+		org = OwnerOrganization(event.GetRepo().GetOwner())
+		users = append(users, event.GetSender(), event.GetMilestone().GetCreator())
+	}
+
+	var actors []*gh.User
+	seen := make(map[int64]bool)
+	for _, user := range users {
+		if user.GetID() == 0 || seen[user.GetID()] {
+			continue
+		}
+		seen[user.GetID()] = true
+		actors = append(actors, user)
+	}
+
+	return db.InsertUsers(ctx, org, actors)
+}
+
+// eventSender is the sender of events whose gh types don't carry it (e.g. gh.LabelEvent).
+type eventSender struct {
+	Sender *gh.User `json:"sender"`
+}
+
+// OwnerOrganization returns the organization if the repository owner is an organization, otherwise nil.
+func OwnerOrganization(owner *gh.User) *gh.Organization {
+	if owner.GetType() != "Organization" {
+		return nil
+	}
+	return &gh.Organization{
+		ID:     owner.ID,
+		Login:  owner.Login,
+		NodeID: owner.NodeID,
+	}
+}
+
+func issueUsers(issue *gh.Issue) []*gh.User {
+	users := []*gh.User{issue.GetUser(), issue.GetClosedBy()}
+	return append(users, issue.Assignees...)
+}
+
+func pullRequestUsers(pr *gh.PullRequest) []*gh.User {
+	users := []*gh.User{pr.GetUser(), pr.GetMergedBy()}
+	users = append(users, pr.Assignees...)
+	return append(users, pr.RequestedReviewers...)
+}
+
 func errRecover(event interface{}, err *error) {
 	if r := recover(); r != nil {
-		*err = fmt.Errorf("Event(%s) recovered from: %v", gh.Stringify(event), r)
+		*err = Permanent(fmt.Errorf("Event(%s) recovered from: %v", gh.Stringify(event), r))
 	}
 }
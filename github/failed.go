@@ -0,0 +1,192 @@
+package github
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/athenianco/metadata/pubsub"
+	"github.com/lib/pq"
+)
+
+// maxAttempts is the number of failed attempts after which also a retryable failure is parked.
+const maxAttempts = 10
+
+// permanentError is the error which won't go away by retrying (e.g. unparseable payload).
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+// Permanent marks the error as permanent, so the event is parked in failed_events instead of being retried.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err}
+}
+
+// IsPermanent returns true if the error won't go away by retrying the event:
+// errors marked by Permanent and database data exceptions or integrity constraint violations (e.g. NOT NULL).
+// Everything else (connection errors, timeouts, serialization failures, ...) is retryable.
+func IsPermanent(err error) bool {
+	switch err := err.(type) {
+	case *permanentError:
+		return true
+
+	case *pq.Error:
+		switch err.Code.Class() {
+		case "22", // data_exception
+			"23": // integrity_constraint_violation
+			return true
+		}
+	}
+	return false
+}
+
+// FailedEvent is the event which failed processing (failed_events).
+type FailedEvent struct {
+	DeliveryID  string
+	EventType   string
+	Payload     []byte // the message data (encoded Event)
+	Error       string
+	Permanent   bool
+	Attempts    int
+	FirstSeenAt time.Time
+	LastSeenAt  time.Time
+	// ParkedAt is set when the event is not retried anymore (until it's requeued).
+	ParkedAt *time.Time
+}
+
+// RecordFailedEvent records the failed attempt of the event processing (failed_events).
+// It returns true if the event got parked: the error is permanent or the event failed maxAttempts times.
+func (db *Database) RecordFailedEvent(ctx context.Context, deliveryID, eventType string, payload []byte, err error) (bool, error) {
+	const query = `
+	INSERT INTO failed_events
+	(delivery_id, event_type, payload, error, permanent, attempts, first_seen_at, last_seen_at, parked_at)
+	VALUES ($1, $2, $3, $4, $5::boolean, 1, $6::timestamptz, $6::timestamptz, CASE WHEN $5::boolean OR $7 <= 1 THEN $6::timestamptz END)
+	ON CONFLICT (delivery_id)
+	DO UPDATE SET
+	event_type = EXCLUDED.event_type,
+	payload = EXCLUDED.payload,
+	error = EXCLUDED.error,
+	permanent = EXCLUDED.permanent,
+	attempts = failed_events.attempts + 1,
+	last_seen_at = EXCLUDED.last_seen_at,
+	parked_at = COALESCE(failed_events.parked_at, CASE WHEN EXCLUDED.permanent OR failed_events.attempts + 1 >= $7 THEN EXCLUDED.last_seen_at END)
+	RETURNING parked_at IS NOT NULL`
+
+	var parked bool
+	err = db.QueryRowContext(ctx, query,
+		deliveryID,       // delivery_id text NOT NULL,
+		eventType,        // event_type text NOT NULL,
+		payload,          // payload bytea NOT NULL,
+		err.Error(),      // error text NOT NULL,
+		IsPermanent(err), // permanent boolean NOT NULL,
+		time.Now().UTC(), // first_seen_at, last_seen_at timestamptz NOT NULL,
+		maxAttempts,
+	).Scan(&parked)
+	return parked, err
+}
+
+// ResolveFailedEvent removes the event from failed_events after it was processed successfully.
+func (db *Database) ResolveFailedEvent(ctx context.Context, deliveryID string) error {
+	_, err := db.ExecContext(ctx, `DELETE FROM failed_events WHERE delivery_id = $1`, deliveryID)
+	return err
+}
+
+const failedEventColumns = `delivery_id, event_type, payload, error, permanent, attempts, first_seen_at, last_seen_at, parked_at`
+
+func scanFailedEvent(row interface{ Scan(...interface{}) error }) (*FailedEvent, error) {
+	var (
+		e        FailedEvent
+		parkedAt pq.NullTime
+	)
+	err := row.Scan(&e.DeliveryID, &e.EventType, &e.Payload, &e.Error, &e.Permanent, &e.Attempts, &e.FirstSeenAt, &e.LastSeenAt, &parkedAt)
+	if err != nil {
+		return nil, err
+	}
+	if parkedAt.Valid {
+		e.ParkedAt = &parkedAt.Time
+	}
+	return &e, nil
+}
+
+// FailedEvents returns failed events ordered by the last failure. If parked is true, only parked events are returned.
+func (db *Database) FailedEvents(ctx context.Context, parked bool) ([]*FailedEvent, error) {
+	rows, err := db.QueryContext(ctx,
+		`SELECT `+failedEventColumns+` FROM failed_events WHERE NOT $1 OR parked_at IS NOT NULL ORDER BY last_seen_at`,
+		parked,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*FailedEvent
+	for rows.Next() {
+		e, err := scanFailedEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// FailedEvent returns the failed event by the delivery ID or sql.ErrNoRows.
+func (db *Database) FailedEvent(ctx context.Context, deliveryID string) (*FailedEvent, error) {
+	return scanFailedEvent(db.QueryRowContext(ctx,
+		`SELECT `+failedEventColumns+` FROM failed_events WHERE delivery_id = $1`,
+		deliveryID,
+	))
+}
+
+// UpdateFailedEventPayload replaces the payload (e.g. fixed by hand) of the failed event.
+func (db *Database) UpdateFailedEventPayload(ctx context.Context, deliveryID string, payload []byte) error {
+	return db.updateFailedEvent(ctx, `UPDATE failed_events SET payload = $2 WHERE delivery_id = $1`, deliveryID, payload)
+}
+
+// DeleteFailedEvent removes the failed event without processing it.
+func (db *Database) DeleteFailedEvent(ctx context.Context, deliveryID string) error {
+	return db.updateFailedEvent(ctx, `DELETE FROM failed_events WHERE delivery_id = $1`, deliveryID)
+}
+
+func (db *Database) updateFailedEvent(ctx context.Context, query, deliveryID string, args ...interface{}) error {
+	res, err := db.ExecContext(ctx, query, append([]interface{}{deliveryID}, args...)...)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return sql.ErrNoRows
+	}
+	return err
+}
+
+// RequeueFailedEvent re-publishes the failed event to the message bus and unparks it.
+// The event stays in failed_events until it's processed successfully.
+func RequeueFailedEvent(ctx context.Context, db *Database, publisher pubsub.Publisher, deliveryID string) error {
+	e, err := db.FailedEvent(ctx, deliveryID)
+	if err != nil {
+		return err
+	}
+
+	if err = db.updateFailedEvent(ctx, `UPDATE failed_events SET parked_at = NULL WHERE delivery_id = $1`, deliveryID); err != nil {
+		return err
+	}
+	err = publisher.Publish(ctx, pubsub.Message{
+		Data: e.Payload,
+		Attributes: map[string]string{
+			deliveryIDAttribute: e.DeliveryID,
+			eventTypeAttribute:  e.EventType,
+			replayAttribute:     "true",
+		},
+	})
+	if err != nil {
+		db.updateFailedEvent(ctx, `UPDATE failed_events SET parked_at = $2 WHERE delivery_id = $1`, deliveryID, e.ParkedAt)
+	}
+	return err
+}
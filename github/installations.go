@@ -0,0 +1,319 @@
+package github
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	gh "github.com/google/go-github/v28/github"
+	"github.com/lib/pq"
+)
+
+// Installation is an installation of the GitHub App (the tenant) stored in the installations table.
+type Installation struct {
+	ID                  int64
+	AppID               int64
+	AccountID           int64
+	AccountLogin        string
+	AccountType         string
+	TargetType          string
+	RepositorySelection string
+	Permissions         map[string]string
+	Events              []string
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+	SuspendedAt         *time.Time
+	DeletedAt           *time.Time
+	PurgedAt            *time.Time
+}
+
+// installationUpdate overwrites the stored installation with the payload's account, permissions and repository selection.
+const installationUpdate = `
+	SET app_id = EXCLUDED.app_id,
+		account_id = EXCLUDED.account_id,
+		account_login = EXCLUDED.account_login,
+		account_type = EXCLUDED.account_type,
+		target_type = EXCLUDED.target_type,
+		repository_selection = EXCLUDED.repository_selection,
+		permissions = EXCLUDED.permissions,
+		events = EXCLUDED.events,
+		updated_at = EXCLUDED.updated_at`
+
+// UpsertInstallation (installations) records the installation with its account, permissions and repository selection.
+// An installation which was deleted (even purged) or suspended before is brought back to life.
+func (db *Database) UpsertInstallation(ctx context.Context, inst *gh.Installation) error {
+	return db.upsertInstallation(ctx, inst, nil, nil, installationUpdate+`,
+		suspended_at = NULL,
+		deleted_at = NULL,
+		purged_at = NULL`)
+}
+
+// DeleteInstallation (installations) marks the installation as deleted (the App was uninstalled).
+// Events of the deleted installation are ignored and its rows are purged after the retention (see Purger).
+// An installation which wasn't recorded (e.g. installed before installations were recorded) is inserted as deleted.
+func (db *Database) DeleteInstallation(ctx context.Context, inst *gh.Installation) error {
+	return db.upsertInstallation(ctx, inst, nil, db.eventTime(), installationUpdate+`,
+		deleted_at = EXCLUDED.deleted_at`)
+}
+
+// SuspendInstallation (installations) marks the installation as suspended or unsuspended.
+// An installation which wasn't recorded is inserted.
+func (db *Database) SuspendInstallation(ctx context.Context, inst *gh.Installation, suspended bool) error {
+	var suspendedAt interface{}
+	if suspended {
+		suspendedAt = db.eventTime()
+	}
+	return db.upsertInstallation(ctx, inst, suspendedAt, nil, installationUpdate+`,
+		suspended_at = EXCLUDED.suspended_at`)
+}
+
+// upsertInstallation inserts the installation from the payload, or updates the stored one by set.
+func (db *Database) upsertInstallation(ctx context.Context, inst *gh.Installation, suspendedAt, deletedAt interface{}, set string) error {
+	perms := inst.GetPermissions()
+	if perms == nil {
+		perms = new(gh.InstallationPermissions)
+	}
+	permissions, err := json.Marshal(perms)
+	if err != nil {
+		return err
+	}
+
+	upsert := `
+	INSERT INTO installations (id, app_id, account_id, account_login, account_type, target_type, repository_selection, permissions, events,
+		created_at, updated_at, suspended_at, deleted_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+	ON CONFLICT (id)
+	DO UPDATE` + set
+	_, err = db.ExecContext(ctx, upsert,
+		inst.GetID(),                          // id bigint NOT NULL,
+		inst.GetAppID(),                       // app_id bigint NOT NULL,
+		inst.GetAccount().GetID(),             // account_id bigint NOT NULL,
+		inst.GetAccount().GetLogin(),          // account_login text NOT NULL,
+		inst.GetAccount().GetType(),           // account_type text NOT NULL,
+		inst.GetTargetType(),                  // target_type text NOT NULL,
+		inst.GetRepositorySelection(),         // repository_selection text NOT NULL,
+		string(permissions),                   // permissions jsonb NOT NULL,
+		pq.Array(stringsOrEmpty(inst.Events)), // events text[] NOT NULL,
+		inst.GetCreatedAt().UTC(),             // created_at timestamptz,
+		inst.GetUpdatedAt().UTC(),             // updated_at timestamptz,
+		suspendedAt,                           // suspended_at timestamptz,
+		deletedAt,                             // deleted_at timestamptz,
+	)
+	return err
+}
+
+// ScopeRows sets the installation of the database (see ForInstallation) on all rows (and their history)
+// written before they were scoped by installations, so they are included in the installation's metrics
+// and purged with it. Users are rekeyed by the installation too (see userKey). It's run once after the installations migration by a deployment which had
+// a single installation (or organization) and returns tables with scoped rows.
+func (db *Database) ScopeRows(ctx context.Context) ([]string, error) {
+	if db.installationID == 0 {
+		return nil, errors.New("the installation is not set")
+	}
+
+	var scoped []string
+	err := db.withTx(ctx, func(db *Database) error {
+		if err := db.rekeyUsers(ctx); err != nil {
+			return err
+		}
+		for _, tab := range sortedTables() {
+			versioned := strings.HasSuffix(tab, "_versioned")
+			if !versioned && !strings.Contains(tables[tab], "installation_id") {
+				continue
+			}
+			if versioned {
+				if _, err := db.ExecContext(ctx, fmt.Sprintf(
+					`UPDATE %s SET installation_id = $1 WHERE installation_id IS NULL`, historyTable(tab),
+				), db.installationID); err != nil {
+					return err
+				}
+			}
+			res, err := db.ExecContext(ctx, fmt.Sprintf(`UPDATE %s SET installation_id = $1 WHERE installation_id IS NULL`, tab), db.installationID)
+			if err != nil {
+				return err
+			}
+			if n, err := res.RowsAffected(); err != nil {
+				return err
+			} else if n > 0 {
+				scoped = append(scoped, tab)
+				db.changed(tab)
+			}
+		}
+		return nil
+	})
+	return scoped, err
+}
+
+// rekeyUsers changes keys of unscoped users to the keys of the installation (see userKey).
+// The unscoped user is deleted if the user has been stored for the installation since.
+func (db *Database) rekeyUsers(ctx context.Context) error {
+	const tab = "github_users_versioned"
+	rows, err := db.QueryContext(ctx, `SELECT sum256, organization_id, id FROM `+tab+` WHERE installation_id IS NULL`)
+	if err != nil {
+		return err
+	}
+	keys := make(map[string]string)
+	for rows.Next() {
+		var (
+			key       string
+			orgID, id int64
+		)
+		if err = rows.Scan(&key, &orgID, &id); err != nil {
+			rows.Close()
+			return err
+		}
+		keys[key] = db.userKey(&gh.Organization{ID: &orgID}, id)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	for old, key := range keys {
+		if _, err = db.ExecContext(ctx, `UPDATE `+historyTable(tab)+` SET sum256 = $2 WHERE sum256 = $1`, old, key); err != nil {
+			return err
+		}
+		if _, err = db.ExecContext(ctx,
+			`UPDATE github_skipped_versions SET sum256 = $2 WHERE table_name = '`+tab+`' AND sum256 = $1`, old, key,
+		); err != nil {
+			return err
+		}
+		res, err := db.ExecContext(ctx, `
+		UPDATE `+tab+` SET sum256 = $2
+		WHERE sum256 = $1 AND NOT EXISTS (SELECT 1 FROM `+tab+` WHERE sum256 = $2)`,
+			old, key,
+		)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			if _, err = db.ExecContext(ctx, `DELETE FROM `+tab+` WHERE sum256 = $1`, old); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Installation returns the stored installation, or nil if it's unknown.
+func (db *Database) Installation(ctx context.Context, id int64) (*Installation, error) {
+	var (
+		inst        Installation
+		permissions []byte
+		suspendedAt pq.NullTime
+		deletedAt   pq.NullTime
+		purgedAt    pq.NullTime
+	)
+	err := db.QueryRowContext(ctx, `
+	SELECT id, app_id, account_id, account_login, account_type, target_type, repository_selection, permissions, events,
+		created_at, updated_at, suspended_at, deleted_at, purged_at
+	FROM installations
+	WHERE id = $1`, id).Scan(
+		&inst.ID,
+		&inst.AppID,
+		&inst.AccountID,
+		&inst.AccountLogin,
+		&inst.AccountType,
+		&inst.TargetType,
+		&inst.RepositorySelection,
+		&permissions,
+		pq.Array(&inst.Events),
+		&inst.CreatedAt,
+		&inst.UpdatedAt,
+		&suspendedAt,
+		&deletedAt,
+		&purgedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(permissions, &inst.Permissions); err != nil {
+		return nil, err
+	}
+	if suspendedAt.Valid {
+		inst.SuspendedAt = &suspendedAt.Time
+	}
+	if deletedAt.Valid {
+		inst.DeletedAt = &deletedAt.Time
+	}
+	if purgedAt.Valid {
+		inst.PurgedAt = &purgedAt.Time
+	}
+	return &inst, nil
+}
+
+// RemoveRepository (removed_repositories) records the repository removed from the installation.
+// Events of the removed repository are ignored and its rows are purged after the retention (see Purger).
+func (db *Database) RemoveRepository(ctx context.Context, inst *gh.Installation, repo *gh.Repository) error {
+	const upsert = `
+	INSERT INTO removed_repositories (installation_id, repository_id, repository_fullname, removed_at)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (installation_id, repository_id)
+	DO UPDATE SET repository_fullname = EXCLUDED.repository_fullname, removed_at = EXCLUDED.removed_at, purged_at = NULL`
+	_, err := db.ExecContext(ctx, upsert,
+		inst.GetID(),       // installation_id bigint NOT NULL,
+		repo.GetID(),       // repository_id bigint NOT NULL,
+		repo.GetFullName(), // repository_fullname text NOT NULL,
+		time.Now().UTC(),   // removed_at timestamptz NOT NULL,
+	)
+	return err
+}
+
+// RestoreRepository (removed_repositories) forgets the removal of the repository added to the installation again.
+func (db *Database) RestoreRepository(ctx context.Context, inst *gh.Installation, repo *gh.Repository) error {
+	_, err := db.ExecContext(ctx,
+		`DELETE FROM removed_repositories WHERE installation_id = $1 AND repository_id = $2`,
+		inst.GetID(),
+		repo.GetID(),
+	)
+	return err
+}
+
+// isInactive checks if the installation was deleted or the repository (0 if none) was removed from it.
+func (db *Database) isInactive(ctx context.Context, inst *gh.Installation, repoID int64) (bool, error) {
+	var inactive bool
+	err := db.QueryRowContext(ctx, `
+	SELECT EXISTS (SELECT 1 FROM installations WHERE id = $1 AND deleted_at IS NOT NULL)
+		OR EXISTS (SELECT 1 FROM removed_repositories WHERE installation_id = $1 AND repository_id = $2)`,
+		inst.GetID(),
+		repoID,
+	).Scan(&inactive)
+	return inactive, err
+}
+
+// isForeignInstallation checks if the installation belongs to another App than db.AppID.
+// Payloads of most events carry only the installation ID, so the App is looked up in installations,
+// and unknown installations (e.g. installed before they were recorded) are accepted.
+func (db *Database) isForeignInstallation(ctx context.Context, inst *gh.Installation) (bool, error) {
+	if db.AppID == 0 {
+		return false, nil
+	}
+	appID := inst.GetAppID()
+	if appID == 0 {
+		err := db.QueryRowContext(ctx, `SELECT app_id FROM installations WHERE id = $1`, inst.GetID()).Scan(&appID)
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+	return appID != db.AppID, nil
+}
+
+// stringsOrEmpty returns an empty slice instead of nil, so NOT NULL array columns get '{}'.
+func stringsOrEmpty(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
@@ -0,0 +1,210 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	gh "github.com/google/go-github/v28/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstallations(t *testing.T) {
+	require := require.New(t)
+	ctx := context.TODO()
+
+	db, err := OpenDatabase(dbURI, 0, 0)
+	require.NoError(err)
+	defer db.Close()
+
+	payload, err := ioutil.ReadFile("testdata/installation_event.json")
+	require.NoError(err)
+	var event gh.InstallationEvent
+	require.NoError(json.Unmarshal(payload, &event))
+	require.NoError(db.UpsertInstallation(ctx, event.GetInstallation()))
+
+	inst, err := db.Installation(ctx, 6094607)
+	require.NoError(err)
+	require.NotNil(inst)
+	require.Equal(int64(49039), inst.AppID)
+	require.Equal("kuba--", inst.AccountLogin)
+	require.Equal("selected", inst.RepositorySelection)
+	require.Equal("read", inst.Permissions["pull_requests"])
+	require.Contains(inst.Events, "pull_request_review")
+	require.Nil(inst.SuspendedAt)
+	require.Nil(inst.DeletedAt)
+
+	require.NoError(db.SuspendInstallation(ctx, event.GetInstallation(), true))
+	require.NoError(db.DeleteInstallation(ctx, event.GetInstallation()))
+	inst, err = db.Installation(ctx, 6094607)
+	require.NoError(err)
+	require.NotNil(inst.SuspendedAt)
+	require.NotNil(inst.DeletedAt)
+
+	// installed again
+	require.NoError(db.UpsertInstallation(ctx, event.GetInstallation()))
+	inst, err = db.Installation(ctx, 6094607)
+	require.NoError(err)
+	require.Nil(inst.SuspendedAt)
+	require.Nil(inst.DeletedAt)
+
+	inst, err = db.Installation(ctx, 1)
+	require.NoError(err)
+	require.Nil(inst)
+}
+
+func TestForeignInstallation(t *testing.T) {
+	require := require.New(t)
+	ctx := context.TODO()
+
+	db, err := OpenDatabase(dbURI, 0, 0)
+	require.NoError(err)
+	defer db.Close()
+
+	payload, err := ioutil.ReadFile("testdata/installation_event.json")
+	require.NoError(err)
+	require.NoError((&Event{Type: "installation", Payload: payload}).Process(ctx, db))
+
+	// push events carry only the installation ID
+	installation := &gh.Installation{ID: gh.Int64(6094607)}
+	for _, tc := range []struct {
+		appID   int64
+		inst    *gh.Installation
+		foreign bool
+	}{
+		{appID: 0, inst: installation, foreign: false},
+		{appID: 49039, inst: installation, foreign: false},
+		{appID: 1, inst: installation, foreign: true},
+		{appID: 1, inst: &gh.Installation{ID: gh.Int64(6094607), AppID: gh.Int64(1)}, foreign: false},
+		// unknown installations are accepted
+		{appID: 1, inst: &gh.Installation{ID: gh.Int64(1)}, foreign: false},
+	} {
+		db.AppID = tc.appID
+		foreign, err := db.isForeignInstallation(ctx, tc.inst)
+		require.NoError(err)
+		require.Equalf(tc.foreign, foreign, "app: %d, installation: %s", tc.appID, tc.inst)
+	}
+
+	// events of other Apps are ignored
+	db.AppID = 1
+	payload, err = ioutil.ReadFile("testdata/push_event.json")
+	require.NoError(err)
+	require.NoError((&Event{Type: "push", Payload: payload}).Process(ctx, db))
+}
+
+func TestRemovedRepository(t *testing.T) {
+	require := require.New(t)
+	ctx := context.TODO()
+
+	db, err := OpenDatabase(dbURI, 0, 0)
+	require.NoError(err)
+	defer db.Close()
+
+	inst := &gh.Installation{ID: gh.Int64(6094607)}
+	repo := &gh.Repository{ID: gh.Int64(85718512), FullName: gh.String("kuba--/cuckoo")}
+	require.NoError(db.RestoreRepository(ctx, inst, repo))
+
+	inactive, err := db.isInactive(ctx, inst, repo.GetID())
+	require.NoError(err)
+	require.False(inactive)
+
+	require.NoError(db.RemoveRepository(ctx, inst, repo))
+	inactive, err = db.isInactive(ctx, inst, repo.GetID())
+	require.NoError(err)
+	require.True(inactive)
+	// other repositories of the installation are active
+	inactive, err = db.isInactive(ctx, inst, 1)
+	require.NoError(err)
+	require.False(inactive)
+
+	require.NoError(db.RestoreRepository(ctx, inst, repo))
+	inactive, err = db.isInactive(ctx, inst, repo.GetID())
+	require.NoError(err)
+	require.False(inactive)
+}
+
+// TestDeleteUnseenInstallation checks that uninstalling the installation, which wasn't recorded before
+// (e.g. installed before installations were recorded), records it as deleted, so it's purged.
+func TestDeleteUnseenInstallation(t *testing.T) {
+	require := require.New(t)
+	ctx := context.TODO()
+
+	db, err := OpenDatabase(dbURI, 0, 0)
+	require.NoError(err)
+	defer db.Close()
+
+	const id = 6094608
+	_, err = db.ExecContext(ctx, `DELETE FROM installations WHERE id = $1`, id)
+	require.NoError(err)
+
+	payload, err := ioutil.ReadFile("testdata/installation_event.json")
+	require.NoError(err)
+	var event map[string]interface{}
+	require.NoError(json.Unmarshal(payload, &event))
+	event["action"] = "deleted"
+	event["installation"].(map[string]interface{})["id"] = id
+	payload, err = json.Marshal(event)
+	require.NoError(err)
+	receivedAt := time.Date(2020, 3, 1, 10, 0, 0, 0, time.UTC)
+	require.NoError((&Event{Type: "installation", Payload: payload, ReceivedAt: receivedAt}).Process(ctx, db))
+
+	inst, err := db.Installation(ctx, id)
+	require.NoError(err)
+	require.NotNil(inst)
+	require.Equal("kuba--", inst.AccountLogin)
+	require.NotNil(inst.DeletedAt)
+	require.True(receivedAt.Equal(*inst.DeletedAt))
+	require.Nil(inst.SuspendedAt)
+
+	ids, err := db.purgeableInstallations(ctx, receivedAt.Add(time.Hour))
+	require.NoError(err)
+	require.Contains(ids, int64(id))
+}
+
+func TestScopeRows(t *testing.T) {
+	require := require.New(t)
+	ctx := context.TODO()
+
+	db, err := OpenDatabase(dbURI, 0, 0)
+	require.NoError(err)
+	defer db.Close()
+
+	_, err = db.ScopeRows(ctx)
+	require.Error(err)
+
+	// rows of other tests stay unscoped
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(err)
+	defer tx.Rollback()
+	txdb := *db
+	txdb.tx = tx
+	txdb.changes = new([]string)
+
+	owner := &gh.User{ID: gh.Int64(8001), Login: gh.String("unscoped"), Type: gh.String("Organization")}
+	repo := &gh.Repository{ID: gh.Int64(8002), Name: gh.String("repo"), FullName: gh.String("unscoped/repo"), Owner: owner}
+	now := time.Now()
+	require.NoError(txdb.UpsertRepository(ctx, repo))
+	require.NoError(txdb.UpsertIssues(ctx, repo, &gh.Issue{ID: gh.Int64(8003), Number: gh.Int(1), UpdatedAt: &now}))
+	user := &gh.User{ID: gh.Int64(8005), Login: gh.String("personal")}
+	require.NoError(txdb.UpsertUser(ctx, nil, user))
+
+	scoped, err := txdb.ForInstallation(8004).ScopeRows(ctx)
+	require.NoError(err)
+	require.Contains(scoped, "github_repositories_versioned")
+	require.Contains(scoped, "github_issues_versioned")
+	require.Contains(scoped, "github_users_versioned")
+
+	for _, query := range []string{
+		`SELECT installation_id FROM github_repositories_versioned WHERE id = 8002`,
+		`SELECT installation_id FROM github_issues_versioned WHERE id = 8003`,
+		`SELECT installation_id FROM github_issues_history WHERE id = 8003`,
+		// the user is keyed by the installation
+		`SELECT installation_id FROM github_users_versioned WHERE sum256 = '` + txdb.ForInstallation(8004).userKey(nil, 8005) + `'`,
+	} {
+		var id int64
+		require.NoError(txdb.QueryRowContext(ctx, query).Scan(&id), query)
+		require.Equal(int64(8004), id, query)
+	}
+}
@@ -0,0 +1,103 @@
+package github
+
+import (
+	"context"
+	"log"
+
+	"github.com/athenianco/metadata/pubsub"
+)
+
+// Message attributes set by the Publisher and the Replayer.
+const (
+	deliveryIDAttribute = "delivery_id"
+	eventTypeAttribute  = "event_type"
+	replayAttribute     = "replay"
+)
+
+// Publisher returns the webhook's OnEvent callback which publishes events to the message bus.
+func Publisher(publisher pubsub.Publisher) func(ctx context.Context, event *Event) error {
+	return func(ctx context.Context, event *Event) error {
+		return publish(ctx, publisher, event, false)
+	}
+}
+
+// Replayer returns the callback which re-publishes (e.g. archived) events to the message bus.
+// Replayed events are processed again, even if their deliveries were already processed.
+func Replayer(publisher pubsub.Publisher) func(ctx context.Context, event *Event) error {
+	return func(ctx context.Context, event *Event) error {
+		return publish(ctx, publisher, event, true)
+	}
+}
+
+func publish(ctx context.Context, publisher pubsub.Publisher, event *Event, replay bool) error {
+	data, err := MarshalEvent(event)
+	if err != nil {
+		return err
+	}
+
+	attrs := map[string]string{
+		deliveryIDAttribute: event.DeliveryID,
+		eventTypeAttribute:  event.Type,
+	}
+	if replay {
+		attrs[replayAttribute] = "true"
+	}
+	return publisher.Publish(ctx, pubsub.Message{Data: data, Attributes: attrs})
+}
+
+// Processor returns the subscriber which decodes events from messages and processes them into the database.
+// Every delivery is processed only once (see ProcessDelivery), redeliveries (from github or from the message bus)
+// are skipped, unless the event is replayed.
+//
+// Failures are recorded in failed_events. Permanent failures (see IsPermanent) and events
+// which failed too many times are parked there (acknowledged), so the bus stops redelivering them.
+func Processor(db *Database) pubsub.Subscriber {
+	return func(ctx context.Context, msg pubsub.Message) error {
+		deliveryID := msg.Attributes[deliveryIDAttribute]
+		if deliveryID == "" {
+			// Without the delivery ID, failed events are identified by the payload.
+			deliveryID = sum256(string(msg.Data))
+		}
+
+		event, err := UnmarshalEvent(msg.Data)
+		if err != nil {
+			return fail(ctx, db, deliveryID, msg.Attributes[eventTypeAttribute], msg.Data, Permanent(err))
+		}
+		if event.DeliveryID == "" {
+			event.DeliveryID = msg.Attributes[deliveryIDAttribute]
+		} else if msg.Attributes[deliveryIDAttribute] == "" {
+			deliveryID = event.DeliveryID
+		}
+
+		if event.DeliveryID == "" {
+			err = event.Process(ctx, db)
+		} else {
+			var processed bool
+			processed, err = db.ProcessDelivery(ctx, event, msg.Attributes[replayAttribute] != "")
+			if err == nil && !processed {
+				log.Printf("skipped processed delivery: %s, type: %s\n", event.DeliveryID, event.Type)
+				return nil
+			}
+		}
+		if err != nil {
+			return fail(ctx, db, deliveryID, event.Type, msg.Data, err)
+		}
+		// The event might have failed before (and got redelivered or requeued).
+		return db.ResolveFailedEvent(ctx, deliveryID)
+	}
+}
+
+// fail records the failed attempt and returns nil if the event got parked,
+// otherwise the original error is returned, so the message is redelivered.
+func fail(ctx context.Context, db *Database, deliveryID, eventType string, data []byte, err error) error {
+	parked, ferr := db.RecordFailedEvent(ctx, deliveryID, eventType, data, err)
+	if ferr != nil {
+		log.Printf("could not record failed delivery: %s, type: %s, error: %v\n", deliveryID, eventType, ferr)
+		return err
+	}
+	if parked {
+		log.Printf("parked failed delivery: %s, type: %s, error: %v\n", deliveryID, eventType, err)
+		return nil
+	}
+	return err
+}
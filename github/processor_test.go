@@ -0,0 +1,155 @@
+package github
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/athenianco/metadata/pubsub"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessorDeduplication(t *testing.T) {
+	require := require.New(t)
+
+	db, err := OpenDatabase(dbURI, 0, 0)
+	require.NoError(err)
+	defer db.Close()
+
+	payload, err := ioutil.ReadFile("testdata/repository_event.json")
+	require.NoError(err)
+
+	ch := make(chan pubsub.Message, 1)
+	publish := Publisher(publisherFunc(func(ctx context.Context, msg pubsub.Message) error {
+		ch <- msg
+		return nil
+	}))
+	require.NoError(publish(context.TODO(), &Event{
+		Type:       "repository",
+		DeliveryID: "d8a5a2f0-cc78-11e3-81ab-4c9367dc0958",
+		Payload:    payload,
+	}))
+	msg := <-ch
+	require.Equal("d8a5a2f0-cc78-11e3-81ab-4c9367dc0958", msg.Attributes[deliveryIDAttribute])
+
+	versions := func() int {
+		var n int
+		err := db.QueryRow(`select count(*) from github_repositories_history where id=118`).Scan(&n)
+		require.NoError(err)
+		return n
+	}
+
+	process := Processor(db)
+	require.NoError(process(context.TODO(), msg))
+	n := versions()
+
+	// redelivery
+	require.NoError(process(context.TODO(), msg))
+	require.Equal(n, versions())
+
+	processed, err := db.IsDeliveryProcessed(context.TODO(), "d8a5a2f0-cc78-11e3-81ab-4c9367dc0958")
+	require.NoError(err)
+	require.True(processed)
+
+	// processed deliveries are forgotten after the retention
+	purged, err := db.PurgeDeliveries(context.TODO(), time.Now().Add(time.Minute))
+	require.NoError(err)
+	require.True(purged > 0)
+	processed, err = db.IsDeliveryProcessed(context.TODO(), "d8a5a2f0-cc78-11e3-81ab-4c9367dc0958")
+	require.NoError(err)
+	require.False(processed)
+}
+
+func TestIsPermanent(t *testing.T) {
+	require := require.New(t)
+
+	require.False(IsPermanent(nil))
+	require.False(IsPermanent(errors.New("connection refused")))
+	require.False(IsPermanent(&pq.Error{Code: "40001"})) // serialization_failure
+	require.True(IsPermanent(&pq.Error{Code: "23502"}))  // not_null_violation
+	require.True(IsPermanent(&pq.Error{Code: "22P02"}))  // invalid_text_representation
+	require.True(IsPermanent(Permanent(errors.New("unparseable"))))
+	require.Nil(Permanent(nil))
+
+	err := (&Event{Type: "push", Payload: []byte("{")}).Process(context.TODO(), nil)
+	require.Error(err)
+	require.True(IsPermanent(err))
+}
+
+func TestProcessorFailedEvents(t *testing.T) {
+	require := require.New(t)
+	ctx := context.TODO()
+
+	db, err := OpenDatabase(dbURI, 0, 0)
+	require.NoError(err)
+	defer db.Close()
+
+	const deliveryID = "4a1bbc5e-0f6b-11ea-8d71-362b9e155667"
+	db.DeleteFailedEvent(ctx, deliveryID)
+	defer db.DeleteFailedEvent(ctx, deliveryID)
+
+	msg := pubsub.Message{
+		Data: []byte(`{"type": "repository", "payload": "not base64"}`),
+		Attributes: map[string]string{
+			deliveryIDAttribute: deliveryID,
+			eventTypeAttribute:  "repository",
+		},
+	}
+
+	// permanent failure is parked (acknowledged)
+	process := Processor(db)
+	require.NoError(process(ctx, msg))
+	require.NoError(process(ctx, msg))
+
+	e, err := db.FailedEvent(ctx, deliveryID)
+	require.NoError(err)
+	require.Equal("repository", e.EventType)
+	require.Equal(msg.Data, e.Payload)
+	require.True(e.Permanent)
+	require.Equal(2, e.Attempts)
+	require.NotNil(e.ParkedAt)
+
+	events, err := db.FailedEvents(ctx, true)
+	require.NoError(err)
+	require.Contains(deliveryIDs(events), deliveryID)
+
+	// fix and requeue
+	payload, err := ioutil.ReadFile("testdata/repository_event.json")
+	require.NoError(err)
+	data, err := MarshalEvent(&Event{Type: "repository", DeliveryID: deliveryID, Payload: payload})
+	require.NoError(err)
+	require.NoError(db.UpdateFailedEventPayload(ctx, deliveryID, data))
+
+	ch := make(chan pubsub.Message, 1)
+	require.NoError(RequeueFailedEvent(ctx, db, publisherFunc(func(ctx context.Context, msg pubsub.Message) error {
+		ch <- msg
+		return nil
+	}), deliveryID))
+
+	e, err = db.FailedEvent(ctx, deliveryID)
+	require.NoError(err)
+	require.Nil(e.ParkedAt)
+
+	require.NoError(process(ctx, <-ch))
+	_, err = db.FailedEvent(ctx, deliveryID)
+	require.Equal(sql.ErrNoRows, err)
+	require.Equal(sql.ErrNoRows, db.DeleteFailedEvent(ctx, deliveryID))
+}
+
+func deliveryIDs(events []*FailedEvent) []string {
+	ids := make([]string, len(events))
+	for i, e := range events {
+		ids[i] = e.DeliveryID
+	}
+	return ids
+}
+
+type publisherFunc func(ctx context.Context, msg pubsub.Message) error
+
+func (f publisherFunc) Publish(ctx context.Context, msg pubsub.Message) error {
+	return f(ctx, msg)
+}
@@ -0,0 +1,461 @@
+package github
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// DeliveryRetention is the default for how long processed deliveries are kept in the ledger (github_deliveries).
+// It is longer than messages are retained (and redelivered) by Pub/Sub.
+const DeliveryRetention = 14 * 24 * time.Hour
+
+// Purger hard-deletes rows of uninstalled installations and of repositories removed from installations
+// once they have been inactive for the retention window, and processed deliveries after DeliveryRetention.
+type Purger struct {
+	// Interval between purges, see Run.
+	Interval time.Duration
+	// Retention is how long rows are kept after the installation was deleted or the repository removed.
+	Retention time.Duration
+	// DeliveryRetention is how long processed deliveries are kept, so their redeliveries are skipped.
+	DeliveryRetention time.Duration
+	// PurgeArchive (optional) deletes archived raw events for which purged returns true (see archive.Purge),
+	// received since the purged installations were created (zero if it's unknown).
+	// It's called before the rows are purged, so its failure is retried by the next purge.
+	PurgeArchive func(ctx context.Context, since time.Time, purged func(event *Event) bool) error
+
+	db *Database
+}
+
+// NewPurger creates a new purger of the database.
+func NewPurger(db *Database, interval, retention time.Duration) *Purger {
+	return &Purger{
+		Interval:          interval,
+		Retention:         retention,
+		DeliveryRetention: DeliveryRetention,
+		db:                db,
+	}
+}
+
+// Run purges every interval until the context is canceled.
+func (p *Purger) Run(ctx context.Context) error {
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		if err := p.Purge(ctx); err != nil {
+			log.Printf("purge: %v\n", err)
+		}
+	}
+}
+
+// Purge hard-deletes rows of installations deleted and repositories removed before the retention window,
+// and refreshes materialized views built from the purged tables, so the rows disappear from them too.
+// Deliveries processed before the delivery retention window are deleted too.
+func (p *Purger) Purge(ctx context.Context) error {
+	now := time.Now().UTC()
+	n, err := p.db.PurgeDeliveries(ctx, now.Add(-p.DeliveryRetention))
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		log.Printf("purged %d deliveries\n", n)
+	}
+
+	before := now.Add(-p.Retention)
+	purged := make(map[string]bool)
+
+	installations, err := p.db.purgeableInstallations(ctx, before)
+	if err != nil {
+		return err
+	}
+	repos, err := p.db.purgeableRepositories(ctx, before)
+	if err != nil {
+		return err
+	}
+
+	if p.PurgeArchive != nil && len(installations)+len(repos) > 0 {
+		scopes := make([]func(event *Event) bool, 0, len(installations)+len(repos))
+		ids := make([]int64, 0, len(installations)+len(repos))
+		for _, id := range installations {
+			scopes = append(scopes, installationScope(id))
+			ids = append(ids, id)
+		}
+		for _, repo := range repos {
+			scopes = append(scopes, repositoryScope(repo.installationID, repo.fullname))
+			ids = append(ids, repo.installationID)
+		}
+		since, err := p.db.installedSince(ctx, ids)
+		if err != nil {
+			return err
+		}
+		if err = p.PurgeArchive(ctx, since, func(event *Event) bool {
+			for _, scope := range scopes {
+				if scope(event) {
+					return true
+				}
+			}
+			return false
+		}); err != nil {
+			return fmt.Errorf("archive: %v", err)
+		}
+	}
+
+	for _, id := range installations {
+		tabs, err := p.db.PurgeInstallation(ctx, id)
+		if err != nil {
+			return fmt.Errorf("installation %d: %v", id, err)
+		}
+		for _, tab := range tabs {
+			purged[tab] = true
+		}
+		log.Printf("purged installation %d\n", id)
+	}
+
+	for _, repo := range repos {
+		tabs, err := p.db.PurgeRepository(ctx, repo.installationID, repo.id, repo.fullname)
+		if err != nil {
+			return fmt.Errorf("repository %s: %v", repo.fullname, err)
+		}
+		for _, tab := range tabs {
+			purged[tab] = true
+		}
+		log.Printf("purged repository %s of installation %d\n", repo.fullname, repo.installationID)
+	}
+
+	var views []string
+	for view, tabs := range materializedViews {
+		for _, tab := range tabs {
+			if purged[tab] {
+				views = append(views, view)
+				break
+			}
+		}
+	}
+	sort.Strings(views)
+	for _, view := range views {
+		if err = p.db.RefreshMaterializedView(ctx, view); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// purgeableInstallations returns installations deleted before and not purged yet.
+func (db *Database) purgeableInstallations(ctx context.Context, before time.Time) ([]int64, error) {
+	rows, err := db.QueryContext(ctx,
+		`SELECT id FROM installations WHERE deleted_at < $1 AND purged_at IS NULL ORDER BY id`,
+		before,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err = rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// installedSince returns when the first of the installations was created, or zero if it's unknown for any
+// of them (not recorded, or recorded from a payload without created_at, which is stored as the zero time).
+func (db *Database) installedSince(ctx context.Context, ids []int64) (time.Time, error) {
+	var (
+		since   pq.NullTime
+		unknown bool
+	)
+	err := db.QueryRowContext(ctx, `
+	SELECT min(i.created_at), bool_or(i.created_at IS NULL OR i.created_at <= 'epoch')
+	FROM unnest($1::bigint[]) AS ids(id)
+	LEFT JOIN installations i ON i.id = ids.id`,
+		pq.Array(ids),
+	).Scan(&since, &unknown)
+	if err != nil || unknown {
+		return time.Time{}, err
+	}
+	return since.Time, nil
+}
+
+type removedRepository struct {
+	installationID int64
+	id             int64
+	fullname       string
+}
+
+// purgeableRepositories returns repositories removed from installations before and not purged yet.
+func (db *Database) purgeableRepositories(ctx context.Context, before time.Time) ([]removedRepository, error) {
+	rows, err := db.QueryContext(ctx, `
+	SELECT installation_id, repository_id, repository_fullname
+	FROM removed_repositories
+	WHERE removed_at < $1 AND purged_at IS NULL
+	ORDER BY installation_id, repository_id`,
+		before,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var repos []removedRepository
+	for rows.Next() {
+		var repo removedRepository
+		if err = rows.Scan(&repo.installationID, &repo.id, &repo.fullname); err != nil {
+			return nil, err
+		}
+		repos = append(repos, repo)
+	}
+	return repos, rows.Err()
+}
+
+// PurgeInstallation hard-deletes all rows written for the installation (with their history and skipped versions),
+// including rows of its repositories written before they were scoped by installations, checkpoints
+// of its repositories and its failed events, and marks the installation as purged.
+// Unscoped users and organizations are kept, as they don't belong to repositories. It returns tables with deleted rows.
+func (db *Database) PurgeInstallation(ctx context.Context, id int64) ([]string, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// repositories are collected before their rows are deleted
+	repos, err := installationRepositories(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tab := range checkpointTables {
+		if _, err = tx.ExecContext(ctx,
+			fmt.Sprintf(`DELETE FROM %s WHERE repository_fullname = ANY($1)`, tab),
+			pq.Array(repos),
+		); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = deleteFailedEvents(ctx, tx, installationScope(id)); err != nil {
+		return nil, err
+	}
+
+	var deleted []string
+	for _, tab := range sortedTables() {
+		var (
+			ok  bool
+			err error
+		)
+		switch {
+		case tab == "github_repositories_versioned":
+			ok, err = deleteRows(ctx, tx, tab,
+				`installation_id = $1 OR (installation_id IS NULL AND fullname = ANY($2))`, id, pq.Array(repos))
+		case strings.Contains(tables[tab], "repository_fullname"):
+			ok, err = deleteRows(ctx, tx, tab,
+				`installation_id = $1 OR (installation_id IS NULL AND repository_fullname = ANY($2))`, id, pq.Array(repos))
+		default:
+			ok, err = deleteRows(ctx, tx, tab, `installation_id = $1`, id)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			deleted = append(deleted, tab)
+		}
+	}
+
+	if _, err = tx.ExecContext(ctx, `UPDATE installations SET purged_at = $2 WHERE id = $1`, id, time.Now().UTC()); err != nil {
+		return nil, err
+	}
+	return deleted, db.commitPurge(tx, deleted)
+}
+
+// installationRepositories returns full names of repositories written for the installation
+// and of repositories removed from it.
+func installationRepositories(ctx context.Context, tx *sql.Tx, id int64) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, `
+	SELECT fullname FROM github_repositories_versioned WHERE installation_id = $1
+	UNION
+	SELECT repository_fullname FROM removed_repositories WHERE installation_id = $1`,
+		id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var repos []string
+	for rows.Next() {
+		var repo string
+		if err = rows.Scan(&repo); err != nil {
+			return nil, err
+		}
+		repos = append(repos, repo)
+	}
+	return repos, rows.Err()
+}
+
+// PurgeRepository hard-deletes all rows of the repository removed from the installation (with their history
+// and skipped versions), including rows written before they were scoped by installations, its checkpoints
+// and failed events,
+// and marks the repository as purged. It returns tables with deleted rows.
+func (db *Database) PurgeRepository(ctx context.Context, installationID, repoID int64, fullname string) ([]string, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	for _, tab := range checkpointTables {
+		if _, err = tx.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE repository_fullname = $1`, tab), fullname); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = deleteFailedEvents(ctx, tx, repositoryScope(installationID, fullname)); err != nil {
+		return nil, err
+	}
+
+	const installation = `(installation_id = $1 OR installation_id IS NULL)`
+	var deleted []string
+	for _, tab := range sortedTables() {
+		var (
+			ok  bool
+			err error
+		)
+		switch {
+		case tab == "github_repositories_versioned":
+			ok, err = deleteRows(ctx, tx, tab, installation+` AND id = $2`, installationID, repoID)
+		case strings.Contains(tables[tab], "repository_fullname"):
+			ok, err = deleteRows(ctx, tx, tab, installation+` AND repository_fullname = $2`, installationID, fullname)
+		default:
+			// users and organizations don't belong to repositories
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			deleted = append(deleted, tab)
+		}
+	}
+
+	if _, err = tx.ExecContext(ctx,
+		`UPDATE removed_repositories SET purged_at = $3 WHERE installation_id = $1 AND repository_id = $2`,
+		installationID, repoID, time.Now().UTC(),
+	); err != nil {
+		return nil, err
+	}
+	return deleted, db.commitPurge(tx, deleted)
+}
+
+// checkpointTables are progress tables of the backfill and the reconciler, keyed by repositories.
+// Checkpoints of purged repositories are deleted, so they are loaded from the beginning if they come back.
+var checkpointTables = []string{"backfill_checkpoints", "reconcile_checkpoints"}
+
+// deleteRows deletes rows of the table (and of its history and skipped versions) matching the predicate
+// and reports if any row of the table was deleted.
+func deleteRows(ctx context.Context, tx *sql.Tx, tab, where string, args ...interface{}) (bool, error) {
+	if strings.HasSuffix(tab, "_versioned") {
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(
+			`DELETE FROM github_skipped_versions WHERE table_name = '%s' AND sum256 IN (SELECT sum256 FROM %s WHERE %s)`, tab, tab, where,
+		), args...); err != nil {
+			return false, err
+		}
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE %s`, historyTable(tab), where), args...); err != nil {
+			return false, err
+		}
+	}
+	res, err := tx.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE %s`, tab, where), args...)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// deleteFailedEvents deletes failed events (with their payloads) for which purged returns true.
+// Events which can't be decoded can't be attributed to an installation, they are kept.
+func deleteFailedEvents(ctx context.Context, tx *sql.Tx, purged func(event *Event) bool) error {
+	rows, err := tx.QueryContext(ctx, `SELECT delivery_id, payload FROM failed_events`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var (
+			id      string
+			payload []byte
+		)
+		if err = rows.Scan(&id, &payload); err != nil {
+			return err
+		}
+		if event, err := UnmarshalEvent(payload); err == nil && purged(event) {
+			ids = append(ids, id)
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err = tx.ExecContext(ctx, `DELETE FROM failed_events WHERE delivery_id = ANY($1)`, pq.Array(ids))
+	return err
+}
+
+// installationScope matches events of the installation.
+func installationScope(id int64) func(event *Event) bool {
+	return func(event *Event) bool {
+		installationID, _ := event.Scope()
+		return installationID == id
+	}
+}
+
+// repositoryScope matches events of the repository of the installation, including events without the installation.
+func repositoryScope(installationID int64, fullname string) func(event *Event) bool {
+	return func(event *Event) bool {
+		id, repo := event.Scope()
+		return (id == installationID || id == 0) && repo == fullname
+	}
+}
+
+// commitPurge commits the purge and reports changes of tables with deleted rows (see OnChange).
+func (db *Database) commitPurge(tx *sql.Tx, deleted []string) error {
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	for _, tab := range deleted {
+		db.changed(tab)
+	}
+	return nil
+}
+
+// sortedTables returns names of tables (see tables) in a stable order.
+func sortedTables() []string {
+	names := make([]string, 0, len(tables))
+	for tab := range tables {
+		names = append(names, tab)
+	}
+	sort.Strings(names)
+	return names
+}
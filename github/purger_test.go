@@ -0,0 +1,135 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	gh "github.com/google/go-github/v28/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPurge(t *testing.T) {
+	require := require.New(t)
+	ctx := context.TODO()
+
+	db, err := OpenDatabase(dbURI, 0, 0)
+	require.NoError(err)
+	defer db.Close()
+
+	owner := &gh.User{ID: gh.Int64(7001), Login: gh.String("purged"), Type: gh.String("Organization")}
+	inst := &gh.Installation{ID: gh.Int64(7002), AppID: gh.Int64(7003), Account: owner}
+	kept := &gh.Repository{ID: gh.Int64(7004), Name: gh.String("kept"), FullName: gh.String("purged/kept"), Owner: owner}
+	removed := &gh.Repository{ID: gh.Int64(7005), Name: gh.String("removed"), FullName: gh.String("purged/removed"), Owner: owner}
+	now := time.Now()
+	issue := &gh.Issue{ID: gh.Int64(7006), Number: gh.Int(1), UpdatedAt: &now}
+
+	before := now.Add(-time.Hour)
+	stale := &gh.Issue{ID: gh.Int64(7006), Number: gh.Int(1), UpdatedAt: &before}
+
+	require.NoError(db.UpsertInstallation(ctx, inst))
+	idb := db.ForInstallation(inst.GetID())
+	for _, repo := range []*gh.Repository{kept, removed} {
+		require.NoError(idb.UpsertRepository(ctx, repo))
+		require.NoError(idb.UpsertIssues(ctx, repo, issue))
+		// the stale version is skipped
+		require.NoError(idb.UpsertIssues(ctx, repo, stale))
+		require.NoError(db.SaveBackfillCheckpoint(ctx, repo.GetFullName(), "issues", 2, false))
+
+		data, err := MarshalEvent(&Event{
+			Type:    "issues",
+			Payload: []byte(`{"installation": {"id": 7002}, "repository": {"full_name": "` + repo.GetFullName() + `"}}`),
+		})
+		require.NoError(err)
+		_, err = db.RecordFailedEvent(ctx, "purge-"+repo.GetName(), "issues", data, errors.New("failed"))
+		require.NoError(err)
+	}
+
+	count := func(query string, args ...interface{}) int {
+		var n int
+		require.NoError(db.QueryRowContext(ctx, query, args...).Scan(&n))
+		return n
+	}
+
+	// the removed repository is purged after the retention
+	require.NoError(db.RemoveRepository(ctx, inst, removed))
+	p := NewPurger(db, 0, time.Hour)
+	archived := []*Event{
+		{DeliveryID: "kept", Payload: []byte(`{"installation": {"id": 7002}, "repository": {"full_name": "purged/kept"}}`)},
+		{DeliveryID: "removed", Payload: []byte(`{"installation": {"id": 7002}, "repository": {"full_name": "purged/removed"}}`)},
+		{DeliveryID: "other", Payload: []byte(`{"installation": {"id": 7999}, "repository": {"full_name": "purged/kept"}}`)},
+	}
+	p.PurgeArchive = func(ctx context.Context, since time.Time, purged func(event *Event) bool) error {
+		// the installation was created by the payload without created_at
+		require.True(since.IsZero())
+		var kept []*Event
+		for _, e := range archived {
+			if !purged(e) {
+				kept = append(kept, e)
+			}
+		}
+		archived = kept
+		return nil
+	}
+	require.NoError(p.Purge(ctx))
+	require.Equal(1, count(`SELECT count(*) FROM github_issues_versioned WHERE repository_fullname = 'purged/removed'`))
+
+	p.Retention = 0
+	require.NoError(p.Purge(ctx))
+	require.Equal(0, count(`SELECT count(*) FROM github_issues_versioned WHERE repository_fullname = 'purged/removed'`))
+	require.Equal(0, count(`SELECT count(*) FROM github_issues_history WHERE repository_fullname = 'purged/removed'`))
+	require.Equal(0, count(`SELECT count(*) FROM github_repositories_versioned WHERE id = $1`, removed.GetID()))
+	require.Equal(0, count(`SELECT count(*) FROM backfill_checkpoints WHERE repository_fullname = 'purged/removed'`))
+	require.Equal(1, count(`SELECT count(*) FROM github_issues_versioned WHERE repository_fullname = 'purged/kept'`))
+	require.Equal(1, count(`SELECT count(*) FROM removed_repositories WHERE repository_id = $1 AND purged_at IS NOT NULL`, removed.GetID()))
+	require.Equal(0, count(`SELECT count(*) FROM failed_events WHERE delivery_id = 'purge-removed'`))
+	require.Equal(1, count(`SELECT count(*) FROM failed_events WHERE delivery_id = 'purge-kept'`))
+	require.Equal(1, count(`SELECT count(*) FROM github_skipped_versions WHERE table_name = 'github_issues_versioned' AND sum256 IN (
+		SELECT sum256 FROM github_issues_versioned WHERE installation_id = $1)`, inst.GetID()))
+	require.Equal([]string{"kept", "other"}, eventIDs(archived))
+
+	// all rows of the uninstalled installation are purged, including rows of its repositories written
+	// before they were scoped by installations
+	unscoped := &gh.Issue{ID: gh.Int64(7007), Number: gh.Int(2), UpdatedAt: &now}
+	require.NoError(db.UpsertIssues(ctx, kept, unscoped))
+	require.NoError(db.DeleteInstallation(ctx, inst))
+	require.NoError(p.Purge(ctx))
+	require.Equal(0, count(`SELECT count(*) FROM github_issues_versioned WHERE installation_id = $1`, inst.GetID()))
+	require.Equal(0, count(`SELECT count(*) FROM github_repositories_versioned WHERE installation_id = $1`, inst.GetID()))
+	require.Equal(0, count(`SELECT count(*) FROM github_issues_versioned WHERE repository_fullname = 'purged/kept'`))
+	require.Equal(0, count(`SELECT count(*) FROM github_issues_history WHERE repository_fullname = 'purged/kept'`))
+	require.Equal(0, count(`SELECT count(*) FROM backfill_checkpoints WHERE repository_fullname = 'purged/kept'`))
+
+	stored, err := db.Installation(ctx, inst.GetID())
+	require.NoError(err)
+	require.NotNil(stored.PurgedAt)
+
+	// nothing of the purged installation remains
+	for _, tab := range sortedTables() {
+		if !strings.HasSuffix(tab, "_versioned") && !strings.Contains(tables[tab], "installation_id") {
+			continue
+		}
+		require.Equal(0, count(fmt.Sprintf(`SELECT count(*) FROM %s WHERE installation_id = $1`, tab), inst.GetID()), tab)
+		if strings.HasSuffix(tab, "_versioned") {
+			require.Equal(0, count(fmt.Sprintf(`SELECT count(*) FROM %s WHERE installation_id = $1`, historyTable(tab)), inst.GetID()), tab)
+		}
+	}
+	for _, repo := range []*gh.Repository{kept, removed} {
+		require.Equal(0, count(`SELECT count(*) FROM github_skipped_versions WHERE table_name = 'github_issues_versioned' AND sum256 = $1`,
+			sum256(repo.GetID(), issue.GetID())))
+	}
+	require.Equal(0, count(`SELECT count(*) FROM failed_events WHERE delivery_id LIKE 'purge-%'`))
+	require.Equal(0, count(`SELECT count(*) FROM backfill_checkpoints WHERE repository_fullname LIKE 'purged/%'`))
+	require.Equal([]string{"other"}, eventIDs(archived))
+}
+
+func eventIDs(events []*Event) []string {
+	ids := make([]string, len(events))
+	for i, e := range events {
+		ids[i] = e.DeliveryID
+	}
+	return ids
+}
@@ -0,0 +1,142 @@
+package github
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	gh "github.com/google/go-github/v28/github"
+	"github.com/lib/pq"
+)
+
+// IsPullRequestStale checks if the pull request is missing in github_pull_requests_versioned or older than the payload.
+func (db *Database) IsPullRequestStale(ctx context.Context, repo *gh.Repository, pr *gh.PullRequest) (bool, error) {
+	return db.isStale(ctx, "github_pull_requests_versioned",
+		sum256(
+			repo.GetID(),
+			pr.GetID(),
+		),
+		pr.GetUpdatedAt(),
+	)
+}
+
+// IsPullRequestReviewStale checks if the review is missing in github_pull_request_reviews_versioned, older than the payload,
+// or if its body or state differ from the payload's, because editing or dismissing the review doesn't change submitted_at.
+func (db *Database) IsPullRequestReviewStale(ctx context.Context, repo *gh.Repository, pr *gh.PullRequest, review *gh.PullRequestReview) (bool, error) {
+	var (
+		stored      pq.NullTime
+		body, state sql.NullString
+	)
+	err := db.QueryRowContext(ctx,
+		`SELECT submitted_at, body, state FROM github_pull_request_reviews_versioned WHERE sum256 = $1`,
+		sum256(
+			repo.GetID(),
+			pr.GetID(),
+			review.GetID(),
+		),
+	).Scan(&stored, &body, &state)
+	if err == sql.ErrNoRows {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return !stored.Valid || stored.Time.Before(review.GetSubmittedAt()) ||
+		body.String != review.GetBody() || state.String != review.GetState(), nil
+}
+
+// IsPullRequestReviewCommentStale checks if the comment is missing in github_pull_request_comments_versioned or older than the payload.
+func (db *Database) IsPullRequestReviewCommentStale(ctx context.Context, repo *gh.Repository, pr *gh.PullRequest, comment *gh.PullRequestComment) (bool, error) {
+	return db.isStale(ctx, "github_pull_request_comments_versioned",
+		sum256(
+			repo.GetID(),
+			pr.GetID(),
+			comment.GetID(),
+		),
+		comment.GetUpdatedAt(),
+	)
+}
+
+// IsIssueStale checks if the issue is missing in github_issues_versioned or older than the payload.
+func (db *Database) IsIssueStale(ctx context.Context, repo *gh.Repository, issue *gh.Issue) (bool, error) {
+	return db.isStale(ctx, "github_issues_versioned",
+		sum256(
+			repo.GetID(),
+			issue.GetID(),
+		),
+		issue.GetUpdatedAt(),
+	)
+}
+
+// IsIssueCommentStale checks if the comment is missing in github_issue_comments_versioned or older than the payload.
+func (db *Database) IsIssueCommentStale(ctx context.Context, repo *gh.Repository, issue *gh.Issue, comment *gh.IssueComment) (bool, error) {
+	return db.isStale(ctx, "github_issue_comments_versioned",
+		sum256(
+			repo.GetID(),
+			issue.GetID(),
+			comment.GetID(),
+		),
+		comment.GetUpdatedAt(),
+	)
+}
+
+// IsIssueCommentAsPullRequestStale checks if the comment of the pull request is missing
+// in github_pull_request_comments_versioned or older than the payload.
+func (db *Database) IsIssueCommentAsPullRequestStale(ctx context.Context, repo *gh.Repository, issue *gh.Issue, comment *gh.IssueComment) (bool, error) {
+	return db.isStale(ctx, "github_pull_request_comments_versioned",
+		sum256(
+			repo.GetID(),
+			issue.GetID(),
+			comment.GetID(),
+		),
+		comment.GetUpdatedAt(),
+	)
+}
+
+// isStale checks if the row is missing or its orderedBy column is older than the payload's.
+func (db *Database) isStale(ctx context.Context, tab, sum string, orderedAt time.Time) (bool, error) {
+	var stored pq.NullTime
+	err := db.QueryRowContext(ctx,
+		fmt.Sprintf(`SELECT %s FROM %s WHERE sum256 = $1`, orderedBy[tab], tab),
+		sum,
+	).Scan(&stored)
+	if err == sql.ErrNoRows {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return !stored.Valid || stored.Time.Before(orderedAt), nil
+}
+
+// ReconcileCheckpoint returns the start of the last reconciliation of the repository,
+// and false if it was never reconciled.
+func (db *Database) ReconcileCheckpoint(ctx context.Context, repo string) (time.Time, bool, error) {
+	var since time.Time
+	err := db.QueryRowContext(ctx,
+		`SELECT since FROM reconcile_checkpoints WHERE repository_fullname = $1`,
+		repo,
+	).Scan(&since)
+	if err == sql.ErrNoRows {
+		return since, false, nil
+	}
+	return since, err == nil, err
+}
+
+// SaveReconcileCheckpoint records the reconciliation of the repository, which started at since,
+// with the number of repaired (missing or stale) entities.
+func (db *Database) SaveReconcileCheckpoint(ctx context.Context, repo string, since time.Time, repaired int) error {
+	const upsert = `
+	INSERT INTO reconcile_checkpoints (repository_fullname, since, reconciled_at, repaired)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (repository_fullname)
+	DO UPDATE SET since = EXCLUDED.since, reconciled_at = EXCLUDED.reconciled_at, repaired = EXCLUDED.repaired`
+	_, err := db.ExecContext(ctx, upsert,
+		repo,             // repository_fullname text NOT NULL,
+		since.UTC(),      // since timestamptz NOT NULL,
+		time.Now().UTC(), // reconciled_at timestamptz NOT NULL,
+		repaired,         // repaired integer NOT NULL,
+	)
+	return err
+}
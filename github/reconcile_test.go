@@ -0,0 +1,53 @@
+package github
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	gh "github.com/google/go-github/v28/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsStale(t *testing.T) {
+	require := require.New(t)
+	ctx := context.TODO()
+
+	db, err := OpenDatabase(dbURI, 0, 0)
+	require.NoError(err)
+	defer db.Close()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	repo := &gh.Repository{
+		ID:       gh.Int64(time.Now().UnixNano()),
+		Name:     gh.String("stale"),
+		FullName: gh.String("athenianco/stale"),
+		Owner:    &gh.User{Login: gh.String("athenianco")},
+	}
+	issue := &gh.Issue{ID: gh.Int64(1), Number: gh.Int(1), UpdatedAt: &now}
+
+	stale, err := db.IsIssueStale(ctx, repo, issue)
+	require.NoError(err)
+	require.True(stale, "missing")
+
+	require.NoError(db.UpsertIssues(ctx, repo, issue))
+	stale, err = db.IsIssueStale(ctx, repo, issue)
+	require.NoError(err)
+	require.False(stale, "up to date")
+
+	later := now.Add(time.Minute)
+	issue.UpdatedAt = &later
+	stale, err = db.IsIssueStale(ctx, repo, issue)
+	require.NoError(err)
+	require.True(stale, "older than the payload")
+
+	require.NoError(db.SaveReconcileCheckpoint(ctx, repo.GetFullName(), now, 1))
+	since, ok, err := db.ReconcileCheckpoint(ctx, repo.GetFullName())
+	require.NoError(err)
+	require.True(ok)
+	require.True(now.Equal(since))
+
+	_, ok, err = db.ReconcileCheckpoint(ctx, "athenianco/never-reconciled")
+	require.NoError(err)
+	require.False(ok)
+}
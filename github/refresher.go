@@ -0,0 +1,242 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// materializedViews maps materialized views to the versioned tables they are built from.
+var materializedViews = map[string][]string{
+	"deployments":              {"github_deployment_statuses_versioned", "github_deployments_versioned"},
+	"issue_comments":           {"github_issue_comments_versioned", "github_issues_versioned"},
+	"issues":                   {"github_issues_versioned"},
+	"owners":                   {"github_organizations_versioned"},
+	"pull_request_checks":      {"github_check_runs_versioned", "github_pull_requests_versioned", "github_pull_request_pushes", "github_statuses_versioned"},
+	"pull_request_comments":    {"github_issue_comments_versioned", "github_pull_requests_versioned", "github_pull_request_comments_versioned", "github_pull_request_reviews_versioned"},
+	"pull_request_deployments": {"github_deployment_statuses_versioned", "github_deployments_versioned", "github_pull_requests_versioned"},
+	"pull_request_releases":    {"github_pull_requests_versioned", "github_releases_versioned"},
+	"pull_request_reviews":     {"github_pull_request_reviews_versioned"},
+	"pull_requests":            {"github_pull_requests_versioned"},
+	"releases":                 {"github_releases_versioned"},
+	"repositories":             {"github_repositories_versioned"},
+	"users":                    {"github_users_versioned"},
+}
+
+// refreshLockID is the key (with the view's hash) of the advisory lock,
+// so only one instance refreshes the view at a time.
+const refreshLockID = 736483027
+
+// Refresher refreshes materialized views on a schedule and/or after the number of changes of their tables.
+type Refresher struct {
+	// Interval between scheduled refreshes of all views, 0 disables the schedule.
+	Interval time.Duration
+	// Threshold is the number of changed rows of the view's tables which triggers its refresh, 0 disables it.
+	Threshold int
+
+	db      *Database
+	trigger chan struct{}
+
+	mu      sync.Mutex
+	changes map[string]int
+}
+
+// NewRefresher creates a new refresher of the database's materialized views.
+// Changes are counted only if OnChange of the database calls the refresher's Changed.
+func NewRefresher(db *Database, interval time.Duration, threshold int) *Refresher {
+	return &Refresher{
+		Interval:  interval,
+		Threshold: threshold,
+		db:        db,
+		trigger:   make(chan struct{}, 1),
+		changes:   make(map[string]int),
+	}
+}
+
+// Changed counts the change of the versioned table and triggers the refresh of views which reached the threshold.
+func (r *Refresher) Changed(tab string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for view, tabs := range materializedViews {
+		for _, t := range tabs {
+			if t != tab {
+				continue
+			}
+			r.changes[view]++
+			if r.Threshold > 0 && r.changes[view] >= r.Threshold {
+				select {
+				case r.trigger <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}
+}
+
+// Run refreshes views until the context is canceled.
+func (r *Refresher) Run(ctx context.Context) error {
+	var tick <-chan time.Time
+	if r.Interval > 0 {
+		ticker := time.NewTicker(r.Interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		var views []string
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-tick:
+			views = Views()
+		case <-r.trigger:
+			views = r.pending()
+		}
+
+		if err := r.Refresh(ctx, views...); err != nil {
+			log.Printf("refresh: %v\n", err)
+		}
+	}
+}
+
+// pending returns views which reached the threshold.
+func (r *Refresher) pending() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var views []string
+	for view, n := range r.changes {
+		if n >= r.Threshold {
+			views = append(views, view)
+		}
+	}
+	sort.Strings(views)
+	return views
+}
+
+// Refresh refreshes the views. Views refreshed by another instance at the moment are skipped.
+func (r *Refresher) Refresh(ctx context.Context, views ...string) error {
+	for _, view := range views {
+		// Changes made during the refresh count for the next one.
+		r.mu.Lock()
+		delete(r.changes, view)
+		r.mu.Unlock()
+
+		if err := r.db.RefreshMaterializedView(ctx, view); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ServeHTTP responds with last refreshes of materialized views (JSON).
+func (r *Refresher) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	refreshes, err := r.db.MaterializedViewRefreshes(req.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(refreshes)
+}
+
+// Views returns names of all materialized views.
+func Views() []string {
+	views := make([]string, 0, len(materializedViews))
+	for view := range materializedViews {
+		views = append(views, view)
+	}
+	sort.Strings(views)
+	return views
+}
+
+// MaterializedViewRefresh is the last refresh of the materialized view (materialized_view_refreshes).
+type MaterializedViewRefresh struct {
+	Name        string    `json:"name"`
+	RefreshedAt time.Time `json:"refreshed_at"`
+	Duration    string    `json:"duration"`
+}
+
+// RefreshMaterializedView refreshes the view concurrently (so it can be read meanwhile) and records the refresh.
+// A view which has never been populated (created WITH NO DATA) can't be refreshed concurrently, so it's refreshed in place.
+func (db *Database) RefreshMaterializedView(ctx context.Context, view string) error {
+	if _, ok := materializedViews[view]; !ok {
+		return fmt.Errorf("unknown materialized view: %s", view)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var locked, populated bool
+	err = tx.QueryRowContext(ctx,
+		`SELECT pg_try_advisory_xact_lock($1, hashtext($2::text)), ispopulated FROM pg_matviews WHERE schemaname = 'public' AND matviewname = $2::text`,
+		refreshLockID, view,
+	).Scan(&locked, &populated)
+	if err != nil {
+		return err
+	}
+	if !locked {
+		log.Printf("skipped refresh of %s, refreshed by another instance\n", view)
+		return nil
+	}
+
+	start := time.Now()
+	query := `REFRESH MATERIALIZED VIEW CONCURRENTLY ` + pq.QuoteIdentifier(view)
+	if !populated {
+		query = `REFRESH MATERIALIZED VIEW ` + pq.QuoteIdentifier(view)
+	}
+	if _, err = tx.ExecContext(ctx, query); err != nil {
+		return err
+	}
+	duration := time.Since(start)
+
+	const upsert = `
+	INSERT INTO materialized_view_refreshes (name, refreshed_at, duration)
+	VALUES ($1, $2, make_interval(secs => $3))
+	ON CONFLICT (name)
+	DO UPDATE SET refreshed_at = EXCLUDED.refreshed_at, duration = EXCLUDED.duration`
+	if _, err = tx.ExecContext(ctx, upsert,
+		view,               // name text NOT NULL,
+		time.Now().UTC(),   // refreshed_at timestamptz NOT NULL,
+		duration.Seconds(), // duration interval NOT NULL,
+	); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	log.Printf("refreshed %s in %v\n", view, duration)
+	return nil
+}
+
+// MaterializedViewRefreshes returns last refreshes of materialized views.
+func (db *Database) MaterializedViewRefreshes(ctx context.Context) ([]*MaterializedViewRefresh, error) {
+	rows, err := db.QueryContext(ctx, `SELECT name, refreshed_at, duration::text FROM materialized_view_refreshes ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	refreshes := []*MaterializedViewRefresh{}
+	for rows.Next() {
+		var r MaterializedViewRefresh
+		if err = rows.Scan(&r.Name, &r.RefreshedAt, &r.Duration); err != nil {
+			return nil, err
+		}
+		refreshes = append(refreshes, &r)
+	}
+	return refreshes, rows.Err()
+}
@@ -0,0 +1,55 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRefresherThreshold(t *testing.T) {
+	require := require.New(t)
+
+	r := NewRefresher(nil, 0, 2)
+	r.Changed("github_issues_versioned")
+	require.Empty(r.pending())
+	require.Len(r.trigger, 0)
+
+	r.Changed("github_issues_versioned")
+	r.Changed("github_refs_versioned")
+	require.Equal([]string{"issue_comments", "issues"}, r.pending())
+	require.Len(r.trigger, 1)
+
+	for _, tabs := range materializedViews {
+		for _, tab := range tabs {
+			_, ok := tables[tab]
+			require.True(ok, tab)
+		}
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	require := require.New(t)
+	ctx := context.TODO()
+
+	db, err := OpenDatabase(dbURI, 0, 0)
+	require.NoError(err)
+	defer db.Close()
+
+	r := NewRefresher(db, 0, 0)
+	// the first refresh might populate views, the second one is concurrent
+	require.NoError(r.Refresh(ctx, Views()...))
+	require.NoError(r.Refresh(ctx, Views()...))
+	require.Error(r.Refresh(ctx, "unknown"))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/refreshes", nil))
+	var refreshes []*MaterializedViewRefresh
+	require.NoError(json.NewDecoder(w.Body).Decode(&refreshes))
+	require.Len(refreshes, len(Views()))
+	for _, refresh := range refreshes {
+		require.False(refresh.RefreshedAt.IsZero())
+	}
+}
@@ -3,6 +3,9 @@ package github
 import (
 	"context"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	gh "github.com/google/go-github/v28/github"
 )
@@ -10,7 +13,9 @@ import (
 // Webhook is the implementation of http.Handler with OnEvent callback.
 type Webhook struct {
 	SecretKey []byte
-	OnEvent   func(ctx context.Context, event *Event) error
+	// AppID (optional) is the ID of our GitHub App. Events delivered to other Apps are forbidden.
+	AppID   int64
+	OnEvent func(ctx context.Context, event *Event) error
 }
 
 func (h *Webhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -24,11 +29,20 @@ func (h *Webhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "invalid webhook event type", http.StatusBadRequest)
 		return
 	}
+	if h.AppID != 0 && r.Header.Get("X-GitHub-Hook-Installation-Target-Type") == "integration" {
+		if id := r.Header.Get("X-GitHub-Hook-Installation-Target-ID"); id != strconv.FormatInt(h.AppID, 10) {
+			http.Error(w, "event of another github app: "+id, http.StatusForbidden)
+			return
+		}
+	}
 
 	err = h.OnEvent(r.Context(),
 		&Event{
-			Type:    typ,
-			Payload: payload,
+			Type:       typ,
+			DeliveryID: gh.DeliveryID(r),
+			Headers:    headers(r),
+			ReceivedAt: time.Now().UTC(),
+			Payload:    payload,
 		},
 	)
 	if err != nil {
@@ -37,3 +51,15 @@ func (h *Webhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 	w.WriteHeader(http.StatusOK)
 }
+
+// headers returns github's and a few standard headers of the request.
+func headers(r *http.Request) map[string]string {
+	hdrs := make(map[string]string)
+	for k := range r.Header {
+		if strings.HasPrefix(k, "X-Github-") || strings.HasPrefix(k, "X-Hub-") ||
+			k == "Content-Type" || k == "User-Agent" {
+			hdrs[k] = r.Header.Get(k)
+		}
+	}
+	return hdrs
+}
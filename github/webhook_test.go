@@ -49,8 +49,9 @@ func TestWebhook(t *testing.T) {
 			name:   "Valid Request",
 			method: "POST",
 			header: map[string]string{
-				"Content-Type":  "application/json",
-				eventTypeHeader: "TEST",
+				"Content-Type":   "application/json",
+				eventTypeHeader:  "TEST",
+				deliveryIDHeader: "72d3162e-cc78-11e3-81ab-4c9367dc0958",
 			},
 			reponseCode: http.StatusOK,
 		},
@@ -74,6 +75,29 @@ func TestWebhook(t *testing.T) {
 			},
 			reponseCode: http.StatusBadRequest,
 		},
+		{
+			name:   "Event of our App",
+			method: "POST",
+			header: map[string]string{
+				"Content-Type":                           "application/json",
+				eventTypeHeader:                          "TEST",
+				deliveryIDHeader:                         "72d3162e-cc78-11e3-81ab-4c9367dc0959",
+				"X-GitHub-Hook-Installation-Target-Type": "integration",
+				"X-GitHub-Hook-Installation-Target-ID":   "42",
+			},
+			reponseCode: http.StatusOK,
+		},
+		{
+			name:   "Event of another App",
+			method: "POST",
+			header: map[string]string{
+				"Content-Type":                           "application/json",
+				eventTypeHeader:                          "TEST",
+				"X-GitHub-Hook-Installation-Target-Type": "integration",
+				"X-GitHub-Hook-Installation-Target-ID":   "43",
+			},
+			reponseCode: http.StatusForbidden,
+		},
 	}
 
 	fuzz := fuzz.New()
@@ -84,6 +108,7 @@ func TestWebhook(t *testing.T) {
 
 			wh := &Webhook{
 				SecretKey: []byte("SECRET_TOKEN"),
+				AppID:     42,
 				OnEvent: func(ctx context.Context, event *Event) error {
 					data, err := MarshalEvent(event)
 					require.NoError(err)
@@ -130,6 +155,11 @@ func TestWebhook(t *testing.T) {
 					event.Type, tc.header[eventTypeHeader],
 				)
 
+				require.Truef(event.DeliveryID == tc.header[deliveryIDHeader],
+					"event delivery id: %s, expected: %s\n",
+					event.DeliveryID, tc.header[deliveryIDHeader],
+				)
+
 				require.Truef(bytes.Equal(event.Payload, tc.payload),
 					"event payload: %s, expected: %s\n",
 					event.Payload, tc.payload,
@@ -0,0 +1,45 @@
+package metadata
+
+import (
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/athenianco/metadata/github"
+	"github.com/athenianco/metadata/metrics"
+)
+
+var ghMetrics struct {
+	once sync.Once
+	*metrics.Handler
+}
+
+func initGHMetrics() {
+	dbURI := os.Getenv("GITHUB_DATABASE_URI")
+	if dbURI == "" {
+		panic("GITHUB_DATABASE_URI is not set")
+	}
+
+	maxOpenConns, err := strconv.Atoi(os.Getenv("GITHUB_DATABASE_MAX_OPEN_CONNS"))
+	if err != nil {
+		maxOpenConns = 1
+	}
+
+	maxIdleConns, err := strconv.Atoi(os.Getenv("GITHUB_DATABASE_MAX_IDLE_CONNS"))
+	if err != nil {
+		maxIdleConns = 1
+	}
+
+	db, err := github.OpenDatabase(dbURI, maxOpenConns, maxIdleConns)
+	if err != nil {
+		panic(err)
+	}
+	ghMetrics.Handler = &metrics.Handler{DB: db}
+}
+
+// GithubMetrics is http.Handler of the read-only metrics API.
+func GithubMetrics(w http.ResponseWriter, r *http.Request) {
+	ghMetrics.once.Do(initGHMetrics)
+	ghMetrics.ServeHTTP(w, r)
+}
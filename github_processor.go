@@ -47,18 +47,18 @@ func initGHProcessor() {
 		maxIdleConns = 1
 	}
 
-	db, err := github.OpenDatabase(dbURI, maxOpenConns, maxIdleConns)
+	var opts []github.DatabaseOption
+	if migrate, _ := strconv.ParseBool(os.Getenv("GITHUB_DATABASE_MIGRATE")); migrate {
+		opts = append(opts, github.WithMigrations())
+	}
+
+	db, err := github.OpenDatabase(dbURI, maxOpenConns, maxIdleConns, opts...)
 	if err != nil {
 		panic(err)
 	}
+	db.AppID, _ = strconv.ParseInt(os.Getenv("GITHUB_APP_ID"), 10, 64)
 
-	ghProcessor.fnc = func(ctx context.Context, msg pubsub.Message) error {
-		event, err := github.UnmarshalEvent(msg.Data)
-		if err != nil {
-			return err
-		}
-		return event.Process(ctx, db)
-	}
+	ghProcessor.fnc = github.Processor(db)
 }
 
 // GithubProcess is triggered by Pub/Sub.
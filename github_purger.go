@@ -0,0 +1,58 @@
+package metadata
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/athenianco/metadata/archive"
+	"github.com/athenianco/metadata/github"
+)
+
+var ghPurger struct {
+	once sync.Once
+	*github.Purger
+}
+
+func initGHPurger() {
+	dbURI := os.Getenv("GITHUB_DATABASE_URI")
+	if dbURI == "" {
+		panic("GITHUB_DATABASE_URI is not set")
+	}
+
+	retention, err := time.ParseDuration(os.Getenv("GITHUB_PURGER_RETENTION"))
+	if err != nil || retention <= 0 {
+		panic("GITHUB_PURGER_RETENTION is not a valid duration")
+	}
+
+	db, err := github.OpenDatabase(dbURI, 1, 1)
+	if err != nil {
+		panic(err)
+	}
+	ghPurger.Purger = github.NewPurger(db, 0, retention)
+
+	// Archived events of purged installations and repositories are purged too.
+	if archiveURI := os.Getenv("GITHUB_ARCHIVE_URI"); archiveURI != "" {
+		storage, err := archive.OpenStorage(archiveURI)
+		if err != nil {
+			panic(err)
+		}
+		ghPurger.PurgeArchive = func(ctx context.Context, since time.Time, purged func(event *github.Event) bool) error {
+			_, err := archive.Purge(ctx, storage, since, purged)
+			return err
+		}
+	}
+}
+
+// GithubPurge is http.Handler triggered by Cloud Scheduler, which hard-deletes rows of uninstalled installations
+// and of repositories removed from installations after the retention (GITHUB_PURGER_RETENTION).
+func GithubPurge(w http.ResponseWriter, r *http.Request) {
+	ghPurger.once.Do(initGHPurger)
+	if err := ghPurger.Purge(r.Context()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,38 @@
+package metadata
+
+import (
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/athenianco/metadata/github"
+)
+
+var ghRefresher struct {
+	once sync.Once
+	*github.Refresher
+}
+
+func initGHRefresher() {
+	dbURI := os.Getenv("GITHUB_DATABASE_URI")
+	if dbURI == "" {
+		panic("GITHUB_DATABASE_URI is not set")
+	}
+
+	db, err := github.OpenDatabase(dbURI, 1, 1)
+	if err != nil {
+		panic(err)
+	}
+	ghRefresher.Refresher = github.NewRefresher(db, 0, 0)
+}
+
+// GithubRefresh is http.Handler triggered by Cloud Scheduler, which refreshes all materialized views
+// and responds with their last refresh times.
+func GithubRefresh(w http.ResponseWriter, r *http.Request) {
+	ghRefresher.once.Do(initGHRefresher)
+	if err := ghRefresher.Refresh(r.Context(), github.Views()...); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	ghRefresher.ServeHTTP(w, r)
+}
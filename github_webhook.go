@@ -1,11 +1,12 @@
 package metadata
 
 import (
-	"context"
 	"net/http"
 	"os"
+	"strconv"
 	"sync"
 
+	"github.com/athenianco/metadata/archive"
 	"github.com/athenianco/metadata/github"
 	"github.com/athenianco/metadata/pubsub"
 )
@@ -26,20 +27,29 @@ func initGHWebhook() {
 		panic("GITHUB_WEBHOOK_SECRET_KEY is not set")
 	}
 
-	publisher, err := pubsub.NewPublisher(topicID)
+	// Our App ID is optional, without it events of all Apps are accepted.
+	appID, _ := strconv.ParseInt(os.Getenv("GITHUB_APP_ID"), 10, 64)
+
+	publisher, err := pubsub.NewGCPPublisher(topicID)
 	if err != nil {
 		panic(err)
 	}
 
+	onEvent := github.Publisher(publisher)
+	// Raw events archive is optional.
+	if archiveURI := os.Getenv("GITHUB_ARCHIVE_URI"); archiveURI != "" {
+		storage, err := archive.OpenStorage(archiveURI)
+		if err != nil {
+			panic(err)
+		}
+		// Instances may be stopped at any time, so nothing is buffered.
+		onEvent = archive.NewArchiver(storage, 1).OnEvent(onEvent)
+	}
+
 	ghWebhook.Webhook = &github.Webhook{
 		SecretKey: []byte(secretKey),
-		OnEvent: func(ctx context.Context, event *github.Event) error {
-			data, err := github.MarshalEvent(event)
-			if err != nil {
-				return err
-			}
-			return publisher.Publish(ctx, data)
-		},
+		AppID:     appID,
+		OnEvent:   onEvent,
 	}
 }
 
@@ -0,0 +1,110 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/athenianco/metadata/github"
+)
+
+// defaultRange is the date range when from is not set.
+const defaultRange = 30 * 24 * time.Hour
+
+// Handler is the read-only HTTP API (GET only) which responds with the time series of metrics (JSON).
+//
+// Query parameters:
+//   - from, to: date range (2006-01-02 or RFC3339), the last 30 days by default,
+//   - granularity: day (default) or week,
+//   - repositories, authors, labels: comma separated (or repeated) filters,
+//   - installation: ID of the GitHub App's installation (the tenant), required.
+type Handler struct {
+	DB *github.Database
+}
+
+// Response of the Handler.
+type Response struct {
+	Filter Filter   `json:"filter"`
+	Series []*Point `json:"series"`
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	filter, err := ParseFilter(r.URL.Query(), time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	points, err := Compute(r.Context(), h.DB, filter)
+	if err != nil {
+		log.Printf("metrics: %v\n", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&Response{Filter: filter, Series: points})
+}
+
+// ParseFilter parses the filter from query parameters (see Handler).
+func ParseFilter(query url.Values, now time.Time) (Filter, error) {
+	filter := Filter{
+		Granularity:  query.Get("granularity"),
+		Repositories: list(query["repositories"]),
+		Authors:      list(query["authors"]),
+		Labels:       list(query["labels"]),
+	}
+	if filter.Granularity == "" {
+		filter.Granularity = Day
+	}
+
+	var err error
+	if v := query.Get("installation"); v != "" {
+		if filter.Installation, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return filter, fmt.Errorf("installation: %v", err)
+		}
+	}
+	filter.To = now.UTC()
+	if v := query.Get("to"); v != "" {
+		if filter.To, err = parseTime(v); err != nil {
+			return filter, fmt.Errorf("to: %v", err)
+		}
+	}
+	filter.From = filter.To.Add(-defaultRange)
+	if v := query.Get("from"); v != "" {
+		if filter.From, err = parseTime(v); err != nil {
+			return filter, fmt.Errorf("from: %v", err)
+		}
+	}
+	return filter, filter.Validate()
+}
+
+func parseTime(v string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", v); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
+
+// list splits comma separated values.
+func list(values []string) []string {
+	var list []string
+	for _, v := range values {
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				list = append(list, s)
+			}
+		}
+	}
+	return list
+}
@@ -0,0 +1,460 @@
+// Package metrics computes engineering metrics (lead time, reviews, throughput, ...)
+// from materialized views of the metadata database.
+//
+// All durations are in seconds. Averages are null if there's nothing to average in the period.
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/athenianco/metadata/github"
+	"github.com/lib/pq"
+)
+
+// Granularities of the time series.
+const (
+	Day  = "day"
+	Week = "week"
+)
+
+// Filter selects pull requests and issues for metrics.
+type Filter struct {
+	// From (inclusive) and To (exclusive) is the date range. From is truncated to the beginning
+	// of its period (day or week), so the first point covers its whole period.
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+	// Granularity of the time series: day or week (weeks start on Monday).
+	Granularity string `json:"granularity"`
+	// Repositories (full names, e.g. athenianco/metadata), all repositories if empty.
+	Repositories []string `json:"repositories,omitempty"`
+	// Authors (logins) of pull requests and issues, all authors if empty.
+	Authors []string `json:"authors,omitempty"`
+	// Labels, at least one of them has to be set on the pull request or issue, all if empty.
+	Labels []string `json:"labels,omitempty"`
+	// Installation of the GitHub App (the tenant), required: metrics never mix tenants.
+	Installation int64 `json:"installation"`
+}
+
+// Validate checks the filter.
+func (f *Filter) Validate() error {
+	if f.Granularity != Day && f.Granularity != Week {
+		return fmt.Errorf("unknown granularity: %q", f.Granularity)
+	}
+	if !f.From.Before(f.To) {
+		return errors.New("from must be before to")
+	}
+	if f.Installation <= 0 {
+		return errors.New("installation is required")
+	}
+	return nil
+}
+
+// Point holds metrics of a single period (day or week) of the time series.
+type Point struct {
+	// Date is the beginning of the period.
+	Date time.Time `json:"date"`
+
+	// PullRequestsOpened is the number of pull requests created in the period.
+	PullRequestsOpened int `json:"pull_requests_opened"`
+	// PullRequestsClosed is the number of pull requests closed (or merged) in the period.
+	PullRequestsClosed int `json:"pull_requests_closed"`
+	// PullRequestsMerged is the number of pull requests merged in the period (throughput).
+	PullRequestsMerged int `json:"pull_requests_merged"`
+	// MergeRate is the ratio of merged to closed pull requests.
+	MergeRate *float64 `json:"merge_rate"`
+	// LeadTime is the average time from creation to merge of pull requests merged in the period.
+	LeadTime *float64 `json:"lead_time"`
+	// TimeToFirstReview is the average time from creation to the first review (not by the author)
+	// of pull requests created in the period.
+	TimeToFirstReview *float64 `json:"time_to_first_review"`
+	// Reviews is the number of reviews (not by authors of pull requests) submitted in the period.
+	Reviews int `json:"reviews"`
+
+	// IssuesClosed is the number of issues closed in the period (throughput).
+	IssuesClosed int `json:"issues_closed"`
+	// IssueCloseTime is the average time from creation to close of issues closed in the period.
+	IssueCloseTime *float64 `json:"issue_close_time"`
+
+	// Releases is the number of releases published in the period (release frequency).
+	Releases int `json:"releases"`
+	// ReleaseTime is the average time from merge to release of pull requests released in the period.
+	ReleaseTime *float64 `json:"release_time"`
+
+	// CIWaitTime is the average time from start to completion of checks (check runs and commit statuses)
+	// of pull requests completed in the period.
+	CIWaitTime *float64 `json:"ci_wait_time"`
+	// FlakyCheckRate is the ratio of failed checks, which succeeded later on the same commit, to checks
+	// of pull requests completed in the period.
+	FlakyCheckRate *float64 `json:"flaky_check_rate"`
+
+	// Deployments is the number of deployments which succeeded in the period (deployment frequency).
+	Deployments int `json:"deployments"`
+	// DeploymentLeadTime is the average time from merge to the successful deployment (to every environment)
+	// of pull requests deployed in the period (lead time for changes).
+	DeploymentLeadTime *float64 `json:"deployment_lead_time"`
+	// ChangeFailureRate is the ratio of failed deployments to deployments finished in the period.
+	ChangeFailureRate *float64 `json:"change_failure_rate"`
+	// TimeToRestore is the average time from the first failed deployment to the next successful deployment
+	// (of the same repository and environment) of deployments restored in the period.
+	TimeToRestore *float64 `json:"time_to_restore"`
+
+	// LabelTime is the average time from labeling to unlabeling (with the filter's labels, any if empty)
+	// of labels removed from issues and pull requests in the period.
+	LabelTime *float64 `json:"label_time"`
+	// TimeToFirstAssignment is the average time from creation to the first assignment
+	// of issues and pull requests first assigned in the period.
+	TimeToFirstAssignment *float64 `json:"time_to_first_assignment"`
+}
+
+// Compute returns the time series of metrics (a point per every period of the filter's date range).
+func Compute(ctx context.Context, db *github.Database, filter Filter) ([]*Point, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	var points []*Point
+	index := make(map[time.Time]*Point)
+	for date := truncate(filter.From, filter.Granularity); date.Before(filter.To); date = next(date, filter.Granularity) {
+		p := &Point{Date: date}
+		points = append(points, p)
+		index[date] = p
+	}
+
+	args := []interface{}{
+		filter.Granularity,
+		// the first period is complete rather than starting at From
+		truncate(filter.From, filter.Granularity),
+		filter.To.UTC(),
+		pq.Array(nonNil(filter.Repositories)),
+		pq.Array(nonNil(filter.Authors)),
+		pq.Array(nonNil(filter.Labels)),
+		filter.Installation,
+	}
+	for _, q := range queries {
+		if err := scan(ctx, db, q.query, args, index, q.scan); err != nil {
+			return nil, err
+		}
+	}
+	return points, nil
+}
+
+// filterPredicate filters rows of pull_requests or issues (by the alias) by $4 repositories, $5 authors, $6 labels
+// and $7 installation. Rows of uninstalled installations and of repositories removed from installations
+// are excluded until they are purged.
+const filterPredicate = `
+	(cardinality($4::text[]) = 0 OR %[1]s.repository_fullname = ANY($4::text[])) AND
+	(cardinality($5::text[]) = 0 OR %[1]s.user_login = ANY($5::text[])) AND
+	(cardinality($6::text[]) = 0 OR %[1]s.labels && $6::text[]) AND
+	%[1]s.installation_id = $7::bigint AND` + activePredicate
+
+// releasePredicate filters rows of releases or deployments (by the alias) like filterPredicate,
+// authors (creators of deployments) are matched by $5 authors. They don't have labels, so $6 labels don't apply
+// (it's referenced only to keep arguments typed).
+const releasePredicate = `
+	(cardinality($4::text[]) = 0 OR %[1]s.repository_fullname = ANY($4::text[])) AND
+	(cardinality($5::text[]) = 0 OR %[1]s.author_login = ANY($5::text[])) AND
+	cardinality($6::text[]) >= 0 AND
+	%[1]s.installation_id = $7::bigint AND` + activePredicate
+
+// changePredicate filters rows of issue_changes (by the alias) like filterPredicate, authors of issues
+// and pull requests are matched by $5 authors and changed labels by $6 labels.
+const changePredicate = `
+	(cardinality($4::text[]) = 0 OR %[1]s.repository_fullname = ANY($4::text[])) AND
+	(cardinality($5::text[]) = 0 OR %[1]s.user_login = ANY($5::text[])) AND
+	(cardinality($6::text[]) = 0 OR %[1]s.label = ANY($6::text[])) AND
+	%[1]s.installation_id = $7::bigint AND` + activePredicate
+
+// activePredicate excludes rows (by the alias) of uninstalled installations and of repositories removed
+// from installations.
+const activePredicate = `
+	NOT EXISTS (SELECT 1 FROM installations inst WHERE inst.id = %[1]s.installation_id AND inst.deleted_at IS NOT NULL) AND
+	NOT EXISTS (SELECT 1 FROM removed_repositories rr WHERE rr.installation_id = %[1]s.installation_id AND rr.repository_fullname = %[1]s.repository_fullname)`
+
+// firstAssignments selects issues and pull requests assigned for the first time in the date range ($2, $3)
+// of the $7 installation with when they were assigned. Only assignments in the range are scanned,
+// an earlier one is looked up by the index of the issue.
+const firstAssignments = `
+	SELECT c.installation_id, c.repository_fullname, c.issue_number, min(c.changed_at) AS assigned_at
+	FROM issue_changes c
+	WHERE c.action = 'assigned' AND c.changed_at >= $2 AND c.changed_at < $3 AND c.installation_id = $7::bigint AND
+		NOT EXISTS (
+			SELECT 1 FROM issue_changes e
+			WHERE e.installation_id = c.installation_id AND e.repository_fullname = c.repository_fullname AND
+				e.issue_number = c.issue_number AND e.action = 'assigned' AND e.changed_at < $2
+		)
+	GROUP BY 1, 2, 3`
+
+// merged tells whether the pull request (by the alias) was merged. merged_at of pull requests closed
+// without merging is the zero time rather than NULL.
+const merged = `%s.merged_at > '0001-01-01 00:00:00+00'`
+
+// bucket truncates the timestamp to the period ($1 granularity).
+const bucket = `date_trunc($1, %s AT TIME ZONE 'UTC')`
+
+var queries = []struct {
+	query string
+	scan  func(p *Point) []interface{}
+}{
+	{
+		query: `
+	SELECT ` + fmt.Sprintf(bucket, "p.created_at") + `, count(*)
+	FROM pull_requests p
+	WHERE p.created_at >= $2 AND p.created_at < $3 AND ` + fmt.Sprintf(filterPredicate, "p") + `
+	GROUP BY 1`,
+		scan: func(p *Point) []interface{} {
+			return []interface{}{&p.PullRequestsOpened}
+		},
+	},
+	{
+		query: `
+	SELECT ` + fmt.Sprintf(bucket, "p.closed_at") + `,
+		count(*),
+		count(*) FILTER (WHERE ` + fmt.Sprintf(merged, "p") + `),
+		count(*) FILTER (WHERE ` + fmt.Sprintf(merged, "p") + `)::float / count(*),
+		avg(extract(epoch FROM p.merged_at - p.created_at)) FILTER (WHERE ` + fmt.Sprintf(merged, "p") + `)
+	FROM pull_requests p
+	WHERE p.closed_at >= $2 AND p.closed_at < $3 AND ` + fmt.Sprintf(filterPredicate, "p") + `
+	GROUP BY 1`,
+		scan: func(p *Point) []interface{} {
+			return []interface{}{&p.PullRequestsClosed, &p.PullRequestsMerged, &p.MergeRate, &p.LeadTime}
+		},
+	},
+	{
+		query: `
+	SELECT ` + fmt.Sprintf(bucket, "p.created_at") + `, avg(extract(epoch FROM r.first_review - p.created_at))
+	FROM pull_requests p, LATERAL (
+		SELECT min(r.created_at) AS first_review
+		FROM pull_request_reviews r
+		WHERE r.installation_id = p.installation_id AND r.repository_fullname = p.repository_fullname AND
+			r.pull_request_number = p.number AND r.user_login <> p.user_login
+	) r
+	WHERE p.created_at >= $2 AND p.created_at < $3 AND ` + fmt.Sprintf(filterPredicate, "p") + `
+	GROUP BY 1`,
+		scan: func(p *Point) []interface{} {
+			return []interface{}{&p.TimeToFirstReview}
+		},
+	},
+	{
+		query: `
+	SELECT ` + fmt.Sprintf(bucket, "r.created_at") + `, count(*)
+	FROM pull_request_reviews r
+	JOIN pull_requests p ON p.installation_id = r.installation_id AND p.repository_fullname = r.repository_fullname AND
+		p.number = r.pull_request_number
+	WHERE r.created_at >= $2 AND r.created_at < $3 AND r.user_login <> p.user_login AND ` + fmt.Sprintf(filterPredicate, "p") + `
+	GROUP BY 1`,
+		scan: func(p *Point) []interface{} {
+			return []interface{}{&p.Reviews}
+		},
+	},
+	{
+		query: `
+	SELECT ` + fmt.Sprintf(bucket, "i.closed_at") + `, count(*), avg(extract(epoch FROM i.closed_at - i.created_at))
+	FROM issues i
+	WHERE i.closed_at >= $2 AND i.closed_at < $3 AND ` + fmt.Sprintf(filterPredicate, "i") + `
+	GROUP BY 1`,
+		scan: func(p *Point) []interface{} {
+			return []interface{}{&p.IssuesClosed, &p.IssueCloseTime}
+		},
+	},
+	{
+		query: `
+	SELECT ` + fmt.Sprintf(bucket, "r.published_at") + `, count(*)
+	FROM releases r
+	WHERE r.published_at >= $2 AND r.published_at < $3 AND ` + fmt.Sprintf(releasePredicate, "r") + `
+	GROUP BY 1`,
+		scan: func(p *Point) []interface{} {
+			return []interface{}{&p.Releases}
+		},
+	},
+	{
+		query: `
+	SELECT ` + fmt.Sprintf(bucket, "r.release_published_at") + `, avg(extract(epoch FROM r.release_published_at - r.merged_at))
+	FROM pull_request_releases r
+	JOIN pull_requests p ON p.installation_id = r.installation_id AND p.repository_fullname = r.repository_fullname AND
+		p.number = r.number
+	WHERE r.release_published_at >= $2 AND r.release_published_at < $3 AND ` + fmt.Sprintf(merged, "r") + ` AND ` + fmt.Sprintf(filterPredicate, "p") + `
+	GROUP BY 1`,
+		scan: func(p *Point) []interface{} {
+			return []interface{}{&p.ReleaseTime}
+		},
+	},
+	{
+		query: `
+	SELECT ` + fmt.Sprintf(bucket, "c.completed_at") + `,
+		avg(extract(epoch FROM c.completed_at - c.started_at)),
+		count(*) FILTER (WHERE c.conclusion IN ('failure', 'timed_out', 'error') AND EXISTS (
+			SELECT 1
+			FROM pull_request_checks s
+			WHERE s.installation_id = c.installation_id AND s.pull_request_sum256 = c.pull_request_sum256 AND
+				s.kind = c.kind AND s.head_sha = c.head_sha AND s.name = c.name AND
+				s.conclusion = 'success' AND s.completed_at > c.completed_at
+		))::float / count(*)
+	FROM pull_request_checks c
+	JOIN pull_requests p ON p.installation_id = c.installation_id AND p.sum256 = c.pull_request_sum256
+	WHERE c.completed_at >= $2 AND c.completed_at < $3 AND ` + fmt.Sprintf(filterPredicate, "p") + `
+	GROUP BY 1`,
+		scan: func(p *Point) []interface{} {
+			return []interface{}{&p.CIWaitTime, &p.FlakyCheckRate}
+		},
+	},
+	{
+		query: `
+	SELECT ` + fmt.Sprintf(bucket, "d.finished_at") + `,
+		count(*) FILTER (WHERE d.state = 'success'),
+		count(*) FILTER (WHERE d.state <> 'success')::float / count(*)
+	FROM deployments d
+	WHERE d.finished_at >= $2 AND d.finished_at < $3 AND ` + fmt.Sprintf(releasePredicate, "d") + `
+	GROUP BY 1`,
+		scan: func(p *Point) []interface{} {
+			return []interface{}{&p.Deployments, &p.ChangeFailureRate}
+		},
+	},
+	{
+		query: `
+	SELECT ` + fmt.Sprintf(bucket, "d.deployed_at") + `, avg(extract(epoch FROM d.deployed_at - d.merged_at))
+	FROM pull_request_deployments d
+	JOIN pull_requests p ON p.installation_id = d.installation_id AND p.sum256 = d.sum256
+	WHERE d.deployed_at >= $2 AND d.deployed_at < $3 AND ` + fmt.Sprintf(merged, "d") + ` AND ` + fmt.Sprintf(filterPredicate, "p") + `
+	GROUP BY 1`,
+		scan: func(p *Point) []interface{} {
+			return []interface{}{&p.DeploymentLeadTime}
+		},
+	},
+	{
+		// failures followed by the same successful deployment are restored by it since the first of them
+		query: `
+	SELECT ` + fmt.Sprintf(bucket, "f.restored_at") + `, avg(extract(epoch FROM f.restored_at - f.failed_at))
+	FROM (
+		SELECT min(d.finished_at) AS failed_at, r.restored_at
+		FROM deployments d, LATERAL (
+			SELECT min(s.finished_at) AS restored_at
+			FROM deployments s
+			WHERE s.installation_id = d.installation_id AND s.repository_fullname = d.repository_fullname AND
+				s.environment = d.environment AND
+				s.state = 'success' AND s.finished_at > d.finished_at
+		) r
+		WHERE d.state IN ('failure', 'error') AND ` + fmt.Sprintf(releasePredicate, "d") + `
+		GROUP BY d.repository_fullname, d.environment, r.restored_at
+	) f
+	WHERE f.restored_at >= $2 AND f.restored_at < $3
+	GROUP BY 1`,
+		scan: func(p *Point) []interface{} {
+			return []interface{}{&p.TimeToRestore}
+		},
+	},
+	{
+		query: `
+	SELECT ` + fmt.Sprintf(bucket, "u.unlabeled_at") + `, avg(extract(epoch FROM u.unlabeled_at - c.changed_at))
+	FROM issue_changes c, LATERAL (
+		SELECT min(u.changed_at) AS unlabeled_at
+		FROM issue_changes u
+		WHERE u.installation_id = c.installation_id AND u.repository_fullname = c.repository_fullname AND
+			u.issue_number = c.issue_number AND u.label = c.label AND u.action = 'unlabeled' AND u.changed_at > c.changed_at
+	) u
+	WHERE c.action = 'labeled' AND u.unlabeled_at >= $2 AND u.unlabeled_at < $3 AND ` + fmt.Sprintf(changePredicate, "c") + `
+	GROUP BY 1`,
+		scan: func(p *Point) []interface{} {
+			return []interface{}{&p.LabelTime}
+		},
+	},
+	{
+		query: `
+	SELECT ` + fmt.Sprintf(bucket, "a.assigned_at") + `, avg(extract(epoch FROM a.assigned_at - a.created_at))
+	FROM (
+		SELECT i.created_at, f.assigned_at
+		FROM (` + firstAssignments + `) f
+		JOIN issues i ON i.installation_id = f.installation_id AND i.repository_fullname = f.repository_fullname AND
+			i.number = f.issue_number
+		WHERE ` + fmt.Sprintf(filterPredicate, "i") + `
+		UNION ALL
+		SELECT p.created_at, f.assigned_at
+		FROM (` + firstAssignments + `) f
+		JOIN pull_requests p ON p.installation_id = f.installation_id AND p.repository_fullname = f.repository_fullname AND
+			p.number = f.issue_number
+		WHERE ` + fmt.Sprintf(filterPredicate, "p") + `
+	) a
+	GROUP BY 1`,
+		scan: func(p *Point) []interface{} {
+			return []interface{}{&p.TimeToFirstAssignment}
+		},
+	},
+}
+
+// scan runs the query which returns the period in the first column and the point's fields (see dest) in the rest.
+func scan(ctx context.Context, db *github.Database, query string, args []interface{}, index map[time.Time]*Point, dest func(p *Point) []interface{}) error {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var v Point
+		if err = rows.Scan(append([]interface{}{&v.Date}, dest(&v)...)...); err != nil {
+			return err
+		}
+		p, ok := index[v.Date.UTC()]
+		if !ok {
+			return fmt.Errorf("unexpected period: %v", v.Date)
+		}
+		p.merge(&v)
+	}
+	return rows.Err()
+}
+
+// merge copies metrics set by the query.
+func (p *Point) merge(v *Point) {
+	p.PullRequestsOpened += v.PullRequestsOpened
+	p.PullRequestsClosed += v.PullRequestsClosed
+	p.PullRequestsMerged += v.PullRequestsMerged
+	p.Reviews += v.Reviews
+	p.IssuesClosed += v.IssuesClosed
+	p.Releases += v.Releases
+	p.Deployments += v.Deployments
+	for _, f := range []struct{ dst, src **float64 }{
+		{&p.MergeRate, &v.MergeRate},
+		{&p.LeadTime, &v.LeadTime},
+		{&p.TimeToFirstReview, &v.TimeToFirstReview},
+		{&p.IssueCloseTime, &v.IssueCloseTime},
+		{&p.ReleaseTime, &v.ReleaseTime},
+		{&p.CIWaitTime, &v.CIWaitTime},
+		{&p.FlakyCheckRate, &v.FlakyCheckRate},
+		{&p.DeploymentLeadTime, &v.DeploymentLeadTime},
+		{&p.ChangeFailureRate, &v.ChangeFailureRate},
+		{&p.TimeToRestore, &v.TimeToRestore},
+		{&p.LabelTime, &v.LabelTime},
+		{&p.TimeToFirstAssignment, &v.TimeToFirstAssignment},
+	} {
+		if *f.src != nil {
+			*f.dst = *f.src
+		}
+	}
+}
+
+// truncate returns the beginning of the period (in UTC), like date_trunc in postgres.
+func truncate(t time.Time, granularity string) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	if granularity == Week {
+		// weeks start on Monday
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	}
+	return day
+}
+
+func next(t time.Time, granularity string) time.Time {
+	if granularity == Week {
+		return t.AddDate(0, 0, 7)
+	}
+	return t.AddDate(0, 0, 1)
+}
+
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
@@ -0,0 +1,39 @@
+package migrations
+
+// failedEvents keeps the events which failed to be processed and parks the permanently failing ones.
+const failedEventsUp = `
+--
+-- Name: failed_events; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.failed_events (
+    delivery_id text NOT NULL,
+    event_type text NOT NULL,
+    payload bytea NOT NULL,
+    error text NOT NULL,
+    permanent boolean NOT NULL,
+    attempts integer NOT NULL,
+    first_seen_at timestamp with time zone NOT NULL,
+    last_seen_at timestamp with time zone NOT NULL,
+    parked_at timestamp with time zone
+);
+
+
+--
+-- Name: failed_events failed_events_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.failed_events
+    ADD CONSTRAINT failed_events_pkey PRIMARY KEY (delivery_id);
+
+
+--
+-- Name: failed_events_parked_at; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX failed_events_parked_at ON public.failed_events USING btree (parked_at);
+`
+
+const failedEventsDown = `
+DROP TABLE public.failed_events;
+`
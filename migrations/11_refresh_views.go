@@ -0,0 +1,479 @@
+package migrations
+
+// refreshViews adds the unique sum256 column (the row of the versioned table) to materialized views,
+// which is required by REFRESH MATERIALIZED VIEW CONCURRENTLY, and records when the views were refreshed.
+const refreshViewsUp = `
+DROP MATERIALIZED VIEW public.issue_comments;
+DROP MATERIALIZED VIEW public.issues;
+DROP MATERIALIZED VIEW public.owners;
+DROP MATERIALIZED VIEW public.pull_request_comments;
+DROP MATERIALIZED VIEW public.pull_request_reviews;
+DROP MATERIALIZED VIEW public.pull_requests;
+DROP MATERIALIZED VIEW public.repositories;
+DROP MATERIALIZED VIEW public.users;
+
+
+--
+-- Name: issue_comments; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.issue_comments AS
+ SELECT c.repository_owner,
+    c.repository_name,
+    c.repository_fullname,
+    c.issue_number,
+    c.created_at,
+    c.body,
+    c.user_id,
+    c.user_login,
+    c.htmlurl AS html_url,
+    c.sum256
+   FROM (public.github_issue_comments_versioned c
+     JOIN public.github_issues_versioned i ON (((i.repository_owner = c.repository_owner) AND (i.repository_name = c.repository_name) AND (i.number = c.issue_number))))
+  WHERE ((c.deleted_at IS NULL) AND (i.deleted_at IS NULL))
+  WITH NO DATA;
+
+
+--
+-- Name: issues; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.issues AS
+ SELECT github_issues_versioned.repository_owner,
+    github_issues_versioned.repository_name,
+    github_issues_versioned.repository_fullname,
+    github_issues_versioned.number,
+    github_issues_versioned.state,
+    github_issues_versioned.title,
+    github_issues_versioned.body,
+    github_issues_versioned.created_at,
+    github_issues_versioned.closed_at,
+    github_issues_versioned.updated_at,
+    github_issues_versioned.comments,
+    github_issues_versioned.user_id,
+    github_issues_versioned.user_login,
+    github_issues_versioned.htmlurl AS html_url,
+    github_issues_versioned.labels,
+    github_issues_versioned.sum256
+   FROM public.github_issues_versioned
+  WHERE (github_issues_versioned.deleted_at IS NULL)
+  WITH NO DATA;
+
+
+--
+-- Name: owners; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.owners AS
+ SELECT github_organizations_versioned.login,
+    github_organizations_versioned.name,
+    github_organizations_versioned.sum256
+   FROM public.github_organizations_versioned
+  WHERE (github_organizations_versioned.deleted_at IS NULL)
+  WITH NO DATA;
+
+
+--
+-- Name: pull_request_comments; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.pull_request_comments AS
+ SELECT c.repository_owner,
+    c.repository_name,
+    c.repository_fullname,
+    c.issue_number AS pull_request_number,
+    c.created_at,
+    c.body,
+    c.user_id,
+    c.user_login,
+    c.htmlurl AS html_url,
+    c.sum256
+   FROM (public.github_issue_comments_versioned c
+     JOIN public.github_pull_requests_versioned p ON (((p.repository_owner = c.repository_owner) AND (p.repository_name = c.repository_name) AND (p.number = c.issue_number))))
+  WHERE (c.deleted_at IS NULL)
+UNION
+ SELECT c.repository_owner,
+    c.repository_name,
+    c.repository_fullname,
+    c.pull_request_number,
+    c.created_at,
+    c.body,
+    c.user_id,
+    c.user_login,
+    c.htmlurl AS html_url,
+    c.sum256
+   FROM public.github_pull_request_comments_versioned c
+  WHERE (c.deleted_at IS NULL)
+UNION
+ SELECT c.repository_owner,
+    c.repository_name,
+    c.repository_fullname,
+    c.pull_request_number,
+    c.submitted_at AS created_at,
+    c.body,
+    c.user_id,
+    c.user_login,
+    c.htmlurl AS html_url,
+    c.sum256
+   FROM public.github_pull_request_reviews_versioned c
+  WHERE (c.body <> ''::text)
+  WITH NO DATA;
+
+
+--
+-- Name: pull_request_reviews; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.pull_request_reviews AS
+ SELECT github_pull_request_reviews_versioned.repository_owner,
+    github_pull_request_reviews_versioned.repository_name,
+    github_pull_request_reviews_versioned.repository_fullname,
+    github_pull_request_reviews_versioned.pull_request_number,
+    github_pull_request_reviews_versioned.submitted_at AS created_at,
+    github_pull_request_reviews_versioned.user_id,
+    github_pull_request_reviews_versioned.user_login,
+    github_pull_request_reviews_versioned.htmlurl AS html_url,
+        CASE
+            WHEN (github_pull_request_reviews_versioned.state = 'CHANGES_REQUESTED'::text) THEN 'COMMENTED'::text
+            ELSE github_pull_request_reviews_versioned.state
+        END AS state,
+    github_pull_request_reviews_versioned.sum256
+   FROM public.github_pull_request_reviews_versioned
+  WITH NO DATA;
+
+
+--
+-- Name: pull_requests; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.pull_requests AS
+ SELECT github_pull_requests_versioned.repository_owner,
+    github_pull_requests_versioned.repository_name,
+    github_pull_requests_versioned.repository_fullname,
+    github_pull_requests_versioned.number,
+    github_pull_requests_versioned.state,
+    github_pull_requests_versioned.title,
+    github_pull_requests_versioned.body,
+    github_pull_requests_versioned.created_at,
+    github_pull_requests_versioned.closed_at,
+    github_pull_requests_versioned.merged_at,
+    github_pull_requests_versioned.updated_at,
+    github_pull_requests_versioned.commits,
+    github_pull_requests_versioned.comments,
+    github_pull_requests_versioned.changed_files,
+    github_pull_requests_versioned.additions,
+    github_pull_requests_versioned.deletions,
+    github_pull_requests_versioned.review_comments AS reviews,
+    github_pull_requests_versioned.user_id,
+    github_pull_requests_versioned.user_login,
+    github_pull_requests_versioned.base_repository_name,
+    github_pull_requests_versioned.base_repository_owner,
+    github_pull_requests_versioned.base_repository_fullname,
+    github_pull_requests_versioned.head_ref,
+    github_pull_requests_versioned.head_sha,
+    github_pull_requests_versioned.merge_commit_sha,
+    github_pull_requests_versioned.htmlurl AS html_url,
+    github_pull_requests_versioned.labels,
+    github_pull_requests_versioned.sum256
+   FROM public.github_pull_requests_versioned
+  WITH NO DATA;
+
+
+--
+-- Name: repositories; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.repositories AS
+ SELECT github_repositories_versioned.owner_login AS owner,
+    github_repositories_versioned.name,
+    github_repositories_versioned.fullname,
+    github_repositories_versioned.private,
+    github_repositories_versioned.description,
+    github_repositories_versioned.sum256
+   FROM public.github_repositories_versioned
+  WHERE (github_repositories_versioned.deleted_at IS NULL)
+  WITH NO DATA;
+
+
+--
+-- Name: users; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.users AS
+ SELECT github_users_versioned.login,
+    github_users_versioned.name,
+    github_users_versioned.sum256
+   FROM public.github_users_versioned
+  WITH NO DATA;
+
+
+--
+-- Name: issue_comments_sum256; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX issue_comments_sum256 ON public.issue_comments USING btree (sum256);
+
+
+--
+-- Name: issues_sum256; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX issues_sum256 ON public.issues USING btree (sum256);
+
+
+--
+-- Name: owners_sum256; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX owners_sum256 ON public.owners USING btree (sum256);
+
+
+--
+-- Name: pull_request_comments_sum256; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX pull_request_comments_sum256 ON public.pull_request_comments USING btree (sum256);
+
+
+--
+-- Name: pull_request_reviews_sum256; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX pull_request_reviews_sum256 ON public.pull_request_reviews USING btree (sum256);
+
+
+--
+-- Name: pull_requests_sum256; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX pull_requests_sum256 ON public.pull_requests USING btree (sum256);
+
+
+--
+-- Name: repositories_sum256; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX repositories_sum256 ON public.repositories USING btree (sum256);
+
+
+--
+-- Name: users_sum256; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX users_sum256 ON public.users USING btree (sum256);
+
+
+--
+-- Name: materialized_view_refreshes; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.materialized_view_refreshes (
+    name text NOT NULL,
+    refreshed_at timestamp with time zone NOT NULL,
+    duration interval NOT NULL
+);
+
+
+--
+-- Name: materialized_view_refreshes materialized_view_refreshes_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.materialized_view_refreshes
+    ADD CONSTRAINT materialized_view_refreshes_pkey PRIMARY KEY (name);
+`
+
+const refreshViewsDown = `
+DROP TABLE public.materialized_view_refreshes;
+DROP MATERIALIZED VIEW public.issue_comments;
+DROP MATERIALIZED VIEW public.issues;
+DROP MATERIALIZED VIEW public.owners;
+DROP MATERIALIZED VIEW public.pull_request_comments;
+DROP MATERIALIZED VIEW public.pull_request_reviews;
+DROP MATERIALIZED VIEW public.pull_requests;
+DROP MATERIALIZED VIEW public.repositories;
+DROP MATERIALIZED VIEW public.users;
+
+
+--
+-- Name: issue_comments; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.issue_comments AS
+ SELECT c.repository_owner,
+    c.repository_name,
+    c.repository_fullname,
+    c.issue_number,
+    c.created_at,
+    c.body,
+    c.user_id,
+    c.user_login,
+    c.htmlurl AS html_url
+   FROM (public.github_issue_comments_versioned c
+     JOIN public.github_issues_versioned i ON (((i.repository_owner = c.repository_owner) AND (i.repository_name = c.repository_name) AND (i.number = c.issue_number))))
+  WHERE ((c.deleted_at IS NULL) AND (i.deleted_at IS NULL))
+  WITH NO DATA;
+
+
+--
+-- Name: issues; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.issues AS
+ SELECT github_issues_versioned.repository_owner,
+    github_issues_versioned.repository_name,
+    github_issues_versioned.repository_fullname,
+    github_issues_versioned.number,
+    github_issues_versioned.state,
+    github_issues_versioned.title,
+    github_issues_versioned.body,
+    github_issues_versioned.created_at,
+    github_issues_versioned.closed_at,
+    github_issues_versioned.updated_at,
+    github_issues_versioned.comments,
+    github_issues_versioned.user_id,
+    github_issues_versioned.user_login,
+    github_issues_versioned.htmlurl AS html_url,
+    github_issues_versioned.labels
+   FROM public.github_issues_versioned
+  WHERE (github_issues_versioned.deleted_at IS NULL)
+  WITH NO DATA;
+
+
+--
+-- Name: owners; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.owners AS
+ SELECT github_organizations_versioned.login,
+    github_organizations_versioned.name
+   FROM public.github_organizations_versioned
+  WHERE (github_organizations_versioned.deleted_at IS NULL)
+  WITH NO DATA;
+
+
+--
+-- Name: pull_request_comments; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.pull_request_comments AS
+ SELECT c.repository_owner,
+    c.repository_name,
+    c.repository_fullname,
+    c.issue_number AS pull_request_number,
+    c.created_at,
+    c.body,
+    c.user_id,
+    c.user_login,
+    c.htmlurl AS html_url
+   FROM (public.github_issue_comments_versioned c
+     JOIN public.github_pull_requests_versioned p ON (((p.repository_owner = c.repository_owner) AND (p.repository_name = c.repository_name) AND (p.number = c.issue_number))))
+  WHERE (c.deleted_at IS NULL)
+UNION
+ SELECT c.repository_owner,
+    c.repository_name,
+    c.repository_fullname,
+    c.pull_request_number,
+    c.created_at,
+    c.body,
+    c.user_id,
+    c.user_login,
+    c.htmlurl AS html_url
+   FROM public.github_pull_request_comments_versioned c
+  WHERE (c.deleted_at IS NULL)
+UNION
+ SELECT c.repository_owner,
+    c.repository_name,
+    c.repository_fullname,
+    c.pull_request_number,
+    c.submitted_at AS created_at,
+    c.body,
+    c.user_id,
+    c.user_login,
+    c.htmlurl AS html_url
+   FROM public.github_pull_request_reviews_versioned c
+  WHERE (c.body <> ''::text)
+  WITH NO DATA;
+
+
+--
+-- Name: pull_request_reviews; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.pull_request_reviews AS
+ SELECT github_pull_request_reviews_versioned.repository_owner,
+    github_pull_request_reviews_versioned.repository_name,
+    github_pull_request_reviews_versioned.repository_fullname,
+    github_pull_request_reviews_versioned.pull_request_number,
+    github_pull_request_reviews_versioned.submitted_at AS created_at,
+    github_pull_request_reviews_versioned.user_id,
+    github_pull_request_reviews_versioned.user_login,
+    github_pull_request_reviews_versioned.htmlurl AS html_url,
+        CASE
+            WHEN (github_pull_request_reviews_versioned.state = 'CHANGES_REQUESTED'::text) THEN 'COMMENTED'::text
+            ELSE github_pull_request_reviews_versioned.state
+        END AS state
+   FROM public.github_pull_request_reviews_versioned
+  WITH NO DATA;
+
+
+--
+-- Name: pull_requests; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.pull_requests AS
+ SELECT github_pull_requests_versioned.repository_owner,
+    github_pull_requests_versioned.repository_name,
+    github_pull_requests_versioned.repository_fullname,
+    github_pull_requests_versioned.number,
+    github_pull_requests_versioned.state,
+    github_pull_requests_versioned.title,
+    github_pull_requests_versioned.body,
+    github_pull_requests_versioned.created_at,
+    github_pull_requests_versioned.closed_at,
+    github_pull_requests_versioned.merged_at,
+    github_pull_requests_versioned.updated_at,
+    github_pull_requests_versioned.commits,
+    github_pull_requests_versioned.comments,
+    github_pull_requests_versioned.changed_files,
+    github_pull_requests_versioned.additions,
+    github_pull_requests_versioned.deletions,
+    github_pull_requests_versioned.review_comments AS reviews,
+    github_pull_requests_versioned.user_id,
+    github_pull_requests_versioned.user_login,
+    github_pull_requests_versioned.base_repository_name,
+    github_pull_requests_versioned.base_repository_owner,
+    github_pull_requests_versioned.base_repository_fullname,
+    github_pull_requests_versioned.head_ref,
+    github_pull_requests_versioned.head_sha,
+    github_pull_requests_versioned.merge_commit_sha,
+    github_pull_requests_versioned.htmlurl AS html_url,
+    github_pull_requests_versioned.labels
+   FROM public.github_pull_requests_versioned
+  WITH NO DATA;
+
+
+--
+-- Name: repositories; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.repositories AS
+ SELECT github_repositories_versioned.owner_login AS owner,
+    github_repositories_versioned.name,
+    github_repositories_versioned.fullname,
+    github_repositories_versioned.private,
+    github_repositories_versioned.description
+   FROM public.github_repositories_versioned
+  WHERE (github_repositories_versioned.deleted_at IS NULL)
+  WITH NO DATA;
+
+
+--
+-- Name: users; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.users AS
+ SELECT github_users_versioned.login,
+    github_users_versioned.name
+   FROM public.github_users_versioned
+  WITH NO DATA;
+`
@@ -0,0 +1,28 @@
+package migrations
+
+// backfill records the progress of backfilled repositories, so the backfill can be resumed.
+const backfillUp = `
+--
+-- Name: backfill_checkpoints; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.backfill_checkpoints (
+    repository_fullname text NOT NULL,
+    stage text NOT NULL,
+    page integer NOT NULL,
+    done boolean NOT NULL,
+    updated_at timestamp with time zone NOT NULL
+);
+
+
+--
+-- Name: backfill_checkpoints backfill_checkpoints_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.backfill_checkpoints
+    ADD CONSTRAINT backfill_checkpoints_pkey PRIMARY KEY (repository_fullname, stage);
+`
+
+const backfillDown = `
+DROP TABLE public.backfill_checkpoints;
+`
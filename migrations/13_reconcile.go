@@ -0,0 +1,27 @@
+package migrations
+
+// reconcile records the last reconciliation of repositories with the GitHub API.
+const reconcileUp = `
+--
+-- Name: reconcile_checkpoints; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.reconcile_checkpoints (
+    repository_fullname text NOT NULL,
+    since timestamp with time zone NOT NULL,
+    reconciled_at timestamp with time zone NOT NULL,
+    repaired integer NOT NULL
+);
+
+
+--
+-- Name: reconcile_checkpoints reconcile_checkpoints_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.reconcile_checkpoints
+    ADD CONSTRAINT reconcile_checkpoints_pkey PRIMARY KEY (repository_fullname);
+`
+
+const reconcileDown = `
+DROP TABLE public.reconcile_checkpoints;
+`
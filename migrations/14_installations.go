@@ -0,0 +1,1236 @@
+package migrations
+
+// installations records installations of the GitHub App and adds the installation
+// (the tenant) of the event to every row of the versioned tables and their views.
+// Existing rows can't be attributed to installations, they are scoped by the scope command.
+const installationsUp = `
+--
+-- Name: installations; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.installations (
+    id bigint NOT NULL,
+    app_id bigint NOT NULL,
+    account_id bigint NOT NULL,
+    account_login text NOT NULL,
+    account_type text NOT NULL,
+    target_type text NOT NULL,
+    repository_selection text NOT NULL,
+    permissions jsonb NOT NULL,
+    events text[] NOT NULL,
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+    suspended_at timestamp with time zone,
+    deleted_at timestamp with time zone
+);
+
+
+--
+-- Name: installations installations_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.installations
+    ADD CONSTRAINT installations_pkey PRIMARY KEY (id);
+
+
+ALTER TABLE public.github_commits_versioned ADD COLUMN installation_id bigint;
+ALTER TABLE public.github_issue_comments_versioned ADD COLUMN installation_id bigint;
+ALTER TABLE public.github_issues_versioned ADD COLUMN installation_id bigint;
+ALTER TABLE public.github_organizations_versioned ADD COLUMN installation_id bigint;
+ALTER TABLE public.github_pull_request_comments_versioned ADD COLUMN installation_id bigint;
+ALTER TABLE public.github_pull_request_reviews_versioned ADD COLUMN installation_id bigint;
+ALTER TABLE public.github_pull_requests_versioned ADD COLUMN installation_id bigint;
+ALTER TABLE public.github_refs_versioned ADD COLUMN installation_id bigint;
+ALTER TABLE public.github_repositories_versioned ADD COLUMN installation_id bigint;
+ALTER TABLE public.github_users_versioned ADD COLUMN installation_id bigint;
+ALTER TABLE public.github_commits_history ADD COLUMN installation_id bigint;
+ALTER TABLE public.github_issue_comments_history ADD COLUMN installation_id bigint;
+ALTER TABLE public.github_issues_history ADD COLUMN installation_id bigint;
+ALTER TABLE public.github_organizations_history ADD COLUMN installation_id bigint;
+ALTER TABLE public.github_pull_request_comments_history ADD COLUMN installation_id bigint;
+ALTER TABLE public.github_pull_request_reviews_history ADD COLUMN installation_id bigint;
+ALTER TABLE public.github_pull_requests_history ADD COLUMN installation_id bigint;
+ALTER TABLE public.github_refs_history ADD COLUMN installation_id bigint;
+ALTER TABLE public.github_repositories_history ADD COLUMN installation_id bigint;
+ALTER TABLE public.github_users_history ADD COLUMN installation_id bigint;
+ALTER TABLE public.github_pull_request_pushes ADD COLUMN installation_id bigint;
+ALTER TABLE public.pull_request_state_transitions ADD COLUMN installation_id bigint;
+
+
+--
+-- Name: github_commits; Type: VIEW; Schema: public; Owner: -
+--
+
+CREATE OR REPLACE VIEW public.github_commits AS
+ SELECT github_commits_versioned.added,
+    github_commits_versioned.after,
+    github_commits_versioned.author_email,
+    github_commits_versioned.author_name,
+    github_commits_versioned.before,
+    github_commits_versioned.committed_at,
+    github_commits_versioned.committer_email,
+    github_commits_versioned.committer_name,
+    github_commits_versioned.forced,
+    github_commits_versioned.htmlurl,
+    github_commits_versioned.message,
+    github_commits_versioned.modified,
+    github_commits_versioned.ref,
+    github_commits_versioned.removed,
+    github_commits_versioned.repository_name,
+    github_commits_versioned.repository_owner,
+    github_commits_versioned.repository_fullname,
+    github_commits_versioned.sha,
+    github_commits_versioned.tree_id,
+    github_commits_versioned.installation_id
+   FROM public.github_commits_versioned;
+
+
+--
+-- Name: github_issue_comments; Type: VIEW; Schema: public; Owner: -
+--
+
+CREATE OR REPLACE VIEW public.github_issue_comments AS
+ SELECT github_issue_comments_versioned.author_association,
+    github_issue_comments_versioned.body,
+    github_issue_comments_versioned.created_at,
+    github_issue_comments_versioned.htmlurl,
+    github_issue_comments_versioned.id,
+    github_issue_comments_versioned.issue_number,
+    github_issue_comments_versioned.node_id,
+    github_issue_comments_versioned.repository_name,
+    github_issue_comments_versioned.repository_owner,
+    github_issue_comments_versioned.repository_fullname,
+    github_issue_comments_versioned.updated_at,
+    github_issue_comments_versioned.user_id,
+    github_issue_comments_versioned.user_login,
+    github_issue_comments_versioned.installation_id
+   FROM public.github_issue_comments_versioned
+  WHERE (github_issue_comments_versioned.deleted_at IS NULL);
+
+
+--
+-- Name: github_issues; Type: VIEW; Schema: public; Owner: -
+--
+
+CREATE OR REPLACE VIEW public.github_issues AS
+ SELECT github_issues_versioned.assignees,
+    github_issues_versioned.body,
+    github_issues_versioned.closed_at,
+    github_issues_versioned.closed_by_id,
+    github_issues_versioned.closed_by_login,
+    github_issues_versioned.comments,
+    github_issues_versioned.created_at,
+    github_issues_versioned.htmlurl,
+    github_issues_versioned.id,
+    github_issues_versioned.labels,
+    github_issues_versioned.locked,
+    github_issues_versioned.milestone_id,
+    github_issues_versioned.milestone_title,
+    github_issues_versioned.node_id,
+    github_issues_versioned.number,
+    github_issues_versioned.repository_name,
+    github_issues_versioned.repository_owner,
+    github_issues_versioned.repository_fullname,
+    github_issues_versioned.state,
+    github_issues_versioned.title,
+    github_issues_versioned.updated_at,
+    github_issues_versioned.user_id,
+    github_issues_versioned.user_login,
+    github_issues_versioned.installation_id
+   FROM public.github_issues_versioned
+  WHERE (github_issues_versioned.deleted_at IS NULL);
+
+
+--
+-- Name: github_organizations; Type: VIEW; Schema: public; Owner: -
+--
+
+CREATE OR REPLACE VIEW public.github_organizations AS
+ SELECT github_organizations_versioned.avatar_url,
+    github_organizations_versioned.collaborators,
+    github_organizations_versioned.created_at,
+    github_organizations_versioned.description,
+    github_organizations_versioned.email,
+    github_organizations_versioned.htmlurl,
+    github_organizations_versioned.id,
+    github_organizations_versioned.login,
+    github_organizations_versioned.name,
+    github_organizations_versioned.node_id,
+    github_organizations_versioned.owned_private_repos,
+    github_organizations_versioned.public_repos,
+    github_organizations_versioned.total_private_repos,
+    github_organizations_versioned.updated_at,
+    github_organizations_versioned.installation_id
+   FROM public.github_organizations_versioned
+  WHERE (github_organizations_versioned.deleted_at IS NULL);
+
+
+--
+-- Name: github_pull_request_comments; Type: VIEW; Schema: public; Owner: -
+--
+
+CREATE OR REPLACE VIEW public.github_pull_request_comments AS
+ SELECT github_pull_request_comments_versioned.author_association,
+    github_pull_request_comments_versioned.body,
+    github_pull_request_comments_versioned.commit_id,
+    github_pull_request_comments_versioned.created_at,
+    github_pull_request_comments_versioned.diff_hunk,
+    github_pull_request_comments_versioned.htmlurl,
+    github_pull_request_comments_versioned.id,
+    github_pull_request_comments_versioned.in_reply_to,
+    github_pull_request_comments_versioned.node_id,
+    github_pull_request_comments_versioned.original_commit_id,
+    github_pull_request_comments_versioned.original_position,
+    github_pull_request_comments_versioned.path,
+    github_pull_request_comments_versioned."position",
+    github_pull_request_comments_versioned.pull_request_number,
+    github_pull_request_comments_versioned.pull_request_review_id,
+    github_pull_request_comments_versioned.repository_name,
+    github_pull_request_comments_versioned.repository_owner,
+    github_pull_request_comments_versioned.repository_fullname,
+    github_pull_request_comments_versioned.updated_at,
+    github_pull_request_comments_versioned.user_id,
+    github_pull_request_comments_versioned.user_login,
+    github_pull_request_comments_versioned.installation_id
+   FROM public.github_pull_request_comments_versioned
+  WHERE (github_pull_request_comments_versioned.deleted_at IS NULL);
+
+
+--
+-- Name: github_pull_request_reviews; Type: VIEW; Schema: public; Owner: -
+--
+
+CREATE OR REPLACE VIEW public.github_pull_request_reviews AS
+ SELECT github_pull_request_reviews_versioned.body,
+    github_pull_request_reviews_versioned.commit_id,
+    github_pull_request_reviews_versioned.htmlurl,
+    github_pull_request_reviews_versioned.id,
+    github_pull_request_reviews_versioned.node_id,
+    github_pull_request_reviews_versioned.pull_request_number,
+    github_pull_request_reviews_versioned.repository_name,
+    github_pull_request_reviews_versioned.repository_owner,
+    github_pull_request_reviews_versioned.repository_fullname,
+    github_pull_request_reviews_versioned.state,
+    github_pull_request_reviews_versioned.submitted_at,
+    github_pull_request_reviews_versioned.user_id,
+    github_pull_request_reviews_versioned.user_login,
+    github_pull_request_reviews_versioned.installation_id
+   FROM public.github_pull_request_reviews_versioned;
+
+
+--
+-- Name: github_pull_requests; Type: VIEW; Schema: public; Owner: -
+--
+
+CREATE OR REPLACE VIEW public.github_pull_requests AS
+ SELECT github_pull_requests_versioned.additions,
+    github_pull_requests_versioned.assignees,
+    github_pull_requests_versioned.author_association,
+    github_pull_requests_versioned.base_ref,
+    github_pull_requests_versioned.base_repository_name,
+    github_pull_requests_versioned.base_repository_owner,
+    github_pull_requests_versioned.base_repository_fullname,
+    github_pull_requests_versioned.base_sha,
+    github_pull_requests_versioned.base_user,
+    github_pull_requests_versioned.body,
+    github_pull_requests_versioned.changed_files,
+    github_pull_requests_versioned.closed_at,
+    github_pull_requests_versioned.comments,
+    github_pull_requests_versioned.commits,
+    github_pull_requests_versioned.created_at,
+    github_pull_requests_versioned.deletions,
+    github_pull_requests_versioned.head_ref,
+    github_pull_requests_versioned.head_repository_name,
+    github_pull_requests_versioned.head_repository_owner,
+    github_pull_requests_versioned.head_repository_fullname,
+    github_pull_requests_versioned.head_sha,
+    github_pull_requests_versioned.head_user,
+    github_pull_requests_versioned.htmlurl,
+    github_pull_requests_versioned.id,
+    github_pull_requests_versioned.labels,
+    github_pull_requests_versioned.maintainer_can_modify,
+    github_pull_requests_versioned.merge_commit_sha,
+    github_pull_requests_versioned.mergeable,
+    github_pull_requests_versioned.merged,
+    github_pull_requests_versioned.merged_at,
+    github_pull_requests_versioned.merged_by_id,
+    github_pull_requests_versioned.merged_by_login,
+    github_pull_requests_versioned.milestone_id,
+    github_pull_requests_versioned.milestone_title,
+    github_pull_requests_versioned.node_id,
+    github_pull_requests_versioned.number,
+    github_pull_requests_versioned.repository_name,
+    github_pull_requests_versioned.repository_owner,
+    github_pull_requests_versioned.repository_fullname,
+    github_pull_requests_versioned.review_comments,
+    github_pull_requests_versioned.state,
+    github_pull_requests_versioned.title,
+    github_pull_requests_versioned.updated_at,
+    github_pull_requests_versioned.user_id,
+    github_pull_requests_versioned.user_login,
+    github_pull_requests_versioned.installation_id
+   FROM public.github_pull_requests_versioned;
+
+
+--
+-- Name: github_refs; Type: VIEW; Schema: public; Owner: -
+--
+
+CREATE OR REPLACE VIEW public.github_refs AS
+ SELECT github_refs_versioned.created_at,
+    github_refs_versioned.created_by_id,
+    github_refs_versioned.created_by_login,
+    github_refs_versioned.deleted_at,
+    github_refs_versioned.deleted_by_id,
+    github_refs_versioned.deleted_by_login,
+    github_refs_versioned.master_branch,
+    github_refs_versioned.pusher_type,
+    github_refs_versioned.ref,
+    github_refs_versioned.ref_type,
+    github_refs_versioned.repository_name,
+    github_refs_versioned.repository_owner,
+    github_refs_versioned.repository_fullname,
+    github_refs_versioned.installation_id
+   FROM public.github_refs_versioned;
+
+
+--
+-- Name: github_repositories; Type: VIEW; Schema: public; Owner: -
+--
+
+CREATE OR REPLACE VIEW public.github_repositories AS
+ SELECT github_repositories_versioned.allow_merge_commit,
+    github_repositories_versioned.allow_rebase_merge,
+    github_repositories_versioned.allow_squash_merge,
+    github_repositories_versioned.archived,
+    github_repositories_versioned.clone_url,
+    github_repositories_versioned.created_at,
+    github_repositories_versioned.default_branch,
+    github_repositories_versioned.description,
+    github_repositories_versioned.disabled,
+    github_repositories_versioned.fork,
+    github_repositories_versioned.forks_count,
+    github_repositories_versioned.fullname,
+    github_repositories_versioned.has_issues,
+    github_repositories_versioned.has_wiki,
+    github_repositories_versioned.homepage,
+    github_repositories_versioned.htmlurl,
+    github_repositories_versioned.id,
+    github_repositories_versioned.language,
+    github_repositories_versioned.name,
+    github_repositories_versioned.node_id,
+    github_repositories_versioned.open_issues_count,
+    github_repositories_versioned.owner_id,
+    github_repositories_versioned.owner_login,
+    github_repositories_versioned.owner_type,
+    github_repositories_versioned.private,
+    github_repositories_versioned.pushed_at,
+    github_repositories_versioned.sshurl,
+    github_repositories_versioned.stargazers_count,
+    github_repositories_versioned.topics,
+    github_repositories_versioned.updated_at,
+    github_repositories_versioned.watchers_count,
+    github_repositories_versioned.installation_id
+   FROM public.github_repositories_versioned
+  WHERE (github_repositories_versioned.deleted_at IS NULL);
+
+
+--
+-- Name: github_users; Type: VIEW; Schema: public; Owner: -
+--
+
+CREATE OR REPLACE VIEW public.github_users AS
+ SELECT github_users_versioned.avatar_url,
+    github_users_versioned.bio,
+    github_users_versioned.company,
+    github_users_versioned.created_at,
+    github_users_versioned.email,
+    github_users_versioned.followers,
+    github_users_versioned.following,
+    github_users_versioned.hireable,
+    github_users_versioned.htmlurl,
+    github_users_versioned.id,
+    github_users_versioned.location,
+    github_users_versioned.login,
+    github_users_versioned.name,
+    github_users_versioned.node_id,
+    github_users_versioned.organization_id,
+    github_users_versioned.organization_login,
+    github_users_versioned.owned_private_repos,
+    github_users_versioned.private_gists,
+    github_users_versioned.public_gists,
+    github_users_versioned.public_repos,
+    github_users_versioned.total_private_repos,
+    github_users_versioned.updated_at,
+    github_users_versioned.installation_id
+   FROM public.github_users_versioned;
+
+
+DROP MATERIALIZED VIEW public.issue_comments;
+DROP MATERIALIZED VIEW public.issues;
+DROP MATERIALIZED VIEW public.owners;
+DROP MATERIALIZED VIEW public.pull_request_comments;
+DROP MATERIALIZED VIEW public.pull_request_reviews;
+DROP MATERIALIZED VIEW public.pull_requests;
+DROP MATERIALIZED VIEW public.repositories;
+DROP MATERIALIZED VIEW public.users;
+
+
+--
+-- Name: issue_comments; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.issue_comments AS
+ SELECT c.repository_owner,
+    c.repository_name,
+    c.repository_fullname,
+    c.issue_number,
+    c.created_at,
+    c.body,
+    c.user_id,
+    c.user_login,
+    c.htmlurl AS html_url,
+    c.sum256,
+    c.installation_id
+   FROM (public.github_issue_comments_versioned c
+     JOIN public.github_issues_versioned i ON (((i.repository_owner = c.repository_owner) AND (i.repository_name = c.repository_name) AND (i.number = c.issue_number))))
+  WHERE ((c.deleted_at IS NULL) AND (i.deleted_at IS NULL))
+  WITH NO DATA;
+
+
+--
+-- Name: issue_comments_sum256; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX issue_comments_sum256 ON public.issue_comments USING btree (sum256);
+
+
+--
+-- Name: issues; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.issues AS
+ SELECT github_issues_versioned.repository_owner,
+    github_issues_versioned.repository_name,
+    github_issues_versioned.repository_fullname,
+    github_issues_versioned.number,
+    github_issues_versioned.state,
+    github_issues_versioned.title,
+    github_issues_versioned.body,
+    github_issues_versioned.created_at,
+    github_issues_versioned.closed_at,
+    github_issues_versioned.updated_at,
+    github_issues_versioned.comments,
+    github_issues_versioned.user_id,
+    github_issues_versioned.user_login,
+    github_issues_versioned.htmlurl AS html_url,
+    github_issues_versioned.labels,
+    github_issues_versioned.sum256,
+    github_issues_versioned.installation_id
+   FROM public.github_issues_versioned
+  WHERE (github_issues_versioned.deleted_at IS NULL)
+  WITH NO DATA;
+
+
+--
+-- Name: issues_sum256; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX issues_sum256 ON public.issues USING btree (sum256);
+
+
+--
+-- Name: owners; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.owners AS
+ SELECT github_organizations_versioned.login,
+    github_organizations_versioned.name,
+    github_organizations_versioned.sum256,
+    github_organizations_versioned.installation_id
+   FROM public.github_organizations_versioned
+  WHERE (github_organizations_versioned.deleted_at IS NULL)
+  WITH NO DATA;
+
+
+--
+-- Name: owners_sum256; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX owners_sum256 ON public.owners USING btree (sum256);
+
+
+--
+-- Name: pull_request_comments; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.pull_request_comments AS
+ SELECT c.repository_owner,
+    c.repository_name,
+    c.repository_fullname,
+    c.issue_number AS pull_request_number,
+    c.created_at,
+    c.body,
+    c.user_id,
+    c.user_login,
+    c.htmlurl AS html_url,
+    c.sum256,
+    c.installation_id
+   FROM (public.github_issue_comments_versioned c
+     JOIN public.github_pull_requests_versioned p ON (((p.repository_owner = c.repository_owner) AND (p.repository_name = c.repository_name) AND (p.number = c.issue_number))))
+  WHERE (c.deleted_at IS NULL)
+UNION
+ SELECT c.repository_owner,
+    c.repository_name,
+    c.repository_fullname,
+    c.pull_request_number,
+    c.created_at,
+    c.body,
+    c.user_id,
+    c.user_login,
+    c.htmlurl AS html_url,
+    c.sum256,
+    c.installation_id
+   FROM public.github_pull_request_comments_versioned c
+  WHERE (c.deleted_at IS NULL)
+UNION
+ SELECT c.repository_owner,
+    c.repository_name,
+    c.repository_fullname,
+    c.pull_request_number,
+    c.submitted_at AS created_at,
+    c.body,
+    c.user_id,
+    c.user_login,
+    c.htmlurl AS html_url,
+    c.sum256,
+    c.installation_id
+   FROM public.github_pull_request_reviews_versioned c
+  WHERE (c.body <> ''::text)
+  WITH NO DATA;
+
+
+--
+-- Name: pull_request_comments_sum256; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX pull_request_comments_sum256 ON public.pull_request_comments USING btree (sum256);
+
+
+--
+-- Name: pull_request_reviews; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.pull_request_reviews AS
+ SELECT github_pull_request_reviews_versioned.repository_owner,
+    github_pull_request_reviews_versioned.repository_name,
+    github_pull_request_reviews_versioned.repository_fullname,
+    github_pull_request_reviews_versioned.pull_request_number,
+    github_pull_request_reviews_versioned.submitted_at AS created_at,
+    github_pull_request_reviews_versioned.user_id,
+    github_pull_request_reviews_versioned.user_login,
+    github_pull_request_reviews_versioned.htmlurl AS html_url,
+        CASE
+            WHEN (github_pull_request_reviews_versioned.state = 'CHANGES_REQUESTED'::text) THEN 'COMMENTED'::text
+            ELSE github_pull_request_reviews_versioned.state
+        END AS state,
+    github_pull_request_reviews_versioned.sum256,
+    github_pull_request_reviews_versioned.installation_id
+   FROM public.github_pull_request_reviews_versioned
+  WITH NO DATA;
+
+
+--
+-- Name: pull_request_reviews_sum256; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX pull_request_reviews_sum256 ON public.pull_request_reviews USING btree (sum256);
+
+
+--
+-- Name: pull_requests; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.pull_requests AS
+ SELECT github_pull_requests_versioned.repository_owner,
+    github_pull_requests_versioned.repository_name,
+    github_pull_requests_versioned.repository_fullname,
+    github_pull_requests_versioned.number,
+    github_pull_requests_versioned.state,
+    github_pull_requests_versioned.title,
+    github_pull_requests_versioned.body,
+    github_pull_requests_versioned.created_at,
+    github_pull_requests_versioned.closed_at,
+    github_pull_requests_versioned.merged_at,
+    github_pull_requests_versioned.updated_at,
+    github_pull_requests_versioned.commits,
+    github_pull_requests_versioned.comments,
+    github_pull_requests_versioned.changed_files,
+    github_pull_requests_versioned.additions,
+    github_pull_requests_versioned.deletions,
+    github_pull_requests_versioned.review_comments AS reviews,
+    github_pull_requests_versioned.user_id,
+    github_pull_requests_versioned.user_login,
+    github_pull_requests_versioned.base_repository_name,
+    github_pull_requests_versioned.base_repository_owner,
+    github_pull_requests_versioned.base_repository_fullname,
+    github_pull_requests_versioned.head_ref,
+    github_pull_requests_versioned.head_sha,
+    github_pull_requests_versioned.merge_commit_sha,
+    github_pull_requests_versioned.htmlurl AS html_url,
+    github_pull_requests_versioned.labels,
+    github_pull_requests_versioned.sum256,
+    github_pull_requests_versioned.installation_id
+   FROM public.github_pull_requests_versioned
+  WITH NO DATA;
+
+
+--
+-- Name: pull_requests_sum256; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX pull_requests_sum256 ON public.pull_requests USING btree (sum256);
+
+
+--
+-- Name: repositories; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.repositories AS
+ SELECT github_repositories_versioned.owner_login AS owner,
+    github_repositories_versioned.name,
+    github_repositories_versioned.fullname,
+    github_repositories_versioned.private,
+    github_repositories_versioned.description,
+    github_repositories_versioned.sum256,
+    github_repositories_versioned.installation_id
+   FROM public.github_repositories_versioned
+  WHERE (github_repositories_versioned.deleted_at IS NULL)
+  WITH NO DATA;
+
+
+--
+-- Name: repositories_sum256; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX repositories_sum256 ON public.repositories USING btree (sum256);
+
+
+--
+-- Name: users; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.users AS
+ SELECT github_users_versioned.login,
+    github_users_versioned.name,
+    github_users_versioned.sum256,
+    github_users_versioned.installation_id
+   FROM public.github_users_versioned
+  WITH NO DATA;
+
+
+--
+-- Name: users_sum256; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX users_sum256 ON public.users USING btree (sum256);
+`
+
+const installationsDown = `
+DROP TABLE public.installations;
+
+
+DROP MATERIALIZED VIEW public.issue_comments;
+DROP MATERIALIZED VIEW public.issues;
+DROP MATERIALIZED VIEW public.owners;
+DROP MATERIALIZED VIEW public.pull_request_comments;
+DROP MATERIALIZED VIEW public.pull_request_reviews;
+DROP MATERIALIZED VIEW public.pull_requests;
+DROP MATERIALIZED VIEW public.repositories;
+DROP MATERIALIZED VIEW public.users;
+
+
+DROP VIEW public.github_commits;
+DROP VIEW public.github_issue_comments;
+DROP VIEW public.github_issues;
+DROP VIEW public.github_organizations;
+DROP VIEW public.github_pull_request_comments;
+DROP VIEW public.github_pull_request_reviews;
+DROP VIEW public.github_pull_requests;
+DROP VIEW public.github_refs;
+DROP VIEW public.github_repositories;
+DROP VIEW public.github_users;
+
+
+ALTER TABLE public.github_commits_versioned DROP COLUMN installation_id;
+ALTER TABLE public.github_issue_comments_versioned DROP COLUMN installation_id;
+ALTER TABLE public.github_issues_versioned DROP COLUMN installation_id;
+ALTER TABLE public.github_organizations_versioned DROP COLUMN installation_id;
+ALTER TABLE public.github_pull_request_comments_versioned DROP COLUMN installation_id;
+ALTER TABLE public.github_pull_request_reviews_versioned DROP COLUMN installation_id;
+ALTER TABLE public.github_pull_requests_versioned DROP COLUMN installation_id;
+ALTER TABLE public.github_refs_versioned DROP COLUMN installation_id;
+ALTER TABLE public.github_repositories_versioned DROP COLUMN installation_id;
+ALTER TABLE public.github_users_versioned DROP COLUMN installation_id;
+ALTER TABLE public.github_commits_history DROP COLUMN installation_id;
+ALTER TABLE public.github_issue_comments_history DROP COLUMN installation_id;
+ALTER TABLE public.github_issues_history DROP COLUMN installation_id;
+ALTER TABLE public.github_organizations_history DROP COLUMN installation_id;
+ALTER TABLE public.github_pull_request_comments_history DROP COLUMN installation_id;
+ALTER TABLE public.github_pull_request_reviews_history DROP COLUMN installation_id;
+ALTER TABLE public.github_pull_requests_history DROP COLUMN installation_id;
+ALTER TABLE public.github_refs_history DROP COLUMN installation_id;
+ALTER TABLE public.github_repositories_history DROP COLUMN installation_id;
+ALTER TABLE public.github_users_history DROP COLUMN installation_id;
+ALTER TABLE public.github_pull_request_pushes DROP COLUMN installation_id;
+ALTER TABLE public.pull_request_state_transitions DROP COLUMN installation_id;
+
+
+--
+-- Name: github_commits; Type: VIEW; Schema: public; Owner: -
+--
+
+CREATE VIEW public.github_commits AS
+ SELECT github_commits_versioned.added,
+    github_commits_versioned.after,
+    github_commits_versioned.author_email,
+    github_commits_versioned.author_name,
+    github_commits_versioned.before,
+    github_commits_versioned.committed_at,
+    github_commits_versioned.committer_email,
+    github_commits_versioned.committer_name,
+    github_commits_versioned.forced,
+    github_commits_versioned.htmlurl,
+    github_commits_versioned.message,
+    github_commits_versioned.modified,
+    github_commits_versioned.ref,
+    github_commits_versioned.removed,
+    github_commits_versioned.repository_name,
+    github_commits_versioned.repository_owner,
+    github_commits_versioned.repository_fullname,
+    github_commits_versioned.sha,
+    github_commits_versioned.tree_id
+   FROM public.github_commits_versioned;
+
+
+--
+-- Name: github_issue_comments; Type: VIEW; Schema: public; Owner: -
+--
+
+CREATE VIEW public.github_issue_comments AS
+ SELECT github_issue_comments_versioned.author_association,
+    github_issue_comments_versioned.body,
+    github_issue_comments_versioned.created_at,
+    github_issue_comments_versioned.htmlurl,
+    github_issue_comments_versioned.id,
+    github_issue_comments_versioned.issue_number,
+    github_issue_comments_versioned.node_id,
+    github_issue_comments_versioned.repository_name,
+    github_issue_comments_versioned.repository_owner,
+    github_issue_comments_versioned.repository_fullname,
+    github_issue_comments_versioned.updated_at,
+    github_issue_comments_versioned.user_id,
+    github_issue_comments_versioned.user_login
+   FROM public.github_issue_comments_versioned
+  WHERE (github_issue_comments_versioned.deleted_at IS NULL);
+
+
+--
+-- Name: github_issues; Type: VIEW; Schema: public; Owner: -
+--
+
+CREATE VIEW public.github_issues AS
+ SELECT github_issues_versioned.assignees,
+    github_issues_versioned.body,
+    github_issues_versioned.closed_at,
+    github_issues_versioned.closed_by_id,
+    github_issues_versioned.closed_by_login,
+    github_issues_versioned.comments,
+    github_issues_versioned.created_at,
+    github_issues_versioned.htmlurl,
+    github_issues_versioned.id,
+    github_issues_versioned.labels,
+    github_issues_versioned.locked,
+    github_issues_versioned.milestone_id,
+    github_issues_versioned.milestone_title,
+    github_issues_versioned.node_id,
+    github_issues_versioned.number,
+    github_issues_versioned.repository_name,
+    github_issues_versioned.repository_owner,
+    github_issues_versioned.repository_fullname,
+    github_issues_versioned.state,
+    github_issues_versioned.title,
+    github_issues_versioned.updated_at,
+    github_issues_versioned.user_id,
+    github_issues_versioned.user_login
+   FROM public.github_issues_versioned
+  WHERE (github_issues_versioned.deleted_at IS NULL);
+
+
+--
+-- Name: github_organizations; Type: VIEW; Schema: public; Owner: -
+--
+
+CREATE VIEW public.github_organizations AS
+ SELECT github_organizations_versioned.avatar_url,
+    github_organizations_versioned.collaborators,
+    github_organizations_versioned.created_at,
+    github_organizations_versioned.description,
+    github_organizations_versioned.email,
+    github_organizations_versioned.htmlurl,
+    github_organizations_versioned.id,
+    github_organizations_versioned.login,
+    github_organizations_versioned.name,
+    github_organizations_versioned.node_id,
+    github_organizations_versioned.owned_private_repos,
+    github_organizations_versioned.public_repos,
+    github_organizations_versioned.total_private_repos,
+    github_organizations_versioned.updated_at
+   FROM public.github_organizations_versioned
+  WHERE (github_organizations_versioned.deleted_at IS NULL);
+
+
+--
+-- Name: github_pull_request_comments; Type: VIEW; Schema: public; Owner: -
+--
+
+CREATE VIEW public.github_pull_request_comments AS
+ SELECT github_pull_request_comments_versioned.author_association,
+    github_pull_request_comments_versioned.body,
+    github_pull_request_comments_versioned.commit_id,
+    github_pull_request_comments_versioned.created_at,
+    github_pull_request_comments_versioned.diff_hunk,
+    github_pull_request_comments_versioned.htmlurl,
+    github_pull_request_comments_versioned.id,
+    github_pull_request_comments_versioned.in_reply_to,
+    github_pull_request_comments_versioned.node_id,
+    github_pull_request_comments_versioned.original_commit_id,
+    github_pull_request_comments_versioned.original_position,
+    github_pull_request_comments_versioned.path,
+    github_pull_request_comments_versioned."position",
+    github_pull_request_comments_versioned.pull_request_number,
+    github_pull_request_comments_versioned.pull_request_review_id,
+    github_pull_request_comments_versioned.repository_name,
+    github_pull_request_comments_versioned.repository_owner,
+    github_pull_request_comments_versioned.repository_fullname,
+    github_pull_request_comments_versioned.updated_at,
+    github_pull_request_comments_versioned.user_id,
+    github_pull_request_comments_versioned.user_login
+   FROM public.github_pull_request_comments_versioned
+  WHERE (github_pull_request_comments_versioned.deleted_at IS NULL);
+
+
+--
+-- Name: github_pull_request_reviews; Type: VIEW; Schema: public; Owner: -
+--
+
+CREATE VIEW public.github_pull_request_reviews AS
+ SELECT github_pull_request_reviews_versioned.body,
+    github_pull_request_reviews_versioned.commit_id,
+    github_pull_request_reviews_versioned.htmlurl,
+    github_pull_request_reviews_versioned.id,
+    github_pull_request_reviews_versioned.node_id,
+    github_pull_request_reviews_versioned.pull_request_number,
+    github_pull_request_reviews_versioned.repository_name,
+    github_pull_request_reviews_versioned.repository_owner,
+    github_pull_request_reviews_versioned.repository_fullname,
+    github_pull_request_reviews_versioned.state,
+    github_pull_request_reviews_versioned.submitted_at,
+    github_pull_request_reviews_versioned.user_id,
+    github_pull_request_reviews_versioned.user_login
+   FROM public.github_pull_request_reviews_versioned;
+
+
+--
+-- Name: github_pull_requests; Type: VIEW; Schema: public; Owner: -
+--
+
+CREATE VIEW public.github_pull_requests AS
+ SELECT github_pull_requests_versioned.additions,
+    github_pull_requests_versioned.assignees,
+    github_pull_requests_versioned.author_association,
+    github_pull_requests_versioned.base_ref,
+    github_pull_requests_versioned.base_repository_name,
+    github_pull_requests_versioned.base_repository_owner,
+    github_pull_requests_versioned.base_repository_fullname,
+    github_pull_requests_versioned.base_sha,
+    github_pull_requests_versioned.base_user,
+    github_pull_requests_versioned.body,
+    github_pull_requests_versioned.changed_files,
+    github_pull_requests_versioned.closed_at,
+    github_pull_requests_versioned.comments,
+    github_pull_requests_versioned.commits,
+    github_pull_requests_versioned.created_at,
+    github_pull_requests_versioned.deletions,
+    github_pull_requests_versioned.head_ref,
+    github_pull_requests_versioned.head_repository_name,
+    github_pull_requests_versioned.head_repository_owner,
+    github_pull_requests_versioned.head_repository_fullname,
+    github_pull_requests_versioned.head_sha,
+    github_pull_requests_versioned.head_user,
+    github_pull_requests_versioned.htmlurl,
+    github_pull_requests_versioned.id,
+    github_pull_requests_versioned.labels,
+    github_pull_requests_versioned.maintainer_can_modify,
+    github_pull_requests_versioned.merge_commit_sha,
+    github_pull_requests_versioned.mergeable,
+    github_pull_requests_versioned.merged,
+    github_pull_requests_versioned.merged_at,
+    github_pull_requests_versioned.merged_by_id,
+    github_pull_requests_versioned.merged_by_login,
+    github_pull_requests_versioned.milestone_id,
+    github_pull_requests_versioned.milestone_title,
+    github_pull_requests_versioned.node_id,
+    github_pull_requests_versioned.number,
+    github_pull_requests_versioned.repository_name,
+    github_pull_requests_versioned.repository_owner,
+    github_pull_requests_versioned.repository_fullname,
+    github_pull_requests_versioned.review_comments,
+    github_pull_requests_versioned.state,
+    github_pull_requests_versioned.title,
+    github_pull_requests_versioned.updated_at,
+    github_pull_requests_versioned.user_id,
+    github_pull_requests_versioned.user_login
+   FROM public.github_pull_requests_versioned;
+
+
+--
+-- Name: github_refs; Type: VIEW; Schema: public; Owner: -
+--
+
+CREATE VIEW public.github_refs AS
+ SELECT github_refs_versioned.created_at,
+    github_refs_versioned.created_by_id,
+    github_refs_versioned.created_by_login,
+    github_refs_versioned.deleted_at,
+    github_refs_versioned.deleted_by_id,
+    github_refs_versioned.deleted_by_login,
+    github_refs_versioned.master_branch,
+    github_refs_versioned.pusher_type,
+    github_refs_versioned.ref,
+    github_refs_versioned.ref_type,
+    github_refs_versioned.repository_name,
+    github_refs_versioned.repository_owner,
+    github_refs_versioned.repository_fullname
+   FROM public.github_refs_versioned;
+
+
+--
+-- Name: github_repositories; Type: VIEW; Schema: public; Owner: -
+--
+
+CREATE VIEW public.github_repositories AS
+ SELECT github_repositories_versioned.allow_merge_commit,
+    github_repositories_versioned.allow_rebase_merge,
+    github_repositories_versioned.allow_squash_merge,
+    github_repositories_versioned.archived,
+    github_repositories_versioned.clone_url,
+    github_repositories_versioned.created_at,
+    github_repositories_versioned.default_branch,
+    github_repositories_versioned.description,
+    github_repositories_versioned.disabled,
+    github_repositories_versioned.fork,
+    github_repositories_versioned.forks_count,
+    github_repositories_versioned.fullname,
+    github_repositories_versioned.has_issues,
+    github_repositories_versioned.has_wiki,
+    github_repositories_versioned.homepage,
+    github_repositories_versioned.htmlurl,
+    github_repositories_versioned.id,
+    github_repositories_versioned.language,
+    github_repositories_versioned.name,
+    github_repositories_versioned.node_id,
+    github_repositories_versioned.open_issues_count,
+    github_repositories_versioned.owner_id,
+    github_repositories_versioned.owner_login,
+    github_repositories_versioned.owner_type,
+    github_repositories_versioned.private,
+    github_repositories_versioned.pushed_at,
+    github_repositories_versioned.sshurl,
+    github_repositories_versioned.stargazers_count,
+    github_repositories_versioned.topics,
+    github_repositories_versioned.updated_at,
+    github_repositories_versioned.watchers_count
+   FROM public.github_repositories_versioned
+  WHERE (github_repositories_versioned.deleted_at IS NULL);
+
+
+--
+-- Name: github_users; Type: VIEW; Schema: public; Owner: -
+--
+
+CREATE VIEW public.github_users AS
+ SELECT github_users_versioned.avatar_url,
+    github_users_versioned.bio,
+    github_users_versioned.company,
+    github_users_versioned.created_at,
+    github_users_versioned.email,
+    github_users_versioned.followers,
+    github_users_versioned.following,
+    github_users_versioned.hireable,
+    github_users_versioned.htmlurl,
+    github_users_versioned.id,
+    github_users_versioned.location,
+    github_users_versioned.login,
+    github_users_versioned.name,
+    github_users_versioned.node_id,
+    github_users_versioned.organization_id,
+    github_users_versioned.organization_login,
+    github_users_versioned.owned_private_repos,
+    github_users_versioned.private_gists,
+    github_users_versioned.public_gists,
+    github_users_versioned.public_repos,
+    github_users_versioned.total_private_repos,
+    github_users_versioned.updated_at
+   FROM public.github_users_versioned;
+
+
+--
+-- Name: issue_comments; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.issue_comments AS
+ SELECT c.repository_owner,
+    c.repository_name,
+    c.repository_fullname,
+    c.issue_number,
+    c.created_at,
+    c.body,
+    c.user_id,
+    c.user_login,
+    c.htmlurl AS html_url,
+    c.sum256
+   FROM (public.github_issue_comments_versioned c
+     JOIN public.github_issues_versioned i ON (((i.repository_owner = c.repository_owner) AND (i.repository_name = c.repository_name) AND (i.number = c.issue_number))))
+  WHERE ((c.deleted_at IS NULL) AND (i.deleted_at IS NULL))
+  WITH NO DATA;
+
+
+--
+-- Name: issue_comments_sum256; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX issue_comments_sum256 ON public.issue_comments USING btree (sum256);
+
+
+--
+-- Name: issues; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.issues AS
+ SELECT github_issues_versioned.repository_owner,
+    github_issues_versioned.repository_name,
+    github_issues_versioned.repository_fullname,
+    github_issues_versioned.number,
+    github_issues_versioned.state,
+    github_issues_versioned.title,
+    github_issues_versioned.body,
+    github_issues_versioned.created_at,
+    github_issues_versioned.closed_at,
+    github_issues_versioned.updated_at,
+    github_issues_versioned.comments,
+    github_issues_versioned.user_id,
+    github_issues_versioned.user_login,
+    github_issues_versioned.htmlurl AS html_url,
+    github_issues_versioned.labels,
+    github_issues_versioned.sum256
+   FROM public.github_issues_versioned
+  WHERE (github_issues_versioned.deleted_at IS NULL)
+  WITH NO DATA;
+
+
+--
+-- Name: issues_sum256; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX issues_sum256 ON public.issues USING btree (sum256);
+
+
+--
+-- Name: owners; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.owners AS
+ SELECT github_organizations_versioned.login,
+    github_organizations_versioned.name,
+    github_organizations_versioned.sum256
+   FROM public.github_organizations_versioned
+  WHERE (github_organizations_versioned.deleted_at IS NULL)
+  WITH NO DATA;
+
+
+--
+-- Name: owners_sum256; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX owners_sum256 ON public.owners USING btree (sum256);
+
+
+--
+-- Name: pull_request_comments; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.pull_request_comments AS
+ SELECT c.repository_owner,
+    c.repository_name,
+    c.repository_fullname,
+    c.issue_number AS pull_request_number,
+    c.created_at,
+    c.body,
+    c.user_id,
+    c.user_login,
+    c.htmlurl AS html_url,
+    c.sum256
+   FROM (public.github_issue_comments_versioned c
+     JOIN public.github_pull_requests_versioned p ON (((p.repository_owner = c.repository_owner) AND (p.repository_name = c.repository_name) AND (p.number = c.issue_number))))
+  WHERE (c.deleted_at IS NULL)
+UNION
+ SELECT c.repository_owner,
+    c.repository_name,
+    c.repository_fullname,
+    c.pull_request_number,
+    c.created_at,
+    c.body,
+    c.user_id,
+    c.user_login,
+    c.htmlurl AS html_url,
+    c.sum256
+   FROM public.github_pull_request_comments_versioned c
+  WHERE (c.deleted_at IS NULL)
+UNION
+ SELECT c.repository_owner,
+    c.repository_name,
+    c.repository_fullname,
+    c.pull_request_number,
+    c.submitted_at AS created_at,
+    c.body,
+    c.user_id,
+    c.user_login,
+    c.htmlurl AS html_url,
+    c.sum256
+   FROM public.github_pull_request_reviews_versioned c
+  WHERE (c.body <> ''::text)
+  WITH NO DATA;
+
+
+--
+-- Name: pull_request_comments_sum256; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX pull_request_comments_sum256 ON public.pull_request_comments USING btree (sum256);
+
+
+--
+-- Name: pull_request_reviews; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.pull_request_reviews AS
+ SELECT github_pull_request_reviews_versioned.repository_owner,
+    github_pull_request_reviews_versioned.repository_name,
+    github_pull_request_reviews_versioned.repository_fullname,
+    github_pull_request_reviews_versioned.pull_request_number,
+    github_pull_request_reviews_versioned.submitted_at AS created_at,
+    github_pull_request_reviews_versioned.user_id,
+    github_pull_request_reviews_versioned.user_login,
+    github_pull_request_reviews_versioned.htmlurl AS html_url,
+        CASE
+            WHEN (github_pull_request_reviews_versioned.state = 'CHANGES_REQUESTED'::text) THEN 'COMMENTED'::text
+            ELSE github_pull_request_reviews_versioned.state
+        END AS state,
+    github_pull_request_reviews_versioned.sum256
+   FROM public.github_pull_request_reviews_versioned
+  WITH NO DATA;
+
+
+--
+-- Name: pull_request_reviews_sum256; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX pull_request_reviews_sum256 ON public.pull_request_reviews USING btree (sum256);
+
+
+--
+-- Name: pull_requests; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.pull_requests AS
+ SELECT github_pull_requests_versioned.repository_owner,
+    github_pull_requests_versioned.repository_name,
+    github_pull_requests_versioned.repository_fullname,
+    github_pull_requests_versioned.number,
+    github_pull_requests_versioned.state,
+    github_pull_requests_versioned.title,
+    github_pull_requests_versioned.body,
+    github_pull_requests_versioned.created_at,
+    github_pull_requests_versioned.closed_at,
+    github_pull_requests_versioned.merged_at,
+    github_pull_requests_versioned.updated_at,
+    github_pull_requests_versioned.commits,
+    github_pull_requests_versioned.comments,
+    github_pull_requests_versioned.changed_files,
+    github_pull_requests_versioned.additions,
+    github_pull_requests_versioned.deletions,
+    github_pull_requests_versioned.review_comments AS reviews,
+    github_pull_requests_versioned.user_id,
+    github_pull_requests_versioned.user_login,
+    github_pull_requests_versioned.base_repository_name,
+    github_pull_requests_versioned.base_repository_owner,
+    github_pull_requests_versioned.base_repository_fullname,
+    github_pull_requests_versioned.head_ref,
+    github_pull_requests_versioned.head_sha,
+    github_pull_requests_versioned.merge_commit_sha,
+    github_pull_requests_versioned.htmlurl AS html_url,
+    github_pull_requests_versioned.labels,
+    github_pull_requests_versioned.sum256
+   FROM public.github_pull_requests_versioned
+  WITH NO DATA;
+
+
+--
+-- Name: pull_requests_sum256; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX pull_requests_sum256 ON public.pull_requests USING btree (sum256);
+
+
+--
+-- Name: repositories; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.repositories AS
+ SELECT github_repositories_versioned.owner_login AS owner,
+    github_repositories_versioned.name,
+    github_repositories_versioned.fullname,
+    github_repositories_versioned.private,
+    github_repositories_versioned.description,
+    github_repositories_versioned.sum256
+   FROM public.github_repositories_versioned
+  WHERE (github_repositories_versioned.deleted_at IS NULL)
+  WITH NO DATA;
+
+
+--
+-- Name: repositories_sum256; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX repositories_sum256 ON public.repositories USING btree (sum256);
+
+
+--
+-- Name: users; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.users AS
+ SELECT github_users_versioned.login,
+    github_users_versioned.name,
+    github_users_versioned.sum256
+   FROM public.github_users_versioned
+  WITH NO DATA;
+
+
+--
+-- Name: users_sum256; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX users_sum256 ON public.users USING btree (sum256);
+`
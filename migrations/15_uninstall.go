@@ -0,0 +1,33 @@
+package migrations
+
+// uninstall records repositories removed from installations and when the data
+// of uninstalled installations and removed repositories was purged.
+const uninstallUp = `
+ALTER TABLE public.installations ADD COLUMN purged_at timestamp with time zone;
+
+
+--
+-- Name: removed_repositories; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.removed_repositories (
+    installation_id bigint NOT NULL,
+    repository_id bigint NOT NULL,
+    repository_fullname text NOT NULL,
+    removed_at timestamp with time zone NOT NULL,
+    purged_at timestamp with time zone
+);
+
+
+--
+-- Name: removed_repositories removed_repositories_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.removed_repositories
+    ADD CONSTRAINT removed_repositories_pkey PRIMARY KEY (installation_id, repository_id);
+`
+
+const uninstallDown = `
+DROP TABLE public.removed_repositories;
+ALTER TABLE public.installations DROP COLUMN purged_at;
+`
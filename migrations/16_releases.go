@@ -0,0 +1,170 @@
+package migrations
+
+// releases records releases of repositories (release events) and maps merged pull requests
+// to the first release published from their base branch after the merge.
+const releasesUp = `
+--
+-- Name: github_releases_history; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_releases_history (
+    version integer NOT NULL,
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    author_id bigint,
+    author_login text,
+    body text,
+    created_at timestamp with time zone,
+    draft boolean,
+    htmlurl text,
+    id bigint,
+    name text,
+    node_id text,
+    prerelease boolean,
+    published_at timestamp with time zone,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL,
+    tag_name text NOT NULL,
+    target_commitish text,
+    deleted_at timestamp with time zone,
+    installation_id bigint
+);
+
+--
+-- Name: github_releases_versioned; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_releases_versioned (
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    author_id bigint,
+    author_login text,
+    body text,
+    created_at timestamp with time zone,
+    draft boolean,
+    htmlurl text,
+    id bigint,
+    name text,
+    node_id text,
+    prerelease boolean,
+    published_at timestamp with time zone,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL,
+    tag_name text NOT NULL,
+    target_commitish text,
+    deleted_at timestamp with time zone,
+    installation_id bigint
+);
+
+--
+-- Name: github_releases; Type: VIEW; Schema: public; Owner: -
+--
+
+CREATE VIEW public.github_releases AS
+ SELECT github_releases_versioned.author_id,
+    github_releases_versioned.author_login,
+    github_releases_versioned.body,
+    github_releases_versioned.created_at,
+    github_releases_versioned.draft,
+    github_releases_versioned.htmlurl,
+    github_releases_versioned.id,
+    github_releases_versioned.name,
+    github_releases_versioned.node_id,
+    github_releases_versioned.prerelease,
+    github_releases_versioned.published_at,
+    github_releases_versioned.repository_name,
+    github_releases_versioned.repository_owner,
+    github_releases_versioned.repository_fullname,
+    github_releases_versioned.tag_name,
+    github_releases_versioned.target_commitish,
+    github_releases_versioned.installation_id
+   FROM public.github_releases_versioned
+  WHERE (github_releases_versioned.deleted_at IS NULL);
+
+--
+-- Name: releases; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.releases AS
+ SELECT github_releases_versioned.repository_owner,
+    github_releases_versioned.repository_name,
+    github_releases_versioned.repository_fullname,
+    github_releases_versioned.id,
+    github_releases_versioned.tag_name,
+    github_releases_versioned.name,
+    github_releases_versioned.target_commitish,
+    github_releases_versioned.prerelease,
+    github_releases_versioned.created_at,
+    github_releases_versioned.published_at,
+    github_releases_versioned.author_id,
+    github_releases_versioned.author_login,
+    github_releases_versioned.htmlurl AS html_url,
+    github_releases_versioned.sum256,
+    github_releases_versioned.installation_id
+   FROM public.github_releases_versioned
+  WHERE ((github_releases_versioned.deleted_at IS NULL) AND (NOT github_releases_versioned.draft))
+  WITH NO DATA;
+
+--
+-- Name: pull_request_releases; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.pull_request_releases AS
+ SELECT DISTINCT ON (p.sum256) p.repository_owner,
+    p.repository_name,
+    p.repository_fullname,
+    p.number,
+    p.merged_at,
+    r.id AS release_id,
+    r.tag_name AS release_tag_name,
+    r.name AS release_name,
+    r.published_at AS release_published_at,
+    p.sum256,
+    p.installation_id
+   FROM (public.github_pull_requests_versioned p
+     JOIN public.github_releases_versioned r ON (((r.repository_fullname = p.repository_fullname) AND (r.target_commitish = p.base_ref) AND (r.published_at >= p.merged_at))))
+  WHERE (p.merged AND (r.deleted_at IS NULL) AND (NOT r.draft) AND (NOT r.prerelease))
+  ORDER BY p.sum256, r.published_at
+  WITH NO DATA;
+
+--
+-- Name: github_releases_versioned releases_versioned_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.github_releases_versioned
+    ADD CONSTRAINT releases_versioned_pkey PRIMARY KEY (sum256);
+
+--
+-- Name: releases_history_sum256_version; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX releases_history_sum256_version ON public.github_releases_history USING btree (sum256, version);
+
+--
+-- Name: releases_versions; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX releases_versions ON public.github_releases_versioned USING btree (versions);
+
+--
+-- Name: releases_sum256; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX releases_sum256 ON public.releases USING btree (sum256);
+
+--
+-- Name: pull_request_releases_sum256; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX pull_request_releases_sum256 ON public.pull_request_releases USING btree (sum256);
+`
+
+const releasesDown = `
+DROP MATERIALIZED VIEW public.pull_request_releases;
+DROP MATERIALIZED VIEW public.releases;
+DROP VIEW public.github_releases;
+DROP TABLE public.github_releases_versioned;
+DROP TABLE public.github_releases_history;
+`
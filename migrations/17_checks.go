@@ -0,0 +1,346 @@
+package migrations
+
+// checks records CI results (check runs, check suites and commit statuses) of commits
+// and links completed checks to pull requests by their head commits.
+const checksUp = `
+--
+-- Name: github_check_runs_history; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_check_runs_history (
+    version integer NOT NULL,
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    app_id bigint,
+    app_name text,
+    check_suite_id bigint,
+    completed_at timestamp with time zone,
+    conclusion text,
+    details_url text,
+    external_id text,
+    head_sha text NOT NULL,
+    htmlurl text,
+    id bigint,
+    name text NOT NULL,
+    node_id text,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL,
+    started_at timestamp with time zone,
+    status text,
+    installation_id bigint
+);
+
+
+--
+-- Name: github_check_runs_versioned; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_check_runs_versioned (
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    app_id bigint,
+    app_name text,
+    check_suite_id bigint,
+    completed_at timestamp with time zone,
+    conclusion text,
+    details_url text,
+    external_id text,
+    head_sha text NOT NULL,
+    htmlurl text,
+    id bigint,
+    name text NOT NULL,
+    node_id text,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL,
+    started_at timestamp with time zone,
+    status text,
+    installation_id bigint
+);
+
+
+--
+-- Name: github_check_runs; Type: VIEW; Schema: public; Owner: -
+--
+
+CREATE VIEW public.github_check_runs AS
+ SELECT github_check_runs_versioned.app_id,
+    github_check_runs_versioned.app_name,
+    github_check_runs_versioned.check_suite_id,
+    github_check_runs_versioned.completed_at,
+    github_check_runs_versioned.conclusion,
+    github_check_runs_versioned.details_url,
+    github_check_runs_versioned.external_id,
+    github_check_runs_versioned.head_sha,
+    github_check_runs_versioned.htmlurl,
+    github_check_runs_versioned.id,
+    github_check_runs_versioned.name,
+    github_check_runs_versioned.node_id,
+    github_check_runs_versioned.repository_name,
+    github_check_runs_versioned.repository_owner,
+    github_check_runs_versioned.repository_fullname,
+    github_check_runs_versioned.started_at,
+    github_check_runs_versioned.status,
+    github_check_runs_versioned.installation_id
+   FROM public.github_check_runs_versioned;
+
+--
+-- Name: github_check_suites_history; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_check_suites_history (
+    version integer NOT NULL,
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    after text,
+    app_id bigint,
+    app_name text,
+    before text,
+    conclusion text,
+    head_branch text,
+    head_sha text NOT NULL,
+    id bigint,
+    node_id text,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL,
+    status text,
+    installation_id bigint
+);
+
+
+--
+-- Name: github_check_suites_versioned; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_check_suites_versioned (
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    after text,
+    app_id bigint,
+    app_name text,
+    before text,
+    conclusion text,
+    head_branch text,
+    head_sha text NOT NULL,
+    id bigint,
+    node_id text,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL,
+    status text,
+    installation_id bigint
+);
+
+
+--
+-- Name: github_check_suites; Type: VIEW; Schema: public; Owner: -
+--
+
+CREATE VIEW public.github_check_suites AS
+ SELECT github_check_suites_versioned.after,
+    github_check_suites_versioned.app_id,
+    github_check_suites_versioned.app_name,
+    github_check_suites_versioned.before,
+    github_check_suites_versioned.conclusion,
+    github_check_suites_versioned.head_branch,
+    github_check_suites_versioned.head_sha,
+    github_check_suites_versioned.id,
+    github_check_suites_versioned.node_id,
+    github_check_suites_versioned.repository_name,
+    github_check_suites_versioned.repository_owner,
+    github_check_suites_versioned.repository_fullname,
+    github_check_suites_versioned.status,
+    github_check_suites_versioned.installation_id
+   FROM public.github_check_suites_versioned;
+
+--
+-- Name: github_statuses_history; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_statuses_history (
+    version integer NOT NULL,
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    context text NOT NULL,
+    created_at timestamp with time zone,
+    description text,
+    id bigint,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL,
+    sha text NOT NULL,
+    state text NOT NULL,
+    target_url text,
+    updated_at timestamp with time zone,
+    installation_id bigint
+);
+
+
+--
+-- Name: github_statuses_versioned; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_statuses_versioned (
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    context text NOT NULL,
+    created_at timestamp with time zone,
+    description text,
+    id bigint,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL,
+    sha text NOT NULL,
+    state text NOT NULL,
+    target_url text,
+    updated_at timestamp with time zone,
+    installation_id bigint
+);
+
+
+--
+-- Name: github_statuses; Type: VIEW; Schema: public; Owner: -
+--
+
+CREATE VIEW public.github_statuses AS
+ SELECT github_statuses_versioned.context,
+    github_statuses_versioned.created_at,
+    github_statuses_versioned.description,
+    github_statuses_versioned.id,
+    github_statuses_versioned.repository_name,
+    github_statuses_versioned.repository_owner,
+    github_statuses_versioned.repository_fullname,
+    github_statuses_versioned.sha,
+    github_statuses_versioned.state,
+    github_statuses_versioned.target_url,
+    github_statuses_versioned.updated_at,
+    github_statuses_versioned.installation_id
+   FROM public.github_statuses_versioned;
+
+--
+-- Name: pull_request_checks; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.pull_request_checks AS
+ SELECT p.repository_owner,
+    p.repository_name,
+    p.repository_fullname,
+    p.number AS pull_request_number,
+    p.sum256 AS pull_request_sum256,
+    c.kind,
+    c.head_sha,
+    c.name,
+    c.conclusion,
+    c.started_at,
+    c.completed_at,
+    c.app_name,
+    c.sum256,
+    p.installation_id
+   FROM (public.github_pull_requests_versioned p
+     JOIN ( SELECT 'check_run'::text AS kind,
+            r.repository_fullname,
+            r.head_sha,
+            r.name,
+            r.conclusion,
+            r.started_at,
+            r.completed_at,
+            r.app_name,
+            r.sum256
+           FROM public.github_check_runs_versioned r
+          WHERE (r.status = 'completed'::text)
+        UNION ALL
+         SELECT 'status'::text AS kind,
+            s.repository_fullname,
+            s.sha AS head_sha,
+            s.context AS name,
+            s.state AS conclusion,
+            COALESCE(( SELECT max(pending.created_at) AS max
+                   FROM public.github_statuses_versioned pending
+                  WHERE ((pending.repository_fullname = s.repository_fullname) AND (pending.sha = s.sha) AND (pending.context = s.context) AND (pending.state = 'pending'::text) AND (pending.created_at <= s.created_at))), s.created_at) AS started_at,
+            s.created_at AS completed_at,
+            NULL::text AS app_name,
+            s.sum256
+           FROM public.github_statuses_versioned s
+          WHERE (s.state <> 'pending'::text)) c ON (((c.repository_fullname = p.repository_fullname) AND ((c.head_sha = p.head_sha) OR (EXISTS ( SELECT 1
+           FROM public.github_pull_request_pushes pp
+          WHERE (((pp.pull_request_sum256)::text = (p.sum256)::text) AND (pp.after = c.head_sha))))))))
+  WITH NO DATA;
+
+--
+-- Name: github_check_runs_versioned check_runs_versioned_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.github_check_runs_versioned
+    ADD CONSTRAINT check_runs_versioned_pkey PRIMARY KEY (sum256);
+
+--
+-- Name: github_check_suites_versioned check_suites_versioned_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.github_check_suites_versioned
+    ADD CONSTRAINT check_suites_versioned_pkey PRIMARY KEY (sum256);
+
+--
+-- Name: github_statuses_versioned statuses_versioned_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.github_statuses_versioned
+    ADD CONSTRAINT statuses_versioned_pkey PRIMARY KEY (sum256);
+
+--
+-- Name: check_runs_history_sum256_version; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX check_runs_history_sum256_version ON public.github_check_runs_history USING btree (sum256, version);
+
+--
+-- Name: check_runs_versions; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX check_runs_versions ON public.github_check_runs_versioned USING btree (versions);
+
+--
+-- Name: check_suites_history_sum256_version; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX check_suites_history_sum256_version ON public.github_check_suites_history USING btree (sum256, version);
+
+--
+-- Name: check_suites_versions; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX check_suites_versions ON public.github_check_suites_versioned USING btree (versions);
+
+--
+-- Name: statuses_history_sum256_version; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX statuses_history_sum256_version ON public.github_statuses_history USING btree (sum256, version);
+
+--
+-- Name: statuses_versions; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX statuses_versions ON public.github_statuses_versioned USING btree (versions);
+
+--
+-- Name: pull_request_checks_sum256; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX pull_request_checks_sum256 ON public.pull_request_checks USING btree (pull_request_sum256, kind, sum256);
+`
+
+const checksDown = `
+DROP MATERIALIZED VIEW public.pull_request_checks;
+DROP VIEW public.github_statuses;
+DROP TABLE public.github_statuses_versioned;
+DROP TABLE public.github_statuses_history;
+DROP VIEW public.github_check_suites;
+DROP TABLE public.github_check_suites_versioned;
+DROP TABLE public.github_check_suites_history;
+DROP VIEW public.github_check_runs;
+DROP TABLE public.github_check_runs_versioned;
+DROP TABLE public.github_check_runs_history;
+`
@@ -0,0 +1,266 @@
+package migrations
+
+// deployments records deployments of repositories with their status transitions and maps merged pull requests
+// to the first successful deployment of their base branch (or merge commit) to every environment.
+const deploymentsUp = `
+--
+-- Name: github_deployment_statuses_history; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_deployment_statuses_history (
+    version integer NOT NULL,
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    created_at timestamp with time zone,
+    creator_id bigint,
+    creator_login text,
+    deployment_id bigint NOT NULL,
+    description text,
+    environment text,
+    id bigint,
+    node_id text,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL,
+    state text NOT NULL,
+    target_url text,
+    updated_at timestamp with time zone,
+    installation_id bigint
+);
+
+
+--
+-- Name: github_deployment_statuses_versioned; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_deployment_statuses_versioned (
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    created_at timestamp with time zone,
+    creator_id bigint,
+    creator_login text,
+    deployment_id bigint NOT NULL,
+    description text,
+    environment text,
+    id bigint,
+    node_id text,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL,
+    state text NOT NULL,
+    target_url text,
+    updated_at timestamp with time zone,
+    installation_id bigint
+);
+
+
+--
+-- Name: github_deployment_statuses; Type: VIEW; Schema: public; Owner: -
+--
+
+CREATE VIEW public.github_deployment_statuses AS
+ SELECT github_deployment_statuses_versioned.created_at,
+    github_deployment_statuses_versioned.creator_id,
+    github_deployment_statuses_versioned.creator_login,
+    github_deployment_statuses_versioned.deployment_id,
+    github_deployment_statuses_versioned.description,
+    github_deployment_statuses_versioned.environment,
+    github_deployment_statuses_versioned.id,
+    github_deployment_statuses_versioned.node_id,
+    github_deployment_statuses_versioned.repository_name,
+    github_deployment_statuses_versioned.repository_owner,
+    github_deployment_statuses_versioned.repository_fullname,
+    github_deployment_statuses_versioned.state,
+    github_deployment_statuses_versioned.target_url,
+    github_deployment_statuses_versioned.updated_at,
+    github_deployment_statuses_versioned.installation_id
+   FROM public.github_deployment_statuses_versioned;
+
+--
+-- Name: github_deployments_history; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_deployments_history (
+    version integer NOT NULL,
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    created_at timestamp with time zone,
+    creator_id bigint,
+    creator_login text,
+    description text,
+    environment text,
+    id bigint,
+    node_id text,
+    payload jsonb,
+    ref text,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL,
+    sha text NOT NULL,
+    task text,
+    updated_at timestamp with time zone,
+    installation_id bigint
+);
+
+
+--
+-- Name: github_deployments_versioned; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_deployments_versioned (
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    created_at timestamp with time zone,
+    creator_id bigint,
+    creator_login text,
+    description text,
+    environment text,
+    id bigint,
+    node_id text,
+    payload jsonb,
+    ref text,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL,
+    sha text NOT NULL,
+    task text,
+    updated_at timestamp with time zone,
+    installation_id bigint
+);
+
+
+--
+-- Name: github_deployments; Type: VIEW; Schema: public; Owner: -
+--
+
+CREATE VIEW public.github_deployments AS
+ SELECT github_deployments_versioned.created_at,
+    github_deployments_versioned.creator_id,
+    github_deployments_versioned.creator_login,
+    github_deployments_versioned.description,
+    github_deployments_versioned.environment,
+    github_deployments_versioned.id,
+    github_deployments_versioned.node_id,
+    github_deployments_versioned.payload,
+    github_deployments_versioned.ref,
+    github_deployments_versioned.repository_name,
+    github_deployments_versioned.repository_owner,
+    github_deployments_versioned.repository_fullname,
+    github_deployments_versioned.sha,
+    github_deployments_versioned.task,
+    github_deployments_versioned.updated_at,
+    github_deployments_versioned.installation_id
+   FROM public.github_deployments_versioned;
+
+--
+-- Name: deployments; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.deployments AS
+ SELECT d.repository_owner,
+    d.repository_name,
+    d.repository_fullname,
+    d.id,
+    d.sha,
+    d.ref,
+    d.task,
+    d.environment,
+    d.created_at,
+    d.creator_id AS author_id,
+    d.creator_login AS author_login,
+    s.state,
+    s.finished_at,
+    d.sum256,
+    d.installation_id
+   FROM (public.github_deployments_versioned d
+     LEFT JOIN LATERAL ( SELECT st.state,
+            st.created_at AS finished_at
+           FROM public.github_deployment_statuses_versioned st
+          WHERE ((st.repository_fullname = d.repository_fullname) AND (st.deployment_id = d.id) AND (st.state = ANY (ARRAY['success'::text, 'failure'::text, 'error'::text])))
+          ORDER BY st.created_at
+         LIMIT 1) s ON (true))
+  WITH NO DATA;
+
+--
+-- Name: pull_request_deployments; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.pull_request_deployments AS
+ SELECT DISTINCT ON (p.sum256, d.environment) p.repository_owner,
+    p.repository_name,
+    p.repository_fullname,
+    p.number,
+    p.merged_at,
+    d.id AS deployment_id,
+    d.environment,
+    s.created_at AS deployed_at,
+    p.sum256,
+    p.installation_id
+   FROM ((public.github_pull_requests_versioned p
+     JOIN public.github_deployments_versioned d ON (((d.repository_fullname = p.repository_fullname) AND ((d.sha = p.merge_commit_sha) OR (d.ref = p.base_ref)) AND (d.created_at >= p.merged_at))))
+     JOIN public.github_deployment_statuses_versioned s ON (((s.repository_fullname = d.repository_fullname) AND (s.deployment_id = d.id) AND (s.state = 'success'::text))))
+  WHERE p.merged
+  ORDER BY p.sum256, d.environment, s.created_at
+  WITH NO DATA;
+
+--
+-- Name: github_deployment_statuses_versioned deployment_statuses_versioned_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.github_deployment_statuses_versioned
+    ADD CONSTRAINT deployment_statuses_versioned_pkey PRIMARY KEY (sum256);
+
+--
+-- Name: github_deployments_versioned deployments_versioned_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.github_deployments_versioned
+    ADD CONSTRAINT deployments_versioned_pkey PRIMARY KEY (sum256);
+
+--
+-- Name: deployment_statuses_history_sum256_version; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX deployment_statuses_history_sum256_version ON public.github_deployment_statuses_history USING btree (sum256, version);
+
+--
+-- Name: deployment_statuses_versions; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX deployment_statuses_versions ON public.github_deployment_statuses_versioned USING btree (versions);
+
+--
+-- Name: deployments_history_sum256_version; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX deployments_history_sum256_version ON public.github_deployments_history USING btree (sum256, version);
+
+--
+-- Name: deployments_sum256; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX deployments_sum256 ON public.deployments USING btree (sum256);
+
+--
+-- Name: deployments_versions; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX deployments_versions ON public.github_deployments_versioned USING btree (versions);
+
+--
+-- Name: pull_request_deployments_sum256; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX pull_request_deployments_sum256 ON public.pull_request_deployments USING btree (sum256, environment);
+`
+
+const deploymentsDown = `
+DROP MATERIALIZED VIEW public.pull_request_deployments;
+DROP MATERIALIZED VIEW public.deployments;
+DROP VIEW public.github_deployments;
+DROP TABLE public.github_deployments_versioned;
+DROP TABLE public.github_deployments_history;
+DROP VIEW public.github_deployment_statuses;
+DROP TABLE public.github_deployment_statuses_versioned;
+DROP TABLE public.github_deployment_statuses_history;
+`
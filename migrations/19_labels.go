@@ -0,0 +1,197 @@
+package migrations
+
+// labels records labels and milestones of repositories (label and milestone events).
+const labelsUp = `
+--
+-- Name: github_labels_history; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_labels_history (
+    version integer NOT NULL,
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    color text,
+    description text,
+    id bigint,
+    name text NOT NULL,
+    node_id text,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL,
+    deleted_at timestamp with time zone,
+    installation_id bigint
+);
+
+
+--
+-- Name: github_labels_versioned; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_labels_versioned (
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    color text,
+    description text,
+    id bigint,
+    name text NOT NULL,
+    node_id text,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL,
+    deleted_at timestamp with time zone,
+    installation_id bigint
+);
+
+
+--
+-- Name: github_labels; Type: VIEW; Schema: public; Owner: -
+--
+
+CREATE VIEW public.github_labels AS
+ SELECT github_labels_versioned.color,
+    github_labels_versioned.description,
+    github_labels_versioned.id,
+    github_labels_versioned.name,
+    github_labels_versioned.node_id,
+    github_labels_versioned.repository_name,
+    github_labels_versioned.repository_owner,
+    github_labels_versioned.repository_fullname,
+    github_labels_versioned.installation_id
+   FROM public.github_labels_versioned
+  WHERE (github_labels_versioned.deleted_at IS NULL);
+
+--
+-- Name: github_milestones_history; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_milestones_history (
+    version integer NOT NULL,
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    closed_at timestamp with time zone,
+    closed_issues bigint,
+    created_at timestamp with time zone,
+    creator_id bigint,
+    creator_login text,
+    description text,
+    due_on timestamp with time zone,
+    htmlurl text,
+    id bigint,
+    node_id text,
+    number bigint,
+    open_issues bigint,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL,
+    state text,
+    title text NOT NULL,
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone,
+    installation_id bigint
+);
+
+
+--
+-- Name: github_milestones_versioned; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_milestones_versioned (
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    closed_at timestamp with time zone,
+    closed_issues bigint,
+    created_at timestamp with time zone,
+    creator_id bigint,
+    creator_login text,
+    description text,
+    due_on timestamp with time zone,
+    htmlurl text,
+    id bigint,
+    node_id text,
+    number bigint,
+    open_issues bigint,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL,
+    state text,
+    title text NOT NULL,
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone,
+    installation_id bigint
+);
+
+
+--
+-- Name: github_milestones; Type: VIEW; Schema: public; Owner: -
+--
+
+CREATE VIEW public.github_milestones AS
+ SELECT github_milestones_versioned.closed_at,
+    github_milestones_versioned.closed_issues,
+    github_milestones_versioned.created_at,
+    github_milestones_versioned.creator_id,
+    github_milestones_versioned.creator_login,
+    github_milestones_versioned.description,
+    github_milestones_versioned.due_on,
+    github_milestones_versioned.htmlurl,
+    github_milestones_versioned.id,
+    github_milestones_versioned.node_id,
+    github_milestones_versioned.number,
+    github_milestones_versioned.open_issues,
+    github_milestones_versioned.repository_name,
+    github_milestones_versioned.repository_owner,
+    github_milestones_versioned.repository_fullname,
+    github_milestones_versioned.state,
+    github_milestones_versioned.title,
+    github_milestones_versioned.updated_at,
+    github_milestones_versioned.installation_id
+   FROM public.github_milestones_versioned
+  WHERE (github_milestones_versioned.deleted_at IS NULL);
+
+--
+-- Name: github_labels_versioned labels_versioned_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.github_labels_versioned
+    ADD CONSTRAINT labels_versioned_pkey PRIMARY KEY (sum256);
+
+--
+-- Name: github_milestones_versioned milestones_versioned_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.github_milestones_versioned
+    ADD CONSTRAINT milestones_versioned_pkey PRIMARY KEY (sum256);
+
+--
+-- Name: labels_history_sum256_version; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX labels_history_sum256_version ON public.github_labels_history USING btree (sum256, version);
+
+--
+-- Name: labels_versions; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX labels_versions ON public.github_labels_versioned USING btree (versions);
+
+--
+-- Name: milestones_history_sum256_version; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX milestones_history_sum256_version ON public.github_milestones_history USING btree (sum256, version);
+
+--
+-- Name: milestones_versions; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX milestones_versions ON public.github_milestones_versioned USING btree (versions);
+`
+
+const labelsDown = `
+DROP VIEW public.github_milestones;
+DROP TABLE public.github_milestones_versioned;
+DROP TABLE public.github_milestones_history;
+DROP VIEW public.github_labels;
+DROP TABLE public.github_labels_versioned;
+DROP TABLE public.github_labels_history;
+`
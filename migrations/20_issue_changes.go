@@ -0,0 +1,47 @@
+package migrations
+
+// issueChanges records who (un)labeled, (un)assigned and (de)milestoned issues and pull requests and when.
+const issueChangesUp = `
+--
+-- Name: issue_changes; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.issue_changes (
+    sum256 character varying(64) NOT NULL,
+    action text NOT NULL,
+    assignee_id bigint,
+    assignee_login text,
+    changed_at timestamp with time zone,
+    issue_id bigint NOT NULL,
+    issue_number bigint NOT NULL,
+    label text,
+    milestone_id bigint,
+    milestone_title text,
+    pull_request boolean NOT NULL,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL,
+    sender_id bigint NOT NULL,
+    sender_login text NOT NULL,
+    user_id bigint NOT NULL,
+    user_login text NOT NULL,
+    installation_id bigint
+);
+
+--
+-- Name: issue_changes issue_changes_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.issue_changes
+    ADD CONSTRAINT issue_changes_pkey PRIMARY KEY (sum256);
+
+--
+-- Name: issue_changes_issue; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX issue_changes_issue ON public.issue_changes USING btree (repository_fullname, issue_number);
+`
+
+const issueChangesDown = `
+DROP TABLE public.issue_changes;
+`
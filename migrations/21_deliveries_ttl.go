@@ -0,0 +1,14 @@
+package migrations
+
+// deliveriesTTL indexes the deliveries by when they were processed, so the old ones are purged.
+const deliveriesTTLUp = `
+--
+-- Name: deliveries_processed_at; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX deliveries_processed_at ON public.github_deliveries USING btree (processed_at);
+`
+
+const deliveriesTTLDown = `
+DROP INDEX public.deliveries_processed_at;
+`
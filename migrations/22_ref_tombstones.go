@@ -0,0 +1,26 @@
+package migrations
+
+// refTombstones allows rows of refs deleted before they were seen, which have no creation columns.
+const refTombstonesUp = `
+ALTER TABLE public.github_refs_versioned ALTER COLUMN created_by_id DROP NOT NULL;
+ALTER TABLE public.github_refs_versioned ALTER COLUMN created_by_login DROP NOT NULL;
+ALTER TABLE public.github_refs_versioned ALTER COLUMN master_branch DROP NOT NULL;
+ALTER TABLE public.github_refs_history ALTER COLUMN created_by_id DROP NOT NULL;
+ALTER TABLE public.github_refs_history ALTER COLUMN created_by_login DROP NOT NULL;
+ALTER TABLE public.github_refs_history ALTER COLUMN master_branch DROP NOT NULL;
+`
+
+const refTombstonesDown = `
+UPDATE public.github_refs_versioned SET created_by_id = 0 WHERE created_by_id IS NULL;
+UPDATE public.github_refs_versioned SET created_by_login = '' WHERE created_by_login IS NULL;
+UPDATE public.github_refs_versioned SET master_branch = '' WHERE master_branch IS NULL;
+UPDATE public.github_refs_history SET created_by_id = 0 WHERE created_by_id IS NULL;
+UPDATE public.github_refs_history SET created_by_login = '' WHERE created_by_login IS NULL;
+UPDATE public.github_refs_history SET master_branch = '' WHERE master_branch IS NULL;
+ALTER TABLE public.github_refs_versioned ALTER COLUMN created_by_id SET NOT NULL;
+ALTER TABLE public.github_refs_versioned ALTER COLUMN created_by_login SET NOT NULL;
+ALTER TABLE public.github_refs_versioned ALTER COLUMN master_branch SET NOT NULL;
+ALTER TABLE public.github_refs_history ALTER COLUMN created_by_id SET NOT NULL;
+ALTER TABLE public.github_refs_history ALTER COLUMN created_by_login SET NOT NULL;
+ALTER TABLE public.github_refs_history ALTER COLUMN master_branch SET NOT NULL;
+`
@@ -0,0 +1,12 @@
+package migrations
+
+// labelsUpdatedAt orders the versions of labels, so renames received out of order don't overwrite the latest name.
+const labelsUpdatedAtUp = `
+ALTER TABLE public.github_labels_versioned ADD COLUMN updated_at timestamp with time zone;
+ALTER TABLE public.github_labels_history ADD COLUMN updated_at timestamp with time zone;
+`
+
+const labelsUpdatedAtDown = `
+ALTER TABLE public.github_labels_versioned DROP COLUMN updated_at;
+ALTER TABLE public.github_labels_history DROP COLUMN updated_at;
+`
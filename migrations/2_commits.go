@@ -0,0 +1,79 @@
+package migrations
+
+// commits stores the commits of push events.
+const commitsUp = `
+--
+-- Name: github_commits_versioned; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_commits_versioned (
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    added text[] NOT NULL,
+    after text NOT NULL,
+    author_email text,
+    author_name text,
+    before text NOT NULL,
+    committed_at timestamp with time zone,
+    committer_email text,
+    committer_name text,
+    forced boolean,
+    htmlurl text,
+    message text,
+    modified text[] NOT NULL,
+    ref text NOT NULL,
+    removed text[] NOT NULL,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL,
+    sha text NOT NULL,
+    tree_id text
+);
+
+
+--
+-- Name: github_commits; Type: VIEW; Schema: public; Owner: -
+--
+
+CREATE VIEW public.github_commits AS
+ SELECT github_commits_versioned.added,
+    github_commits_versioned.after,
+    github_commits_versioned.author_email,
+    github_commits_versioned.author_name,
+    github_commits_versioned.before,
+    github_commits_versioned.committed_at,
+    github_commits_versioned.committer_email,
+    github_commits_versioned.committer_name,
+    github_commits_versioned.forced,
+    github_commits_versioned.htmlurl,
+    github_commits_versioned.message,
+    github_commits_versioned.modified,
+    github_commits_versioned.ref,
+    github_commits_versioned.removed,
+    github_commits_versioned.repository_name,
+    github_commits_versioned.repository_owner,
+    github_commits_versioned.repository_fullname,
+    github_commits_versioned.sha,
+    github_commits_versioned.tree_id
+   FROM public.github_commits_versioned;
+
+
+--
+-- Name: github_commits_versioned commits_versioned_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.github_commits_versioned
+    ADD CONSTRAINT commits_versioned_pkey PRIMARY KEY (sum256);
+
+
+--
+-- Name: commits_versions; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX commits_versions ON public.github_commits_versioned USING btree (versions);
+`
+
+const commitsDown = `
+DROP VIEW public.github_commits;
+DROP TABLE public.github_commits_versioned;
+`
@@ -0,0 +1,67 @@
+package migrations
+
+// refs stores branches and tags from create and delete events.
+const refsUp = `
+--
+-- Name: github_refs_versioned; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_refs_versioned (
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    created_at timestamp with time zone,
+    created_by_id bigint NOT NULL,
+    created_by_login text NOT NULL,
+    deleted_at timestamp with time zone,
+    deleted_by_id bigint,
+    deleted_by_login text,
+    master_branch text NOT NULL,
+    pusher_type text,
+    ref text NOT NULL,
+    ref_type text NOT NULL,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL
+);
+
+
+--
+-- Name: github_refs; Type: VIEW; Schema: public; Owner: -
+--
+
+CREATE VIEW public.github_refs AS
+ SELECT github_refs_versioned.created_at,
+    github_refs_versioned.created_by_id,
+    github_refs_versioned.created_by_login,
+    github_refs_versioned.deleted_at,
+    github_refs_versioned.deleted_by_id,
+    github_refs_versioned.deleted_by_login,
+    github_refs_versioned.master_branch,
+    github_refs_versioned.pusher_type,
+    github_refs_versioned.ref,
+    github_refs_versioned.ref_type,
+    github_refs_versioned.repository_name,
+    github_refs_versioned.repository_owner,
+    github_refs_versioned.repository_fullname
+   FROM public.github_refs_versioned;
+
+
+--
+-- Name: github_refs_versioned refs_versioned_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.github_refs_versioned
+    ADD CONSTRAINT refs_versioned_pkey PRIMARY KEY (sum256);
+
+
+--
+-- Name: refs_versions; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX refs_versions ON public.github_refs_versioned USING btree (versions);
+`
+
+const refsDown = `
+DROP VIEW public.github_refs;
+DROP TABLE public.github_refs_versioned;
+`
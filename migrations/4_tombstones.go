@@ -0,0 +1,547 @@
+package migrations
+
+// tombstones adds deleted_at to the tables of deletable objects and hides the deleted rows from the views.
+const tombstonesUp = `
+DROP MATERIALIZED VIEW public.issue_comments;
+DROP MATERIALIZED VIEW public.issues;
+DROP MATERIALIZED VIEW public.owners;
+DROP MATERIALIZED VIEW public.pull_request_comments;
+DROP MATERIALIZED VIEW public.repositories;
+
+
+ALTER TABLE public.github_issue_comments_versioned ADD COLUMN deleted_at timestamp with time zone;
+ALTER TABLE public.github_issues_versioned ADD COLUMN deleted_at timestamp with time zone;
+ALTER TABLE public.github_organizations_versioned ADD COLUMN deleted_at timestamp with time zone;
+ALTER TABLE public.github_pull_request_comments_versioned ADD COLUMN deleted_at timestamp with time zone;
+ALTER TABLE public.github_repositories_versioned ADD COLUMN deleted_at timestamp with time zone;
+
+
+--
+-- Name: github_issue_comments; Type: VIEW; Schema: public; Owner: -
+--
+
+CREATE OR REPLACE VIEW public.github_issue_comments AS
+ SELECT github_issue_comments_versioned.author_association,
+    github_issue_comments_versioned.body,
+    github_issue_comments_versioned.created_at,
+    github_issue_comments_versioned.htmlurl,
+    github_issue_comments_versioned.id,
+    github_issue_comments_versioned.issue_number,
+    github_issue_comments_versioned.node_id,
+    github_issue_comments_versioned.repository_name,
+    github_issue_comments_versioned.repository_owner,
+    github_issue_comments_versioned.repository_fullname,
+    github_issue_comments_versioned.updated_at,
+    github_issue_comments_versioned.user_id,
+    github_issue_comments_versioned.user_login
+   FROM public.github_issue_comments_versioned
+  WHERE (github_issue_comments_versioned.deleted_at IS NULL);
+
+
+--
+-- Name: github_issues; Type: VIEW; Schema: public; Owner: -
+--
+
+CREATE OR REPLACE VIEW public.github_issues AS
+ SELECT github_issues_versioned.assignees,
+    github_issues_versioned.body,
+    github_issues_versioned.closed_at,
+    github_issues_versioned.closed_by_id,
+    github_issues_versioned.closed_by_login,
+    github_issues_versioned.comments,
+    github_issues_versioned.created_at,
+    github_issues_versioned.htmlurl,
+    github_issues_versioned.id,
+    github_issues_versioned.labels,
+    github_issues_versioned.locked,
+    github_issues_versioned.milestone_id,
+    github_issues_versioned.milestone_title,
+    github_issues_versioned.node_id,
+    github_issues_versioned.number,
+    github_issues_versioned.repository_name,
+    github_issues_versioned.repository_owner,
+    github_issues_versioned.repository_fullname,
+    github_issues_versioned.state,
+    github_issues_versioned.title,
+    github_issues_versioned.updated_at,
+    github_issues_versioned.user_id,
+    github_issues_versioned.user_login
+   FROM public.github_issues_versioned
+  WHERE (github_issues_versioned.deleted_at IS NULL);
+
+
+--
+-- Name: github_organizations; Type: VIEW; Schema: public; Owner: -
+--
+
+CREATE OR REPLACE VIEW public.github_organizations AS
+ SELECT github_organizations_versioned.avatar_url,
+    github_organizations_versioned.collaborators,
+    github_organizations_versioned.created_at,
+    github_organizations_versioned.description,
+    github_organizations_versioned.email,
+    github_organizations_versioned.htmlurl,
+    github_organizations_versioned.id,
+    github_organizations_versioned.login,
+    github_organizations_versioned.name,
+    github_organizations_versioned.node_id,
+    github_organizations_versioned.owned_private_repos,
+    github_organizations_versioned.public_repos,
+    github_organizations_versioned.total_private_repos,
+    github_organizations_versioned.updated_at
+   FROM public.github_organizations_versioned
+  WHERE (github_organizations_versioned.deleted_at IS NULL);
+
+
+--
+-- Name: github_pull_request_comments; Type: VIEW; Schema: public; Owner: -
+--
+
+CREATE OR REPLACE VIEW public.github_pull_request_comments AS
+ SELECT github_pull_request_comments_versioned.author_association,
+    github_pull_request_comments_versioned.body,
+    github_pull_request_comments_versioned.commit_id,
+    github_pull_request_comments_versioned.created_at,
+    github_pull_request_comments_versioned.diff_hunk,
+    github_pull_request_comments_versioned.htmlurl,
+    github_pull_request_comments_versioned.id,
+    github_pull_request_comments_versioned.in_reply_to,
+    github_pull_request_comments_versioned.node_id,
+    github_pull_request_comments_versioned.original_commit_id,
+    github_pull_request_comments_versioned.original_position,
+    github_pull_request_comments_versioned.path,
+    github_pull_request_comments_versioned."position",
+    github_pull_request_comments_versioned.pull_request_number,
+    github_pull_request_comments_versioned.pull_request_review_id,
+    github_pull_request_comments_versioned.repository_name,
+    github_pull_request_comments_versioned.repository_owner,
+    github_pull_request_comments_versioned.repository_fullname,
+    github_pull_request_comments_versioned.updated_at,
+    github_pull_request_comments_versioned.user_id,
+    github_pull_request_comments_versioned.user_login
+   FROM public.github_pull_request_comments_versioned
+  WHERE (github_pull_request_comments_versioned.deleted_at IS NULL);
+
+
+--
+-- Name: github_repositories; Type: VIEW; Schema: public; Owner: -
+--
+
+CREATE OR REPLACE VIEW public.github_repositories AS
+ SELECT github_repositories_versioned.allow_merge_commit,
+    github_repositories_versioned.allow_rebase_merge,
+    github_repositories_versioned.allow_squash_merge,
+    github_repositories_versioned.archived,
+    github_repositories_versioned.clone_url,
+    github_repositories_versioned.created_at,
+    github_repositories_versioned.default_branch,
+    github_repositories_versioned.description,
+    github_repositories_versioned.disabled,
+    github_repositories_versioned.fork,
+    github_repositories_versioned.forks_count,
+    github_repositories_versioned.fullname,
+    github_repositories_versioned.has_issues,
+    github_repositories_versioned.has_wiki,
+    github_repositories_versioned.homepage,
+    github_repositories_versioned.htmlurl,
+    github_repositories_versioned.id,
+    github_repositories_versioned.language,
+    github_repositories_versioned.name,
+    github_repositories_versioned.node_id,
+    github_repositories_versioned.open_issues_count,
+    github_repositories_versioned.owner_id,
+    github_repositories_versioned.owner_login,
+    github_repositories_versioned.owner_type,
+    github_repositories_versioned.private,
+    github_repositories_versioned.pushed_at,
+    github_repositories_versioned.sshurl,
+    github_repositories_versioned.stargazers_count,
+    github_repositories_versioned.topics,
+    github_repositories_versioned.updated_at,
+    github_repositories_versioned.watchers_count
+   FROM public.github_repositories_versioned
+  WHERE (github_repositories_versioned.deleted_at IS NULL);
+
+
+--
+-- Name: issue_comments; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.issue_comments AS
+ SELECT c.repository_owner,
+    c.repository_name,
+    c.repository_fullname,
+    c.issue_number,
+    c.created_at,
+    c.body,
+    c.user_id,
+    c.user_login,
+    c.htmlurl AS html_url
+   FROM (public.github_issue_comments_versioned c
+     JOIN public.github_issues_versioned i ON (((i.repository_owner = c.repository_owner) AND (i.repository_name = c.repository_name) AND (i.number = c.issue_number))))
+  WHERE ((c.deleted_at IS NULL) AND (i.deleted_at IS NULL))
+  WITH NO DATA;
+
+
+--
+-- Name: issues; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.issues AS
+ SELECT github_issues_versioned.repository_owner,
+    github_issues_versioned.repository_name,
+    github_issues_versioned.repository_fullname,
+    github_issues_versioned.number,
+    github_issues_versioned.state,
+    github_issues_versioned.title,
+    github_issues_versioned.body,
+    github_issues_versioned.created_at,
+    github_issues_versioned.closed_at,
+    github_issues_versioned.updated_at,
+    github_issues_versioned.comments,
+    github_issues_versioned.user_id,
+    github_issues_versioned.user_login,
+    github_issues_versioned.htmlurl AS html_url,
+    github_issues_versioned.labels
+   FROM public.github_issues_versioned
+  WHERE (github_issues_versioned.deleted_at IS NULL)
+  WITH NO DATA;
+
+
+--
+-- Name: owners; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.owners AS
+ SELECT github_organizations_versioned.login,
+    github_organizations_versioned.name
+   FROM public.github_organizations_versioned
+  WHERE (github_organizations_versioned.deleted_at IS NULL)
+  WITH NO DATA;
+
+
+--
+-- Name: pull_request_comments; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.pull_request_comments AS
+ SELECT c.repository_owner,
+    c.repository_name,
+    c.repository_fullname,
+    c.issue_number AS pull_request_number,
+    c.created_at,
+    c.body,
+    c.user_id,
+    c.user_login,
+    c.htmlurl AS html_url
+   FROM (public.github_issue_comments_versioned c
+     JOIN public.github_pull_requests_versioned p ON (((p.repository_owner = c.repository_owner) AND (p.repository_name = c.repository_name) AND (p.number = c.issue_number))))
+  WHERE (c.deleted_at IS NULL)
+UNION
+ SELECT c.repository_owner,
+    c.repository_name,
+    c.repository_fullname,
+    c.pull_request_number,
+    c.created_at,
+    c.body,
+    c.user_id,
+    c.user_login,
+    c.htmlurl AS html_url
+   FROM public.github_pull_request_comments_versioned c
+  WHERE (c.deleted_at IS NULL)
+UNION
+ SELECT c.repository_owner,
+    c.repository_name,
+    c.repository_fullname,
+    c.pull_request_number,
+    c.submitted_at AS created_at,
+    c.body,
+    c.user_id,
+    c.user_login,
+    c.htmlurl AS html_url
+   FROM public.github_pull_request_reviews_versioned c
+  WHERE (c.body <> ''::text)
+  WITH NO DATA;
+
+
+--
+-- Name: repositories; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.repositories AS
+ SELECT github_repositories_versioned.owner_login AS owner,
+    github_repositories_versioned.name,
+    github_repositories_versioned.fullname,
+    github_repositories_versioned.private,
+    github_repositories_versioned.description
+   FROM public.github_repositories_versioned
+  WHERE (github_repositories_versioned.deleted_at IS NULL)
+  WITH NO DATA;
+`
+
+const tombstonesDown = `
+DROP MATERIALIZED VIEW public.issue_comments;
+DROP MATERIALIZED VIEW public.issues;
+DROP MATERIALIZED VIEW public.owners;
+DROP MATERIALIZED VIEW public.pull_request_comments;
+DROP MATERIALIZED VIEW public.repositories;
+
+
+--
+-- Name: github_issue_comments; Type: VIEW; Schema: public; Owner: -
+--
+
+CREATE OR REPLACE VIEW public.github_issue_comments AS
+ SELECT github_issue_comments_versioned.author_association,
+    github_issue_comments_versioned.body,
+    github_issue_comments_versioned.created_at,
+    github_issue_comments_versioned.htmlurl,
+    github_issue_comments_versioned.id,
+    github_issue_comments_versioned.issue_number,
+    github_issue_comments_versioned.node_id,
+    github_issue_comments_versioned.repository_name,
+    github_issue_comments_versioned.repository_owner,
+    github_issue_comments_versioned.repository_fullname,
+    github_issue_comments_versioned.updated_at,
+    github_issue_comments_versioned.user_id,
+    github_issue_comments_versioned.user_login
+   FROM public.github_issue_comments_versioned;
+
+
+--
+-- Name: github_issues; Type: VIEW; Schema: public; Owner: -
+--
+
+CREATE OR REPLACE VIEW public.github_issues AS
+ SELECT github_issues_versioned.assignees,
+    github_issues_versioned.body,
+    github_issues_versioned.closed_at,
+    github_issues_versioned.closed_by_id,
+    github_issues_versioned.closed_by_login,
+    github_issues_versioned.comments,
+    github_issues_versioned.created_at,
+    github_issues_versioned.htmlurl,
+    github_issues_versioned.id,
+    github_issues_versioned.labels,
+    github_issues_versioned.locked,
+    github_issues_versioned.milestone_id,
+    github_issues_versioned.milestone_title,
+    github_issues_versioned.node_id,
+    github_issues_versioned.number,
+    github_issues_versioned.repository_name,
+    github_issues_versioned.repository_owner,
+    github_issues_versioned.repository_fullname,
+    github_issues_versioned.state,
+    github_issues_versioned.title,
+    github_issues_versioned.updated_at,
+    github_issues_versioned.user_id,
+    github_issues_versioned.user_login
+   FROM public.github_issues_versioned;
+
+
+--
+-- Name: github_organizations; Type: VIEW; Schema: public; Owner: -
+--
+
+CREATE OR REPLACE VIEW public.github_organizations AS
+ SELECT github_organizations_versioned.avatar_url,
+    github_organizations_versioned.collaborators,
+    github_organizations_versioned.created_at,
+    github_organizations_versioned.description,
+    github_organizations_versioned.email,
+    github_organizations_versioned.htmlurl,
+    github_organizations_versioned.id,
+    github_organizations_versioned.login,
+    github_organizations_versioned.name,
+    github_organizations_versioned.node_id,
+    github_organizations_versioned.owned_private_repos,
+    github_organizations_versioned.public_repos,
+    github_organizations_versioned.total_private_repos,
+    github_organizations_versioned.updated_at
+   FROM public.github_organizations_versioned;
+
+
+--
+-- Name: github_pull_request_comments; Type: VIEW; Schema: public; Owner: -
+--
+
+CREATE OR REPLACE VIEW public.github_pull_request_comments AS
+ SELECT github_pull_request_comments_versioned.author_association,
+    github_pull_request_comments_versioned.body,
+    github_pull_request_comments_versioned.commit_id,
+    github_pull_request_comments_versioned.created_at,
+    github_pull_request_comments_versioned.diff_hunk,
+    github_pull_request_comments_versioned.htmlurl,
+    github_pull_request_comments_versioned.id,
+    github_pull_request_comments_versioned.in_reply_to,
+    github_pull_request_comments_versioned.node_id,
+    github_pull_request_comments_versioned.original_commit_id,
+    github_pull_request_comments_versioned.original_position,
+    github_pull_request_comments_versioned.path,
+    github_pull_request_comments_versioned."position",
+    github_pull_request_comments_versioned.pull_request_number,
+    github_pull_request_comments_versioned.pull_request_review_id,
+    github_pull_request_comments_versioned.repository_name,
+    github_pull_request_comments_versioned.repository_owner,
+    github_pull_request_comments_versioned.repository_fullname,
+    github_pull_request_comments_versioned.updated_at,
+    github_pull_request_comments_versioned.user_id,
+    github_pull_request_comments_versioned.user_login
+   FROM public.github_pull_request_comments_versioned;
+
+
+--
+-- Name: github_repositories; Type: VIEW; Schema: public; Owner: -
+--
+
+CREATE OR REPLACE VIEW public.github_repositories AS
+ SELECT github_repositories_versioned.allow_merge_commit,
+    github_repositories_versioned.allow_rebase_merge,
+    github_repositories_versioned.allow_squash_merge,
+    github_repositories_versioned.archived,
+    github_repositories_versioned.clone_url,
+    github_repositories_versioned.created_at,
+    github_repositories_versioned.default_branch,
+    github_repositories_versioned.description,
+    github_repositories_versioned.disabled,
+    github_repositories_versioned.fork,
+    github_repositories_versioned.forks_count,
+    github_repositories_versioned.fullname,
+    github_repositories_versioned.has_issues,
+    github_repositories_versioned.has_wiki,
+    github_repositories_versioned.homepage,
+    github_repositories_versioned.htmlurl,
+    github_repositories_versioned.id,
+    github_repositories_versioned.language,
+    github_repositories_versioned.name,
+    github_repositories_versioned.node_id,
+    github_repositories_versioned.open_issues_count,
+    github_repositories_versioned.owner_id,
+    github_repositories_versioned.owner_login,
+    github_repositories_versioned.owner_type,
+    github_repositories_versioned.private,
+    github_repositories_versioned.pushed_at,
+    github_repositories_versioned.sshurl,
+    github_repositories_versioned.stargazers_count,
+    github_repositories_versioned.topics,
+    github_repositories_versioned.updated_at,
+    github_repositories_versioned.watchers_count
+   FROM public.github_repositories_versioned;
+
+
+ALTER TABLE public.github_issue_comments_versioned DROP COLUMN deleted_at;
+ALTER TABLE public.github_issues_versioned DROP COLUMN deleted_at;
+ALTER TABLE public.github_organizations_versioned DROP COLUMN deleted_at;
+ALTER TABLE public.github_pull_request_comments_versioned DROP COLUMN deleted_at;
+ALTER TABLE public.github_repositories_versioned DROP COLUMN deleted_at;
+
+
+--
+-- Name: issue_comments; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.issue_comments AS
+ SELECT c.repository_owner,
+    c.repository_name,
+    c.repository_fullname,
+    c.issue_number,
+    c.created_at,
+    c.body,
+    c.user_id,
+    c.user_login,
+    c.htmlurl AS html_url
+   FROM (public.github_issue_comments_versioned c
+     JOIN public.github_issues_versioned i ON (((i.repository_owner = c.repository_owner) AND (i.repository_name = c.repository_name) AND (i.number = c.issue_number))))
+  WITH NO DATA;
+
+
+--
+-- Name: issues; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.issues AS
+ SELECT github_issues_versioned.repository_owner,
+    github_issues_versioned.repository_name,
+    github_issues_versioned.repository_fullname,
+    github_issues_versioned.number,
+    github_issues_versioned.state,
+    github_issues_versioned.title,
+    github_issues_versioned.body,
+    github_issues_versioned.created_at,
+    github_issues_versioned.closed_at,
+    github_issues_versioned.updated_at,
+    github_issues_versioned.comments,
+    github_issues_versioned.user_id,
+    github_issues_versioned.user_login,
+    github_issues_versioned.htmlurl AS html_url,
+    github_issues_versioned.labels
+   FROM public.github_issues_versioned
+  WITH NO DATA;
+
+
+--
+-- Name: owners; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.owners AS
+ SELECT github_organizations_versioned.login,
+    github_organizations_versioned.name
+   FROM public.github_organizations_versioned
+  WITH NO DATA;
+
+
+--
+-- Name: pull_request_comments; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.pull_request_comments AS
+ SELECT c.repository_owner,
+    c.repository_name,
+    c.repository_fullname,
+    c.issue_number AS pull_request_number,
+    c.created_at,
+    c.body,
+    c.user_id,
+    c.user_login,
+    c.htmlurl AS html_url
+   FROM (public.github_issue_comments_versioned c
+     JOIN public.github_pull_requests_versioned p ON (((p.repository_owner = c.repository_owner) AND (p.repository_name = c.repository_name) AND (p.number = c.issue_number))))
+UNION
+ SELECT c.repository_owner,
+    c.repository_name,
+    c.repository_fullname,
+    c.pull_request_number,
+    c.created_at,
+    c.body,
+    c.user_id,
+    c.user_login,
+    c.htmlurl AS html_url
+   FROM public.github_pull_request_comments_versioned c
+UNION
+ SELECT c.repository_owner,
+    c.repository_name,
+    c.repository_fullname,
+    c.pull_request_number,
+    c.submitted_at AS created_at,
+    c.body,
+    c.user_id,
+    c.user_login,
+    c.htmlurl AS html_url
+   FROM public.github_pull_request_reviews_versioned c
+  WHERE (c.body <> ''::text)
+  WITH NO DATA;
+
+
+--
+-- Name: repositories; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.repositories AS
+ SELECT github_repositories_versioned.owner_login AS owner,
+    github_repositories_versioned.name,
+    github_repositories_versioned.fullname,
+    github_repositories_versioned.private,
+    github_repositories_versioned.description
+   FROM public.github_repositories_versioned
+  WITH NO DATA;
+`
@@ -0,0 +1,415 @@
+package migrations
+
+// history keeps every version of the rows of the versioned tables.
+const historyUp = `
+--
+-- Name: github_commits_history; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_commits_history (
+    version integer NOT NULL,
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    added text[] NOT NULL,
+    after text NOT NULL,
+    author_email text,
+    author_name text,
+    before text NOT NULL,
+    committed_at timestamp with time zone,
+    committer_email text,
+    committer_name text,
+    forced boolean,
+    htmlurl text,
+    message text,
+    modified text[] NOT NULL,
+    ref text NOT NULL,
+    removed text[] NOT NULL,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL,
+    sha text NOT NULL,
+    tree_id text
+);
+
+
+--
+-- Name: github_issue_comments_history; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_issue_comments_history (
+    version integer NOT NULL,
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    author_association text,
+    body text,
+    created_at timestamp with time zone,
+    htmlurl text,
+    id bigint,
+    issue_number bigint NOT NULL,
+    node_id text,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL,
+    updated_at timestamp with time zone,
+    user_id bigint NOT NULL,
+    user_login text NOT NULL,
+    deleted_at timestamp with time zone
+);
+
+
+--
+-- Name: github_issues_history; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_issues_history (
+    version integer NOT NULL,
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    assignees text[] NOT NULL,
+    body text,
+    closed_at timestamp with time zone,
+    closed_by_id bigint NOT NULL,
+    closed_by_login text NOT NULL,
+    comments bigint,
+    created_at timestamp with time zone,
+    htmlurl text,
+    id bigint,
+    labels text[] NOT NULL,
+    locked boolean,
+    milestone_id text NOT NULL,
+    milestone_title text NOT NULL,
+    node_id text,
+    number bigint,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL,
+    state text,
+    title text,
+    updated_at timestamp with time zone,
+    user_id bigint NOT NULL,
+    user_login text NOT NULL,
+    deleted_at timestamp with time zone
+);
+
+
+--
+-- Name: github_organizations_history; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_organizations_history (
+    version integer NOT NULL,
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    avatar_url text,
+    collaborators bigint,
+    created_at timestamp with time zone,
+    description text,
+    email text,
+    htmlurl text,
+    id bigint,
+    login text,
+    name text,
+    node_id text,
+    owned_private_repos bigint,
+    public_repos bigint,
+    total_private_repos bigint,
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone
+);
+
+
+--
+-- Name: github_pull_request_comments_history; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_pull_request_comments_history (
+    version integer NOT NULL,
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    author_association text,
+    body text,
+    commit_id text,
+    created_at timestamp with time zone,
+    diff_hunk text,
+    htmlurl text,
+    id bigint,
+    in_reply_to bigint,
+    node_id text,
+    original_commit_id text,
+    original_position bigint,
+    path text,
+    "position" bigint,
+    pull_request_number bigint NOT NULL,
+    pull_request_review_id bigint,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL,
+    updated_at timestamp with time zone,
+    user_id bigint NOT NULL,
+    user_login text NOT NULL,
+    deleted_at timestamp with time zone
+);
+
+
+--
+-- Name: github_pull_request_reviews_history; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_pull_request_reviews_history (
+    version integer NOT NULL,
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    body text,
+    commit_id text,
+    htmlurl text,
+    id bigint,
+    node_id text,
+    pull_request_number bigint NOT NULL,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL,
+    state text,
+    submitted_at timestamp with time zone,
+    user_id bigint NOT NULL,
+    user_login text NOT NULL
+);
+
+
+--
+-- Name: github_pull_requests_history; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_pull_requests_history (
+    version integer NOT NULL,
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    additions bigint,
+    assignees text[] NOT NULL,
+    author_association text,
+    base_ref text NOT NULL,
+    base_repository_name text NOT NULL,
+    base_repository_owner text NOT NULL,
+    base_repository_fullname text NOT NULL,
+    base_sha text NOT NULL,
+    base_user text NOT NULL,
+    body text,
+    changed_files bigint,
+    closed_at timestamp with time zone,
+    comments bigint,
+    commits bigint,
+    created_at timestamp with time zone,
+    deletions bigint,
+    head_ref text NOT NULL,
+    head_repository_name text NOT NULL,
+    head_repository_owner text NOT NULL,
+    head_repository_fullname text NOT NULL,
+    head_sha text NOT NULL,
+    head_user text NOT NULL,
+    htmlurl text,
+    id bigint,
+    labels text[] NOT NULL,
+    maintainer_can_modify boolean,
+    merge_commit_sha text,
+    mergeable boolean,
+    merged boolean,
+    merged_at timestamp with time zone,
+    merged_by_id bigint NOT NULL,
+    merged_by_login text NOT NULL,
+    milestone_id text NOT NULL,
+    milestone_title text NOT NULL,
+    node_id text,
+    number bigint,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL,
+    review_comments bigint,
+    state text,
+    title text,
+    updated_at timestamp with time zone,
+    user_id bigint NOT NULL,
+    user_login text NOT NULL
+);
+
+
+--
+-- Name: github_refs_history; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_refs_history (
+    version integer NOT NULL,
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    created_at timestamp with time zone,
+    created_by_id bigint NOT NULL,
+    created_by_login text NOT NULL,
+    deleted_at timestamp with time zone,
+    deleted_by_id bigint,
+    deleted_by_login text,
+    master_branch text NOT NULL,
+    pusher_type text,
+    ref text NOT NULL,
+    ref_type text NOT NULL,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL
+);
+
+
+--
+-- Name: github_repositories_history; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_repositories_history (
+    version integer NOT NULL,
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    allow_merge_commit boolean,
+    allow_rebase_merge boolean,
+    allow_squash_merge boolean,
+    archived boolean,
+    clone_url text,
+    created_at timestamp with time zone,
+    default_branch text,
+    description text,
+    disabled boolean,
+    fork boolean,
+    forks_count bigint,
+    fullname text,
+    has_issues boolean,
+    has_wiki boolean,
+    homepage text,
+    htmlurl text,
+    id bigint,
+    language text,
+    name text,
+    node_id text,
+    open_issues_count bigint,
+    owner_id bigint NOT NULL,
+    owner_login text NOT NULL,
+    owner_type text NOT NULL,
+    private boolean,
+    pushed_at timestamp with time zone,
+    sshurl text,
+    stargazers_count bigint,
+    topics text[] NOT NULL,
+    updated_at timestamp with time zone,
+    watchers_count bigint,
+    deleted_at timestamp with time zone
+);
+
+
+--
+-- Name: github_users_history; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_users_history (
+    version integer NOT NULL,
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    avatar_url text,
+    bio text,
+    company text,
+    created_at timestamp with time zone,
+    email text,
+    followers bigint,
+    following bigint,
+    hireable boolean,
+    htmlurl text,
+    id bigint,
+    location text,
+    login text,
+    name text,
+    node_id text,
+    organization_id bigint NOT NULL,
+    organization_login text NOT NULL,
+    owned_private_repos bigint,
+    private_gists bigint,
+    public_gists bigint,
+    public_repos bigint,
+    total_private_repos bigint,
+    updated_at timestamp with time zone
+);
+
+
+--
+-- Name: commits_history_sum256_version; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX commits_history_sum256_version ON public.github_commits_history USING btree (sum256, version);
+
+
+--
+-- Name: issue_comments_history_sum256_version; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX issue_comments_history_sum256_version ON public.github_issue_comments_history USING btree (sum256, version);
+
+
+--
+-- Name: issues_history_sum256_version; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX issues_history_sum256_version ON public.github_issues_history USING btree (sum256, version);
+
+
+--
+-- Name: organizations_history_sum256_version; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX organizations_history_sum256_version ON public.github_organizations_history USING btree (sum256, version);
+
+
+--
+-- Name: pull_request_comments_history_sum256_version; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX pull_request_comments_history_sum256_version ON public.github_pull_request_comments_history USING btree (sum256, version);
+
+
+--
+-- Name: pull_request_reviews_history_sum256_version; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX pull_request_reviews_history_sum256_version ON public.github_pull_request_reviews_history USING btree (sum256, version);
+
+
+--
+-- Name: pull_requests_history_sum256_version; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX pull_requests_history_sum256_version ON public.github_pull_requests_history USING btree (sum256, version);
+
+
+--
+-- Name: refs_history_sum256_version; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX refs_history_sum256_version ON public.github_refs_history USING btree (sum256, version);
+
+
+--
+-- Name: repositories_history_sum256_version; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX repositories_history_sum256_version ON public.github_repositories_history USING btree (sum256, version);
+
+
+--
+-- Name: users_history_sum256_version; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX users_history_sum256_version ON public.github_users_history USING btree (sum256, version);
+`
+
+const historyDown = `
+DROP TABLE public.github_users_history;
+DROP TABLE public.github_repositories_history;
+DROP TABLE public.github_refs_history;
+DROP TABLE public.github_pull_requests_history;
+DROP TABLE public.github_pull_request_reviews_history;
+DROP TABLE public.github_pull_request_comments_history;
+DROP TABLE public.github_organizations_history;
+DROP TABLE public.github_issues_history;
+DROP TABLE public.github_issue_comments_history;
+DROP TABLE public.github_commits_history;
+`
@@ -0,0 +1,28 @@
+package migrations
+
+// skippedVersions records the out-of-order versions which were not upserted.
+const skippedVersionsUp = `
+--
+-- Name: github_skipped_versions; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_skipped_versions (
+    table_name text NOT NULL,
+    sum256 character varying(64) NOT NULL,
+    version integer NOT NULL,
+    ordered_by text NOT NULL,
+    ordered_at timestamp with time zone,
+    skipped_at timestamp with time zone NOT NULL
+);
+
+
+--
+-- Name: skipped_versions_sum256; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX skipped_versions_sum256 ON public.github_skipped_versions USING btree (table_name, sum256);
+`
+
+const skippedVersionsDown = `
+DROP TABLE public.github_skipped_versions;
+`
@@ -0,0 +1,41 @@
+package migrations
+
+// stateTransitions records when pull requests were closed, merged and reopened.
+const stateTransitionsUp = `
+--
+-- Name: pull_request_state_transitions; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.pull_request_state_transitions (
+    sum256 character varying(64) NOT NULL,
+    action text NOT NULL,
+    changed_at timestamp with time zone,
+    pull_request_id bigint NOT NULL,
+    pull_request_number bigint NOT NULL,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL,
+    sender_id bigint NOT NULL,
+    sender_login text NOT NULL,
+    state text NOT NULL
+);
+
+
+--
+-- Name: pull_request_state_transitions pull_request_state_transitions_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.pull_request_state_transitions
+    ADD CONSTRAINT pull_request_state_transitions_pkey PRIMARY KEY (sum256);
+
+
+--
+-- Name: pull_request_state_transitions_pull_request; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX pull_request_state_transitions_pull_request ON public.pull_request_state_transitions USING btree (repository_fullname, pull_request_number);
+`
+
+const stateTransitionsDown = `
+DROP TABLE public.pull_request_state_transitions;
+`
@@ -0,0 +1,42 @@
+package migrations
+
+// pullRequestPushes records the pushes (synchronize events) to pull requests.
+const pullRequestPushesUp = `
+--
+-- Name: github_pull_request_pushes; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_pull_request_pushes (
+    sum256 character varying(64) NOT NULL,
+    after text NOT NULL,
+    before text NOT NULL,
+    pull_request_id bigint NOT NULL,
+    pull_request_number bigint NOT NULL,
+    pull_request_sum256 character varying(64) NOT NULL,
+    pushed_at timestamp with time zone,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL,
+    sender_id bigint NOT NULL,
+    sender_login text NOT NULL
+);
+
+
+--
+-- Name: github_pull_request_pushes pull_request_pushes_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.github_pull_request_pushes
+    ADD CONSTRAINT pull_request_pushes_pkey PRIMARY KEY (sum256);
+
+
+--
+-- Name: pull_request_pushes_pull_request; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX pull_request_pushes_pull_request ON public.github_pull_request_pushes USING btree (pull_request_sum256);
+`
+
+const pullRequestPushesDown = `
+DROP TABLE public.github_pull_request_pushes;
+`
@@ -0,0 +1,26 @@
+package migrations
+
+// deliveries records processed webhook deliveries, so redelivered events are ignored.
+const deliveriesUp = `
+--
+-- Name: github_deliveries; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_deliveries (
+    delivery_id text NOT NULL,
+    event_type text NOT NULL,
+    processed_at timestamp with time zone NOT NULL
+);
+
+
+--
+-- Name: github_deliveries deliveries_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.github_deliveries
+    ADD CONSTRAINT deliveries_pkey PRIMARY KEY (delivery_id);
+`
+
+const deliveriesDown = `
+DROP TABLE public.github_deliveries;
+`
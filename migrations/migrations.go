@@ -0,0 +1,208 @@
+// Package migrations applies versioned schema migrations of the metadata database.
+//
+// The applied version is stored in schema_migrations (version, dirty) in the golang-migrate format.
+// A migration which fails half way leaves the schema dirty. Dirty schema has to be fixed by hand
+// and forced to the right version (see Force), before any other migration can run.
+//
+// schema.sql is the pg_dump of the fully migrated database. Every schema change needs a new migration
+// (never edit the applied ones) registered in the migrations list and the updated schema.sql.
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+)
+
+// Migration is a single schema change.
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+// migrations sorted by version.
+var migrations = []Migration{
+	{1, "initial", initialUp, initialDown},
+	{2, "commits", commitsUp, commitsDown},
+	{3, "refs", refsUp, refsDown},
+	{4, "tombstones", tombstonesUp, tombstonesDown},
+	{5, "history", historyUp, historyDown},
+	{6, "skipped_versions", skippedVersionsUp, skippedVersionsDown},
+	{7, "state_transitions", stateTransitionsUp, stateTransitionsDown},
+	{8, "pull_request_pushes", pullRequestPushesUp, pullRequestPushesDown},
+	{9, "deliveries", deliveriesUp, deliveriesDown},
+	{10, "failed_events", failedEventsUp, failedEventsDown},
+	{11, "refresh_views", refreshViewsUp, refreshViewsDown},
+	{12, "backfill", backfillUp, backfillDown},
+	{13, "reconcile", reconcileUp, reconcileDown},
+	{14, "installations", installationsUp, installationsDown},
+	{15, "uninstall", uninstallUp, uninstallDown},
+	{16, "releases", releasesUp, releasesDown},
+	{17, "checks", checksUp, checksDown},
+	{18, "deployments", deploymentsUp, deploymentsDown},
+	{19, "labels", labelsUp, labelsDown},
+	{20, "issue_changes", issueChangesUp, issueChangesDown},
+	{21, "deliveries_ttl", deliveriesTTLUp, deliveriesTTLDown},
+	{22, "ref_tombstones", refTombstonesUp, refTombstonesDown},
+	{23, "labels_updated_at", labelsUpdatedAtUp, labelsUpdatedAtDown},
+}
+
+var (
+	// ErrDirty means the previous migration failed half way.
+	ErrDirty = errors.New("schema is dirty")
+	// ErrNewerSchema means the database was migrated by a newer version of the service.
+	ErrNewerSchema = errors.New("schema is newer than known migrations")
+)
+
+// lockID is the key of the advisory lock, so only one instance migrates at a time.
+const lockID = 7364830279452751
+
+// Latest returns the version of the last known migration.
+func Latest() uint {
+	return migrations[len(migrations)-1].Version
+}
+
+// Version returns the current version of the schema (0 if no migration was applied).
+func Version(ctx context.Context, db *sql.DB) (uint, bool, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+	defer conn.Close()
+
+	if err = ensureTable(ctx, conn); err != nil {
+		return 0, false, err
+	}
+	return version(ctx, conn)
+}
+
+// Up applies all pending migrations.
+func Up(ctx context.Context, db *sql.DB) error {
+	return Migrate(ctx, db, Latest())
+}
+
+// Migrate migrates the schema up or down to the target version (0 reverts all migrations).
+// It refuses to run against a dirty schema or a schema newer than the latest known migration.
+func Migrate(ctx context.Context, db *sql.DB, target uint) error {
+	if target > Latest() {
+		return fmt.Errorf("unknown version: %d", target)
+	}
+
+	return withLock(ctx, db, func(conn *sql.Conn) error {
+		current, dirty, err := version(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return fmt.Errorf("%v: version %d", ErrDirty, current)
+		}
+		if current > Latest() {
+			return fmt.Errorf("%v: version %d, latest %d", ErrNewerSchema, current, Latest())
+		}
+
+		for _, m := range migrations {
+			if m.Version <= current || m.Version > target {
+				continue
+			}
+			if err = run(ctx, conn, m.Version, m.Up, m); err != nil {
+				return err
+			}
+		}
+		for i := len(migrations) - 1; i >= 0; i-- {
+			m := migrations[i]
+			if m.Version > current || m.Version <= target {
+				continue
+			}
+			var prev uint
+			if i > 0 {
+				prev = migrations[i-1].Version
+			}
+			if err = run(ctx, conn, prev, m.Down, m); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Force sets the schema version and clears the dirty flag without running any migration
+// (e.g. after the failed migration was fixed by hand, or to baseline an existing database).
+func Force(ctx context.Context, db *sql.DB, version uint) error {
+	return withLock(ctx, db, func(conn *sql.Conn) error {
+		return setVersion(ctx, conn, version, false)
+	})
+}
+
+// run executes the migration query, marking the schema dirty until it succeeds.
+func run(ctx context.Context, conn *sql.Conn, version uint, query string, m Migration) error {
+	log.Printf("migrating schema to version %d (%d_%s)\n", version, m.Version, m.Name)
+	if err := setVersion(ctx, conn, version, true); err != nil {
+		return err
+	}
+	if _, err := conn.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("migration %d_%s: %v", m.Version, m.Name, err)
+	}
+	return setVersion(ctx, conn, version, false)
+}
+
+func withLock(ctx context.Context, db *sql.DB, fn func(conn *sql.Conn) error) error {
+	// Advisory locks are held by the session, so everything runs on a single connection.
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err = conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, lockID); err != nil {
+		return err
+	}
+	defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, lockID)
+
+	if err = ensureTable(ctx, conn); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+func ensureTable(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS public.schema_migrations (
+	version bigint NOT NULL PRIMARY KEY,
+	dirty boolean NOT NULL
+)`)
+	return err
+}
+
+func version(ctx context.Context, conn *sql.Conn) (uint, bool, error) {
+	var (
+		v     int64
+		dirty bool
+	)
+	err := conn.QueryRowContext(ctx, `SELECT version, dirty FROM public.schema_migrations LIMIT 1`).Scan(&v, &dirty)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	return uint(v), dirty, err
+}
+
+func setVersion(ctx context.Context, conn *sql.Conn, version uint, dirty bool) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, `DELETE FROM public.schema_migrations`); err != nil {
+		tx.Rollback()
+		return err
+	}
+	// Like golang-migrate, version 0 (no migration) is stored as an empty table.
+	if version > 0 || dirty {
+		if _, err = tx.ExecContext(ctx, `INSERT INTO public.schema_migrations (version, dirty) VALUES ($1, $2)`, int64(version), dirty); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
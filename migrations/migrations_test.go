@@ -0,0 +1,133 @@
+package migrations
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrations(t *testing.T) {
+	require := require.New(t)
+
+	var prev uint
+	for _, m := range migrations {
+		require.True(m.Version > prev, "version %d is not greater than %d", m.Version, prev)
+		require.NotEmpty(m.Name)
+		require.NotEmpty(strings.TrimSpace(m.Up), "%d_%s", m.Version, m.Name)
+		require.NotEmpty(strings.TrimSpace(m.Down), "%d_%s", m.Version, m.Name)
+		prev = m.Version
+	}
+	require.Equal(prev, Latest())
+}
+
+// TestSchema replays the initial (baseline) schema and every delta migration and checks
+// that the result is exactly schema.sql.
+func TestSchema(t *testing.T) {
+	require := require.New(t)
+
+	dump, err := ioutil.ReadFile("../schema.sql")
+	require.NoError(err)
+
+	var s replayedSchema
+	for _, m := range migrations {
+		require.NoError(s.apply(m.Up), "%d_%s", m.Version, m.Name)
+	}
+
+	var expected replayedSchema
+	require.NoError(expected.apply(string(dump)))
+	// schema_migrations is created by the migrations package itself.
+	require.NoError(expected.apply("DROP TABLE public.schema_migrations;"))
+
+	require.Equal(expected.statements(), s.statements())
+}
+
+// schemaObject is a table, view, constraint or index and the statement which creates it.
+type schemaObject struct {
+	stmt string
+	// owner is the table (or materialized view) of a constraint or an index.
+	owner string
+}
+
+// replayedSchema is the schema built by applying the statements of the migrations one by one,
+// the way pg_dump would print it.
+type replayedSchema struct {
+	objects map[string]*schemaObject
+}
+
+var (
+	reComment      = regexp.MustCompile(`(?m)^--.*\n`)
+	reCreateTable  = regexp.MustCompile(`^CREATE TABLE public\.(\w+) \(`)
+	reCreateView   = regexp.MustCompile(`^CREATE (?:OR REPLACE )?(VIEW|MATERIALIZED VIEW) public\.(\w+) AS`)
+	reCreateIndex  = regexp.MustCompile(`^CREATE (?:UNIQUE )?INDEX (\w+) ON public\.(\w+) `)
+	reAddConstr    = regexp.MustCompile(`^ALTER TABLE ONLY public\.(\w+)\s+ADD CONSTRAINT (\w+) `)
+	reAddColumn    = regexp.MustCompile(`^ALTER TABLE public\.(\w+) ADD COLUMN (.+)$`)
+	reDropNotNull  = regexp.MustCompile(`^ALTER TABLE public\.(\w+) ALTER COLUMN (\w+) DROP NOT NULL$`)
+	reDrop         = regexp.MustCompile(`^DROP (TABLE|VIEW|MATERIALIZED VIEW) public\.(\w+)$`)
+	reIgnoredStmts = regexp.MustCompile(`^(SET |SELECT pg_catalog\.set_config)`)
+)
+
+func (s *replayedSchema) apply(sql string) error {
+	if s.objects == nil {
+		s.objects = map[string]*schemaObject{}
+	}
+	for _, stmt := range strings.Split(reComment.ReplaceAllString(sql, ""), ";\n") {
+		stmt = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(stmt), ";"))
+		if stmt == "" || reIgnoredStmts.MatchString(stmt) {
+			continue
+		}
+		if m := reCreateTable.FindStringSubmatch(stmt); m != nil {
+			s.objects["TABLE "+m[1]] = &schemaObject{stmt: stmt}
+		} else if m := reCreateView.FindStringSubmatch(stmt); m != nil {
+			stmt = strings.Replace(stmt, "CREATE OR REPLACE VIEW", "CREATE VIEW", 1)
+			s.objects[m[1]+" "+m[2]] = &schemaObject{stmt: stmt}
+		} else if m := reCreateIndex.FindStringSubmatch(stmt); m != nil {
+			s.objects["INDEX "+m[1]] = &schemaObject{stmt: stmt, owner: m[2]}
+		} else if m := reAddConstr.FindStringSubmatch(stmt); m != nil {
+			s.objects["CONSTRAINT "+m[2]] = &schemaObject{stmt: stmt, owner: m[1]}
+		} else if m := reAddColumn.FindStringSubmatch(stmt); m != nil {
+			table, ok := s.objects["TABLE "+m[1]]
+			if !ok {
+				return fmt.Errorf("unknown table: %s", stmt)
+			}
+			// pg_dump prints added columns last.
+			table.stmt = strings.TrimSuffix(table.stmt, "\n)") + ",\n    " + m[2] + "\n)"
+		} else if m := reDropNotNull.FindStringSubmatch(stmt); m != nil {
+			table, ok := s.objects["TABLE "+m[1]]
+			if !ok {
+				return fmt.Errorf("unknown table: %s", stmt)
+			}
+			// pg_dump prints the column in place, without the constraint.
+			reColumn := regexp.MustCompile(`(?m)^(    ` + m[2] + ` .+) NOT NULL(,?)$`)
+			if !reColumn.MatchString(table.stmt) {
+				return fmt.Errorf("unknown NOT NULL column: %s", stmt)
+			}
+			table.stmt = reColumn.ReplaceAllString(table.stmt, "$1$2")
+		} else if m := reDrop.FindStringSubmatch(stmt); m != nil {
+			if _, ok := s.objects[m[1]+" "+m[2]]; !ok {
+				return fmt.Errorf("unknown object: %s", stmt)
+			}
+			delete(s.objects, m[1]+" "+m[2])
+			for key, obj := range s.objects {
+				if obj.owner == m[2] {
+					delete(s.objects, key)
+				}
+			}
+		} else {
+			return fmt.Errorf("unsupported statement: %s", stmt)
+		}
+	}
+	return nil
+}
+
+// statements returns the statements creating the objects by the object.
+func (s *replayedSchema) statements() map[string]string {
+	res := make(map[string]string, len(s.objects))
+	for key, obj := range s.objects {
+		res[key] = obj.stmt
+	}
+	return res
+}
@@ -0,0 +1,182 @@
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	msgExt = ".msg"
+	tmpExt = ".tmp"
+
+	// parkedDir is the subdirectory of messages which failed MaxAttempts times.
+	parkedDir = "parked"
+)
+
+// Filesystem is a message bus backed by a local directory, where every message is stored in a separate file.
+// Messages survive restarts and are removed only after the subscriber succeeds.
+// Messages which failed MaxAttempts times are moved to the parked subdirectory, where they can be inspected
+// and moved back to be redelivered. The directory should be consumed by a single consumer.
+type Filesystem struct {
+	// PollInterval is how often the consumer looks for new (or failed) messages.
+	PollInterval time.Duration
+	// Backoff of redeliveries of messages which the subscriber fails to process.
+	Backoff Backoff
+	// MaxAttempts is the number of failed attempts after which the message is parked, 0 means never.
+	// Attempts are counted since the consumer started.
+	MaxAttempts int
+
+	dir string
+	seq uint64
+
+	// failures of messages by the file name, only the consumer accesses them.
+	failures map[string]*failure
+}
+
+// failure is the number of failed attempts to deliver the message and the time of its next attempt.
+type failure struct {
+	attempts int
+	next     time.Time
+}
+
+// NewFilesystem creates a new message bus in the given directory.
+// The directory (and its parked subdirectory) is created if it does not exist.
+func NewFilesystem(dir string) (*Filesystem, error) {
+	if err := os.MkdirAll(filepath.Join(dir, parkedDir), 0755); err != nil {
+		return nil, err
+	}
+	return &Filesystem{
+		PollInterval: time.Second,
+		Backoff:      DefaultBackoff,
+		MaxAttempts:  20,
+		dir:          dir,
+		failures:     make(map[string]*failure),
+	}, nil
+}
+
+// Publish the message to a new file. The file is written atomically (temporary file + rename),
+// and file names keep the order of publishing.
+func (f *Filesystem) Publish(ctx context.Context, msg Message) error {
+	buf, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%020d-%010d", time.Now().UnixNano(), atomic.AddUint64(&f.seq, 1))
+	tmp := filepath.Join(f.dir, name+tmpExt)
+	if err = ioutil.WriteFile(tmp, buf, 0644); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp, filepath.Join(f.dir, name+msgExt)); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// Consume polls the directory and delivers messages in the order of publishing.
+// Messages which the subscriber fails to process stay in the directory and are redelivered by the first poll
+// after the backoff, until they are parked.
+func (f *Filesystem) Consume(ctx context.Context, subscriber Subscriber) error {
+	for {
+		names, err := f.messages()
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for _, name := range names {
+			if ctx.Err() != nil {
+				return nil
+			}
+			if fl, ok := f.failures[name]; ok && now.Before(fl.next) {
+				continue
+			}
+			if err = f.deliver(ctx, name, subscriber); err != nil {
+				f.failed(name, err)
+			} else {
+				delete(f.failures, name)
+			}
+		}
+
+		select {
+		case <-time.After(f.PollInterval):
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (f *Filesystem) deliver(ctx context.Context, name string, subscriber Subscriber) error {
+	path := filepath.Join(f.dir, name)
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var msg Message
+	if err = json.Unmarshal(buf, &msg); err != nil {
+		return err
+	}
+
+	if err = subscriber(ctx, msg); err != nil {
+		return err
+	}
+
+	if err = os.Remove(path); err != nil {
+		log.Printf("remove file: %s, error: %v\n", path, err)
+	}
+	return nil
+}
+
+// failed counts the failed attempt to deliver the message and schedules its redelivery,
+// or parks the message if it failed MaxAttempts times.
+func (f *Filesystem) failed(name string, err error) {
+	path := filepath.Join(f.dir, name)
+	fl, ok := f.failures[name]
+	if !ok {
+		fl = &failure{}
+		f.failures[name] = fl
+	}
+	fl.attempts++
+
+	if f.MaxAttempts > 0 && fl.attempts >= f.MaxAttempts {
+		delete(f.failures, name)
+		if rerr := os.Rename(path, filepath.Join(f.dir, parkedDir, name)); rerr != nil {
+			log.Printf("park file: %s, error: %v\n", path, rerr)
+			return
+		}
+		log.Printf("parked file: %s, attempts: %d, error: %v\n", path, fl.attempts, err)
+		return
+	}
+
+	delay := f.Backoff.Delay(fl.attempts)
+	fl.next = time.Now().Add(delay)
+	log.Printf("consume file: %s, attempt: %d, redelivery in: %v, error: %v\n", path, fl.attempts, delay, err)
+}
+
+// messages returns sorted names of the message files.
+func (f *Filesystem) messages() ([]string, error) {
+	infos, err := ioutil.ReadDir(f.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, info := range infos {
+		if !info.IsDir() && strings.HasSuffix(info.Name(), msgExt) {
+			names = append(names, info.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
@@ -0,0 +1,109 @@
+package pubsub
+
+import (
+	"context"
+	"log"
+	"os"
+
+	gcp "cloud.google.com/go/pubsub"
+)
+
+// projectID is set from the GCP_PROJECT environment variable, which is
+// automatically set by the Cloud Functions runtime.
+var projectID = os.Getenv("GCP_PROJECT")
+
+// GCPPublisher is Google Pub/Sub publisher.
+type GCPPublisher struct {
+	topic *gcp.Topic
+}
+
+// NewGCPPublisher creates a new instance of Pub/Sub publisher.
+// It also creates the Pub/Sub topic if it does not exist.
+func NewGCPPublisher(topicID string) (*GCPPublisher, error) {
+	ctx := context.Background()
+
+	client, err := gcp.NewClient(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	topic, err := gcpTopic(ctx, client, topicID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GCPPublisher{topic: topic}, nil
+}
+
+// Publish the message to the Pub/Sub topic synchronously.
+func (p *GCPPublisher) Publish(ctx context.Context, msg Message) error {
+	id, err := p.topic.Publish(ctx, &gcp.Message{Data: msg.Data, Attributes: msg.Attributes}).Get(ctx)
+	if err != nil {
+		log.Printf("publish id: %s, data: %s, attributes: %v, error: %v\n", id, string(msg.Data), msg.Attributes, err)
+	}
+	return err
+}
+
+// GCPConsumer is Google Pub/Sub pull subscriber.
+type GCPConsumer struct {
+	subscription *gcp.Subscription
+}
+
+// NewGCPConsumer creates a new instance of Pub/Sub pull subscriber.
+// It also creates the Pub/Sub topic and subscription if they do not exist.
+func NewGCPConsumer(topicID, subscriptionID string) (*GCPConsumer, error) {
+	ctx := context.Background()
+
+	client, err := gcp.NewClient(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	topic, err := gcpTopic(ctx, client, topicID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create the subscription if it doesn't exist.
+	subscription := client.Subscription(subscriptionID)
+	exists, err := subscription.Exists(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		subscription, err = client.CreateSubscription(ctx, subscriptionID, gcp.SubscriptionConfig{Topic: topic})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &GCPConsumer{subscription: subscription}, nil
+}
+
+// Consume receives messages from the Pub/Sub subscription.
+// Messages are acknowledged if the subscriber succeeds, otherwise they are redelivered by Pub/Sub.
+func (c *GCPConsumer) Consume(ctx context.Context, subscriber Subscriber) error {
+	return c.subscription.Receive(ctx, func(ctx context.Context, msg *gcp.Message) {
+		if err := subscriber(ctx, Message{Data: msg.Data, Attributes: msg.Attributes}); err != nil {
+			log.Printf("consume id: %s, error: %v\n", msg.ID, err)
+			msg.Nack()
+			return
+		}
+		msg.Ack()
+	})
+}
+
+// gcpTopic returns the Pub/Sub topic and creates it if it does not exist.
+func gcpTopic(ctx context.Context, client *gcp.Client, topicID string) (*gcp.Topic, error) {
+	topic := client.Topic(topicID)
+	exists, err := topic.Exists(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		if _, err = client.CreateTopic(ctx, topicID); err != nil {
+			return nil, err
+		}
+	}
+	return topic, nil
+}
@@ -0,0 +1,125 @@
+package pubsub
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Memory is an in-process message bus backed by a buffered channel.
+// It is meant for running the webhook and the processor in a single process (locally or in CI).
+type Memory struct {
+	// Backoff of redeliveries of messages which the subscriber fails to process.
+	Backoff Backoff
+
+	ch chan delivery
+
+	mu sync.Mutex
+	// redeliveries are failed messages waiting for the backoff.
+	redeliveries map[*time.Timer]delivery
+}
+
+// delivery is the message and the number of its failed attempts.
+type delivery struct {
+	msg      Message
+	attempts int
+}
+
+// NewMemory creates a new in-process message bus which buffers up to size messages.
+func NewMemory(size int) *Memory {
+	return &Memory{
+		Backoff:      DefaultBackoff,
+		ch:           make(chan delivery, size),
+		redeliveries: make(map[*time.Timer]delivery),
+	}
+}
+
+// Publish the message to the channel. It blocks if the buffer is full.
+func (m *Memory) Publish(ctx context.Context, msg Message) error {
+	data := msg.Data
+	msg.Data = make([]byte, len(data))
+	copy(msg.Data, data)
+
+	select {
+	case m.ch <- delivery{msg: msg}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Consume receives messages from the channel.
+// Messages which the subscriber fails to process are put back to the channel after the backoff
+// and redelivered until they succeed.
+// Once the context is done, buffered messages and messages waiting for the redelivery are delivered
+// (only once) before Consume returns, so they are not lost when the publisher stopped first.
+func (m *Memory) Consume(ctx context.Context, subscriber Subscriber) error {
+	for {
+		select {
+		case d := <-m.ch:
+			if err := subscriber(ctx, d.msg); err != nil {
+				m.redeliver(d, err)
+			}
+		case <-ctx.Done():
+			m.drain(ctx, subscriber)
+			return nil
+		}
+	}
+}
+
+// redeliver puts the failed message back to the channel after the backoff.
+// The message stays in redeliveries until it's sent, and it's sent without blocking: if the buffer is full,
+// it waits for another backoff, so drain takes over the messages which weren't sent yet.
+func (m *Memory) redeliver(d delivery, err error) {
+	d.attempts++
+	delay := m.Backoff.Delay(d.attempts)
+	log.Printf("consume data: %s, attempt: %d, redelivery in: %v, error: %v\n", string(d.msg.Data), d.attempts, delay, err)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var t *time.Timer
+	t = time.AfterFunc(delay, func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if _, ok := m.redeliveries[t]; !ok {
+			// drained
+			return
+		}
+		select {
+		case m.ch <- d:
+			delete(m.redeliveries, t)
+		default:
+			t.Reset(m.Backoff.Min)
+		}
+	})
+	m.redeliveries[t] = d
+}
+
+// drain delivers the buffered messages and the messages waiting for the redelivery.
+// Messages which fail are logged and dropped.
+func (m *Memory) drain(ctx context.Context, subscriber Subscriber) {
+	var pending []delivery
+	m.mu.Lock()
+	for t, d := range m.redeliveries {
+		t.Stop()
+		pending = append(pending, d)
+		delete(m.redeliveries, t)
+	}
+	m.mu.Unlock()
+
+	for {
+		var d delivery
+		select {
+		case d = <-m.ch:
+		default:
+			if len(pending) == 0 {
+				return
+			}
+			d, pending = pending[0], pending[1:]
+		}
+		if err := subscriber(ctx, d.msg); err != nil {
+			log.Printf("drop data: %s, attempt: %d, error: %v\n", string(d.msg.Data), d.attempts+1, err)
+		}
+	}
+}
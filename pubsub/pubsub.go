@@ -0,0 +1,53 @@
+package pubsub
+
+import (
+	"context"
+	"time"
+)
+
+// Message is the payload of a Pub/Sub event.
+type Message struct {
+	Data       []byte            `json:"data"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// Subscriber is Pub/Sub push subscriber.
+type Subscriber func(ctx context.Context, msg Message) error
+
+// Publisher publishes messages to the message bus.
+type Publisher interface {
+	// Publish the message to the message bus synchronously.
+	Publish(ctx context.Context, msg Message) error
+}
+
+// Consumer delivers messages from the message bus to the subscriber.
+type Consumer interface {
+	// Consume blocks and calls the subscriber for every received message until the context is done.
+	// Then it stops receiving and returns once the in-flight calls of the subscriber returned.
+	// The subscriber is called with the context, so a subscriber, which should finish the in-flight messages
+	// after it's done (e.g. on graceful shutdown), processes them with its own context.
+	// A message is delivered at least once, so if the subscriber fails, the message may be redelivered.
+	Consume(ctx context.Context, subscriber Subscriber) error
+}
+
+// Backoff is the delay of the redelivery of a failed message, which doubles with every failed attempt
+// from Min up to Max.
+type Backoff struct {
+	Min time.Duration
+	Max time.Duration
+}
+
+// DefaultBackoff is the backoff of the memory and filesystem buses, like the default retry policy of GCP Pub/Sub.
+var DefaultBackoff = Backoff{Min: 10 * time.Second, Max: 10 * time.Minute}
+
+// Delay returns the delay of the redelivery after the given number of failed attempts.
+func (b Backoff) Delay(attempts int) time.Duration {
+	d := b.Min
+	for i := 1; i < attempts && d < b.Max; i++ {
+		d *= 2
+	}
+	if d > b.Max {
+		d = b.Max
+	}
+	return d
+}
@@ -0,0 +1,237 @@
+package pubsub
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	fuzz "github.com/google/gofuzz"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	_ Publisher = (*GCPPublisher)(nil)
+	_ Consumer  = (*GCPConsumer)(nil)
+	_ Publisher = (*Memory)(nil)
+	_ Consumer  = (*Memory)(nil)
+	_ Publisher = (*Filesystem)(nil)
+	_ Consumer  = (*Filesystem)(nil)
+)
+
+func TestMemory(t *testing.T) {
+	m := NewMemory(32)
+	testBus(t, m, m)
+}
+
+func TestMemoryDrain(t *testing.T) {
+	require := require.New(t)
+
+	m := NewMemory(32)
+	m.Backoff = Backoff{Min: time.Hour, Max: time.Hour}
+
+	require.NoError(m.Publish(context.TODO(), Message{Data: []byte("failed")}))
+	require.NoError(m.Publish(context.TODO(), Message{Data: []byte("buffered")}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var delivered []string
+	err := m.Consume(ctx, func(ctx context.Context, msg Message) error {
+		delivered = append(delivered, string(msg.Data))
+		if len(delivered) == 1 {
+			// the buffered message and the one waiting for the redelivery are drained.
+			cancel()
+			return errors.New("temporary failure")
+		}
+		return nil
+	})
+	require.NoError(err)
+	require.Equal([]string{"failed", "buffered", "failed"}, delivered)
+}
+
+// TestMemoryDrainRedelivery checks that the message, whose redelivery is due while the buffer is full,
+// isn't lost when the consumer drains.
+func TestMemoryDrainRedelivery(t *testing.T) {
+	require := require.New(t)
+
+	m := NewMemory(1)
+	m.Backoff = Backoff{Min: 20 * time.Millisecond, Max: 20 * time.Millisecond}
+	require.NoError(m.Publish(context.TODO(), Message{Data: []byte("failed")}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var delivered []string
+	err := m.Consume(ctx, func(ctx context.Context, msg Message) error {
+		delivered = append(delivered, string(msg.Data))
+		switch string(msg.Data) {
+		case "failed":
+			if len(delivered) == 1 {
+				require.NoError(m.Publish(context.TODO(), Message{Data: []byte("slow")}))
+				return errors.New("temporary failure")
+			}
+		case "slow":
+			// the redelivery is due while the buffer is full, then the consumer drains
+			require.NoError(m.Publish(context.TODO(), Message{Data: []byte("buffered")}))
+			time.Sleep(100 * time.Millisecond)
+			cancel()
+		}
+		return nil
+	})
+	require.NoError(err)
+	require.ElementsMatch([]string{"failed", "slow", "buffered", "failed"}, delivered)
+}
+
+func TestFilesystem(t *testing.T) {
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "pubsub")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	fs, err := NewFilesystem(dir)
+	require.NoError(err)
+	fs.PollInterval = 10 * time.Millisecond
+
+	testBus(t, fs, fs)
+}
+
+func TestMemoryRedelivery(t *testing.T) {
+	require := require.New(t)
+
+	m := NewMemory(32)
+	m.Backoff = Backoff{Min: time.Millisecond, Max: 10 * time.Millisecond}
+
+	require.NoError(m.Publish(context.TODO(), Message{Data: []byte("redelivered")}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	attempts := 0
+	err := m.Consume(ctx, func(ctx context.Context, msg Message) error {
+		attempts++
+		require.Equal("redelivered", string(msg.Data))
+		if attempts < 3 {
+			return errors.New("temporary failure")
+		}
+		cancel()
+		return nil
+	})
+	require.NoError(err)
+	require.Equal(3, attempts)
+}
+
+func TestFilesystemRedelivery(t *testing.T) {
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "pubsub")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	fs, err := NewFilesystem(dir)
+	require.NoError(err)
+	fs.PollInterval = 10 * time.Millisecond
+	fs.Backoff = Backoff{Min: time.Millisecond, Max: 10 * time.Millisecond}
+
+	require.NoError(fs.Publish(context.TODO(), Message{Data: []byte("redelivered")}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	attempts := 0
+	err = fs.Consume(ctx, func(ctx context.Context, msg Message) error {
+		attempts++
+		if attempts < 3 {
+			return errors.New("temporary failure")
+		}
+		require.Equal("redelivered", string(msg.Data))
+		cancel()
+		return nil
+	})
+	require.NoError(err)
+	require.Equal(3, attempts)
+
+	names, err := fs.messages()
+	require.NoError(err)
+	require.Empty(names)
+}
+
+func TestFilesystemParked(t *testing.T) {
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "pubsub")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	fs, err := NewFilesystem(dir)
+	require.NoError(err)
+	fs.PollInterval = 10 * time.Millisecond
+	fs.Backoff = Backoff{Min: time.Millisecond, Max: 10 * time.Millisecond}
+	fs.MaxAttempts = 3
+
+	require.NoError(fs.Publish(context.TODO(), Message{Data: []byte("poison")}))
+	require.NoError(fs.Publish(context.TODO(), Message{Data: []byte("healthy")}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	attempts := 0
+	err = fs.Consume(ctx, func(ctx context.Context, msg Message) error {
+		if string(msg.Data) == "healthy" {
+			return nil
+		}
+		attempts++
+		if attempts == fs.MaxAttempts {
+			cancel()
+		}
+		return errors.New("permanent failure")
+	})
+	require.NoError(err)
+	require.Equal(3, attempts)
+
+	names, err := fs.messages()
+	require.NoError(err)
+	require.Empty(names)
+
+	parked, err := ioutil.ReadDir(filepath.Join(dir, parkedDir))
+	require.NoError(err)
+	require.Len(parked, 1)
+	buf, err := ioutil.ReadFile(filepath.Join(dir, parkedDir, parked[0].Name()))
+	require.NoError(err)
+	require.Contains(string(buf), base64.StdEncoding.EncodeToString([]byte("poison")))
+}
+
+func TestBackoff(t *testing.T) {
+	b := Backoff{Min: time.Second, Max: 5 * time.Second}
+	require.Equal(t, time.Second, b.Delay(1))
+	require.Equal(t, 2*time.Second, b.Delay(2))
+	require.Equal(t, 4*time.Second, b.Delay(3))
+	require.Equal(t, 5*time.Second, b.Delay(4))
+	require.Equal(t, 5*time.Second, b.Delay(100))
+}
+
+func testBus(t *testing.T, pub Publisher, con Consumer) {
+	require := require.New(t)
+
+	var src []Message
+	fuzz.New().NilChance(0).NumElements(1, 32).Fuzz(&src)
+
+	for _, msg := range src {
+		require.NoError(pub.Publish(context.TODO(), msg))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var dst []Message
+	err := con.Consume(ctx, func(ctx context.Context, msg Message) error {
+		dst = append(dst, msg)
+		if len(dst) == len(src) {
+			cancel()
+		}
+		return nil
+	})
+	require.NoError(err)
+	require.Equal(src, dst)
+}
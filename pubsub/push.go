@@ -0,0 +1,43 @@
+package pubsub
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+// pushRequest is the body of the request sent by a Pub/Sub push subscription.
+type pushRequest struct {
+	Message struct {
+		Data       []byte            `json:"data"`
+		Attributes map[string]string `json:"attributes"`
+		MessageID  string            `json:"messageId"`
+	} `json:"message"`
+	Subscription string `json:"subscription"`
+}
+
+// PushHandler is the implementation of http.Handler for Pub/Sub push subscriptions.
+// The message is acknowledged (2xx) if the subscriber succeeds, otherwise Pub/Sub redelivers it.
+type PushHandler struct {
+	Subscriber Subscriber
+}
+
+func (h *PushHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "invalid method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req pushRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Subscriber(r.Context(), Message{Data: req.Message.Data, Attributes: req.Message.Attributes}); err != nil {
+		log.Printf("push id: %s, subscription: %s, error: %v\n", req.Message.MessageID, req.Subscription, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,68 @@
+package pubsub
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPushHandler(t *testing.T) {
+	require := require.New(t)
+
+	tests := []struct {
+		name         string
+		method       string
+		body         string
+		err          error
+		responseCode int
+	}{
+		{
+			name:         "Valid message",
+			method:       "POST",
+			body:         `{"message": {"data": "ZGF0YQ==", "attributes": {"key": "value"}, "messageId": "1"}, "subscription": "sub"}`,
+			responseCode: http.StatusNoContent,
+		},
+		{
+			name:         "Subscriber failure",
+			method:       "POST",
+			body:         `{"message": {"data": "ZGF0YQ==", "messageId": "2"}, "subscription": "sub"}`,
+			err:          errors.New("failure"),
+			responseCode: http.StatusInternalServerError,
+		},
+		{
+			name:         "Invalid body",
+			method:       "POST",
+			body:         `{"message": `,
+			responseCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Invalid method",
+			method:       "GET",
+			responseCode: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h := &PushHandler{
+				Subscriber: func(ctx context.Context, msg Message) error {
+					require.Equal("data", string(msg.Data))
+					if tc.err == nil {
+						require.Equal(map[string]string{"key": "value"}, msg.Attributes)
+					}
+					return tc.err
+				},
+			}
+
+			req := httptest.NewRequest(tc.method, "/", bytes.NewBufferString(tc.body))
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			require.Equal(tc.responseCode, w.Code)
+		})
+	}
+}
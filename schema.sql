@@ -15,6 +15,497 @@ SET xmloption = content;
 SET client_min_messages = warning;
 SET row_security = off;
 
+--
+-- Name: backfill_checkpoints; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.backfill_checkpoints (
+    repository_fullname text NOT NULL,
+    stage text NOT NULL,
+    page integer NOT NULL,
+    done boolean NOT NULL,
+    updated_at timestamp with time zone NOT NULL
+);
+
+
+--
+-- Name: deployments; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.deployments AS
+ SELECT d.repository_owner,
+    d.repository_name,
+    d.repository_fullname,
+    d.id,
+    d.sha,
+    d.ref,
+    d.task,
+    d.environment,
+    d.created_at,
+    d.creator_id AS author_id,
+    d.creator_login AS author_login,
+    s.state,
+    s.finished_at,
+    d.sum256,
+    d.installation_id
+   FROM (public.github_deployments_versioned d
+     LEFT JOIN LATERAL ( SELECT st.state,
+            st.created_at AS finished_at
+           FROM public.github_deployment_statuses_versioned st
+          WHERE ((st.repository_fullname = d.repository_fullname) AND (st.deployment_id = d.id) AND (st.state = ANY (ARRAY['success'::text, 'failure'::text, 'error'::text])))
+          ORDER BY st.created_at
+         LIMIT 1) s ON (true))
+  WITH NO DATA;
+
+
+--
+-- Name: failed_events; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.failed_events (
+    delivery_id text NOT NULL,
+    event_type text NOT NULL,
+    payload bytea NOT NULL,
+    error text NOT NULL,
+    permanent boolean NOT NULL,
+    attempts integer NOT NULL,
+    first_seen_at timestamp with time zone NOT NULL,
+    last_seen_at timestamp with time zone NOT NULL,
+    parked_at timestamp with time zone
+);
+
+
+--
+-- Name: github_check_runs_history; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_check_runs_history (
+    version integer NOT NULL,
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    app_id bigint,
+    app_name text,
+    check_suite_id bigint,
+    completed_at timestamp with time zone,
+    conclusion text,
+    details_url text,
+    external_id text,
+    head_sha text NOT NULL,
+    htmlurl text,
+    id bigint,
+    name text NOT NULL,
+    node_id text,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL,
+    started_at timestamp with time zone,
+    status text,
+    installation_id bigint
+);
+
+
+--
+-- Name: github_check_runs_versioned; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_check_runs_versioned (
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    app_id bigint,
+    app_name text,
+    check_suite_id bigint,
+    completed_at timestamp with time zone,
+    conclusion text,
+    details_url text,
+    external_id text,
+    head_sha text NOT NULL,
+    htmlurl text,
+    id bigint,
+    name text NOT NULL,
+    node_id text,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL,
+    started_at timestamp with time zone,
+    status text,
+    installation_id bigint
+);
+
+
+--
+-- Name: github_check_runs; Type: VIEW; Schema: public; Owner: -
+--
+
+CREATE VIEW public.github_check_runs AS
+ SELECT github_check_runs_versioned.app_id,
+    github_check_runs_versioned.app_name,
+    github_check_runs_versioned.check_suite_id,
+    github_check_runs_versioned.completed_at,
+    github_check_runs_versioned.conclusion,
+    github_check_runs_versioned.details_url,
+    github_check_runs_versioned.external_id,
+    github_check_runs_versioned.head_sha,
+    github_check_runs_versioned.htmlurl,
+    github_check_runs_versioned.id,
+    github_check_runs_versioned.name,
+    github_check_runs_versioned.node_id,
+    github_check_runs_versioned.repository_name,
+    github_check_runs_versioned.repository_owner,
+    github_check_runs_versioned.repository_fullname,
+    github_check_runs_versioned.started_at,
+    github_check_runs_versioned.status,
+    github_check_runs_versioned.installation_id
+   FROM public.github_check_runs_versioned;
+
+
+--
+-- Name: github_check_suites_history; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_check_suites_history (
+    version integer NOT NULL,
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    after text,
+    app_id bigint,
+    app_name text,
+    before text,
+    conclusion text,
+    head_branch text,
+    head_sha text NOT NULL,
+    id bigint,
+    node_id text,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL,
+    status text,
+    installation_id bigint
+);
+
+
+--
+-- Name: github_check_suites_versioned; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_check_suites_versioned (
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    after text,
+    app_id bigint,
+    app_name text,
+    before text,
+    conclusion text,
+    head_branch text,
+    head_sha text NOT NULL,
+    id bigint,
+    node_id text,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL,
+    status text,
+    installation_id bigint
+);
+
+
+--
+-- Name: github_check_suites; Type: VIEW; Schema: public; Owner: -
+--
+
+CREATE VIEW public.github_check_suites AS
+ SELECT github_check_suites_versioned.after,
+    github_check_suites_versioned.app_id,
+    github_check_suites_versioned.app_name,
+    github_check_suites_versioned.before,
+    github_check_suites_versioned.conclusion,
+    github_check_suites_versioned.head_branch,
+    github_check_suites_versioned.head_sha,
+    github_check_suites_versioned.id,
+    github_check_suites_versioned.node_id,
+    github_check_suites_versioned.repository_name,
+    github_check_suites_versioned.repository_owner,
+    github_check_suites_versioned.repository_fullname,
+    github_check_suites_versioned.status,
+    github_check_suites_versioned.installation_id
+   FROM public.github_check_suites_versioned;
+
+
+--
+-- Name: github_commits_history; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_commits_history (
+    version integer NOT NULL,
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    added text[] NOT NULL,
+    after text NOT NULL,
+    author_email text,
+    author_name text,
+    before text NOT NULL,
+    committed_at timestamp with time zone,
+    committer_email text,
+    committer_name text,
+    forced boolean,
+    htmlurl text,
+    message text,
+    modified text[] NOT NULL,
+    ref text NOT NULL,
+    removed text[] NOT NULL,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL,
+    sha text NOT NULL,
+    tree_id text,
+    installation_id bigint
+);
+
+
+--
+-- Name: github_commits_versioned; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_commits_versioned (
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    added text[] NOT NULL,
+    after text NOT NULL,
+    author_email text,
+    author_name text,
+    before text NOT NULL,
+    committed_at timestamp with time zone,
+    committer_email text,
+    committer_name text,
+    forced boolean,
+    htmlurl text,
+    message text,
+    modified text[] NOT NULL,
+    ref text NOT NULL,
+    removed text[] NOT NULL,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL,
+    sha text NOT NULL,
+    tree_id text,
+    installation_id bigint
+);
+
+
+--
+-- Name: github_commits; Type: VIEW; Schema: public; Owner: -
+--
+
+CREATE VIEW public.github_commits AS
+ SELECT github_commits_versioned.added,
+    github_commits_versioned.after,
+    github_commits_versioned.author_email,
+    github_commits_versioned.author_name,
+    github_commits_versioned.before,
+    github_commits_versioned.committed_at,
+    github_commits_versioned.committer_email,
+    github_commits_versioned.committer_name,
+    github_commits_versioned.forced,
+    github_commits_versioned.htmlurl,
+    github_commits_versioned.message,
+    github_commits_versioned.modified,
+    github_commits_versioned.ref,
+    github_commits_versioned.removed,
+    github_commits_versioned.repository_name,
+    github_commits_versioned.repository_owner,
+    github_commits_versioned.repository_fullname,
+    github_commits_versioned.sha,
+    github_commits_versioned.tree_id,
+    github_commits_versioned.installation_id
+   FROM public.github_commits_versioned;
+
+
+--
+-- Name: github_deliveries; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_deliveries (
+    delivery_id text NOT NULL,
+    event_type text NOT NULL,
+    processed_at timestamp with time zone NOT NULL
+);
+
+
+--
+-- Name: github_deployment_statuses_history; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_deployment_statuses_history (
+    version integer NOT NULL,
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    created_at timestamp with time zone,
+    creator_id bigint,
+    creator_login text,
+    deployment_id bigint NOT NULL,
+    description text,
+    environment text,
+    id bigint,
+    node_id text,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL,
+    state text NOT NULL,
+    target_url text,
+    updated_at timestamp with time zone,
+    installation_id bigint
+);
+
+
+--
+-- Name: github_deployment_statuses_versioned; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_deployment_statuses_versioned (
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    created_at timestamp with time zone,
+    creator_id bigint,
+    creator_login text,
+    deployment_id bigint NOT NULL,
+    description text,
+    environment text,
+    id bigint,
+    node_id text,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL,
+    state text NOT NULL,
+    target_url text,
+    updated_at timestamp with time zone,
+    installation_id bigint
+);
+
+
+--
+-- Name: github_deployment_statuses; Type: VIEW; Schema: public; Owner: -
+--
+
+CREATE VIEW public.github_deployment_statuses AS
+ SELECT github_deployment_statuses_versioned.created_at,
+    github_deployment_statuses_versioned.creator_id,
+    github_deployment_statuses_versioned.creator_login,
+    github_deployment_statuses_versioned.deployment_id,
+    github_deployment_statuses_versioned.description,
+    github_deployment_statuses_versioned.environment,
+    github_deployment_statuses_versioned.id,
+    github_deployment_statuses_versioned.node_id,
+    github_deployment_statuses_versioned.repository_name,
+    github_deployment_statuses_versioned.repository_owner,
+    github_deployment_statuses_versioned.repository_fullname,
+    github_deployment_statuses_versioned.state,
+    github_deployment_statuses_versioned.target_url,
+    github_deployment_statuses_versioned.updated_at,
+    github_deployment_statuses_versioned.installation_id
+   FROM public.github_deployment_statuses_versioned;
+
+
+--
+-- Name: github_deployments_history; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_deployments_history (
+    version integer NOT NULL,
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    created_at timestamp with time zone,
+    creator_id bigint,
+    creator_login text,
+    description text,
+    environment text,
+    id bigint,
+    node_id text,
+    payload jsonb,
+    ref text,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL,
+    sha text NOT NULL,
+    task text,
+    updated_at timestamp with time zone,
+    installation_id bigint
+);
+
+
+--
+-- Name: github_deployments_versioned; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_deployments_versioned (
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    created_at timestamp with time zone,
+    creator_id bigint,
+    creator_login text,
+    description text,
+    environment text,
+    id bigint,
+    node_id text,
+    payload jsonb,
+    ref text,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL,
+    sha text NOT NULL,
+    task text,
+    updated_at timestamp with time zone,
+    installation_id bigint
+);
+
+
+--
+-- Name: github_deployments; Type: VIEW; Schema: public; Owner: -
+--
+
+CREATE VIEW public.github_deployments AS
+ SELECT github_deployments_versioned.created_at,
+    github_deployments_versioned.creator_id,
+    github_deployments_versioned.creator_login,
+    github_deployments_versioned.description,
+    github_deployments_versioned.environment,
+    github_deployments_versioned.id,
+    github_deployments_versioned.node_id,
+    github_deployments_versioned.payload,
+    github_deployments_versioned.ref,
+    github_deployments_versioned.repository_name,
+    github_deployments_versioned.repository_owner,
+    github_deployments_versioned.repository_fullname,
+    github_deployments_versioned.sha,
+    github_deployments_versioned.task,
+    github_deployments_versioned.updated_at,
+    github_deployments_versioned.installation_id
+   FROM public.github_deployments_versioned;
+
+
+--
+-- Name: github_issue_comments_history; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_issue_comments_history (
+    version integer NOT NULL,
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    author_association text,
+    body text,
+    created_at timestamp with time zone,
+    htmlurl text,
+    id bigint,
+    issue_number bigint NOT NULL,
+    node_id text,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL,
+    updated_at timestamp with time zone,
+    user_id bigint NOT NULL,
+    user_login text NOT NULL,
+    deleted_at timestamp with time zone,
+    installation_id bigint
+);
+
+
 --
 -- Name: github_issue_comments_versioned; Type: TABLE; Schema: public; Owner: -
 --
@@ -34,7 +525,9 @@ CREATE TABLE public.github_issue_comments_versioned (
     repository_fullname text NOT NULL,
     updated_at timestamp with time zone,
     user_id bigint NOT NULL,
-    user_login text NOT NULL
+    user_login text NOT NULL,
+    deleted_at timestamp with time zone,
+    installation_id bigint
 );
 
 
@@ -55,8 +548,46 @@ CREATE VIEW public.github_issue_comments AS
     github_issue_comments_versioned.repository_fullname,
     github_issue_comments_versioned.updated_at,
     github_issue_comments_versioned.user_id,
-    github_issue_comments_versioned.user_login
-   FROM public.github_issue_comments_versioned;
+    github_issue_comments_versioned.user_login,
+    github_issue_comments_versioned.installation_id
+   FROM public.github_issue_comments_versioned
+  WHERE (github_issue_comments_versioned.deleted_at IS NULL);
+
+
+--
+-- Name: github_issues_history; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_issues_history (
+    version integer NOT NULL,
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    assignees text[] NOT NULL,
+    body text,
+    closed_at timestamp with time zone,
+    closed_by_id bigint NOT NULL,
+    closed_by_login text NOT NULL,
+    comments bigint,
+    created_at timestamp with time zone,
+    htmlurl text,
+    id bigint,
+    labels text[] NOT NULL,
+    locked boolean,
+    milestone_id text NOT NULL,
+    milestone_title text NOT NULL,
+    node_id text,
+    number bigint,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL,
+    state text,
+    title text,
+    updated_at timestamp with time zone,
+    user_id bigint NOT NULL,
+    user_login text NOT NULL,
+    deleted_at timestamp with time zone,
+    installation_id bigint
+);
 
 
 --
@@ -88,7 +619,9 @@ CREATE TABLE public.github_issues_versioned (
     title text,
     updated_at timestamp with time zone,
     user_id bigint NOT NULL,
-    user_login text NOT NULL
+    user_login text NOT NULL,
+    deleted_at timestamp with time zone,
+    installation_id bigint
 );
 
 
@@ -119,31 +652,212 @@ CREATE VIEW public.github_issues AS
     github_issues_versioned.title,
     github_issues_versioned.updated_at,
     github_issues_versioned.user_id,
-    github_issues_versioned.user_login
-   FROM public.github_issues_versioned;
+    github_issues_versioned.user_login,
+    github_issues_versioned.installation_id
+   FROM public.github_issues_versioned
+  WHERE (github_issues_versioned.deleted_at IS NULL);
 
 
 --
--- Name: github_organizations_versioned; Type: TABLE; Schema: public; Owner: -
+-- Name: github_labels_history; Type: TABLE; Schema: public; Owner: -
 --
 
-CREATE TABLE public.github_organizations_versioned (
+CREATE TABLE public.github_labels_history (
+    version integer NOT NULL,
     sum256 character varying(64) NOT NULL,
     versions integer[],
-    avatar_url text,
-    collaborators bigint,
-    created_at timestamp with time zone,
+    color text,
     description text,
-    email text,
-    htmlurl text,
     id bigint,
-    login text,
-    name text,
+    name text NOT NULL,
     node_id text,
-    owned_private_repos bigint,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL,
+    deleted_at timestamp with time zone,
+    installation_id bigint,
+    updated_at timestamp with time zone
+);
+
+
+--
+-- Name: github_labels_versioned; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_labels_versioned (
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    color text,
+    description text,
+    id bigint,
+    name text NOT NULL,
+    node_id text,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL,
+    deleted_at timestamp with time zone,
+    installation_id bigint,
+    updated_at timestamp with time zone
+);
+
+
+--
+-- Name: github_labels; Type: VIEW; Schema: public; Owner: -
+--
+
+CREATE VIEW public.github_labels AS
+ SELECT github_labels_versioned.color,
+    github_labels_versioned.description,
+    github_labels_versioned.id,
+    github_labels_versioned.name,
+    github_labels_versioned.node_id,
+    github_labels_versioned.repository_name,
+    github_labels_versioned.repository_owner,
+    github_labels_versioned.repository_fullname,
+    github_labels_versioned.installation_id
+   FROM public.github_labels_versioned
+  WHERE (github_labels_versioned.deleted_at IS NULL);
+
+
+--
+-- Name: github_milestones_history; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_milestones_history (
+    version integer NOT NULL,
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    closed_at timestamp with time zone,
+    closed_issues bigint,
+    created_at timestamp with time zone,
+    creator_id bigint,
+    creator_login text,
+    description text,
+    due_on timestamp with time zone,
+    htmlurl text,
+    id bigint,
+    node_id text,
+    number bigint,
+    open_issues bigint,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL,
+    state text,
+    title text NOT NULL,
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone,
+    installation_id bigint
+);
+
+
+--
+-- Name: github_milestones_versioned; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_milestones_versioned (
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    closed_at timestamp with time zone,
+    closed_issues bigint,
+    created_at timestamp with time zone,
+    creator_id bigint,
+    creator_login text,
+    description text,
+    due_on timestamp with time zone,
+    htmlurl text,
+    id bigint,
+    node_id text,
+    number bigint,
+    open_issues bigint,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL,
+    state text,
+    title text NOT NULL,
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone,
+    installation_id bigint
+);
+
+
+--
+-- Name: github_milestones; Type: VIEW; Schema: public; Owner: -
+--
+
+CREATE VIEW public.github_milestones AS
+ SELECT github_milestones_versioned.closed_at,
+    github_milestones_versioned.closed_issues,
+    github_milestones_versioned.created_at,
+    github_milestones_versioned.creator_id,
+    github_milestones_versioned.creator_login,
+    github_milestones_versioned.description,
+    github_milestones_versioned.due_on,
+    github_milestones_versioned.htmlurl,
+    github_milestones_versioned.id,
+    github_milestones_versioned.node_id,
+    github_milestones_versioned.number,
+    github_milestones_versioned.open_issues,
+    github_milestones_versioned.repository_name,
+    github_milestones_versioned.repository_owner,
+    github_milestones_versioned.repository_fullname,
+    github_milestones_versioned.state,
+    github_milestones_versioned.title,
+    github_milestones_versioned.updated_at,
+    github_milestones_versioned.installation_id
+   FROM public.github_milestones_versioned
+  WHERE (github_milestones_versioned.deleted_at IS NULL);
+
+
+--
+-- Name: github_organizations_history; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_organizations_history (
+    version integer NOT NULL,
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    avatar_url text,
+    collaborators bigint,
+    created_at timestamp with time zone,
+    description text,
+    email text,
+    htmlurl text,
+    id bigint,
+    login text,
+    name text,
+    node_id text,
+    owned_private_repos bigint,
     public_repos bigint,
     total_private_repos bigint,
-    updated_at timestamp with time zone
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone,
+    installation_id bigint
+);
+
+
+--
+-- Name: github_organizations_versioned; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_organizations_versioned (
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    avatar_url text,
+    collaborators bigint,
+    created_at timestamp with time zone,
+    description text,
+    email text,
+    htmlurl text,
+    id bigint,
+    login text,
+    name text,
+    node_id text,
+    owned_private_repos bigint,
+    public_repos bigint,
+    total_private_repos bigint,
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone,
+    installation_id bigint
 );
 
 
@@ -165,8 +879,44 @@ CREATE VIEW public.github_organizations AS
     github_organizations_versioned.owned_private_repos,
     github_organizations_versioned.public_repos,
     github_organizations_versioned.total_private_repos,
-    github_organizations_versioned.updated_at
-   FROM public.github_organizations_versioned;
+    github_organizations_versioned.updated_at,
+    github_organizations_versioned.installation_id
+   FROM public.github_organizations_versioned
+  WHERE (github_organizations_versioned.deleted_at IS NULL);
+
+
+--
+-- Name: github_pull_request_comments_history; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_pull_request_comments_history (
+    version integer NOT NULL,
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    author_association text,
+    body text,
+    commit_id text,
+    created_at timestamp with time zone,
+    diff_hunk text,
+    htmlurl text,
+    id bigint,
+    in_reply_to bigint,
+    node_id text,
+    original_commit_id text,
+    original_position bigint,
+    path text,
+    "position" bigint,
+    pull_request_number bigint NOT NULL,
+    pull_request_review_id bigint,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL,
+    updated_at timestamp with time zone,
+    user_id bigint NOT NULL,
+    user_login text NOT NULL,
+    deleted_at timestamp with time zone,
+    installation_id bigint
+);
 
 
 --
@@ -196,7 +946,9 @@ CREATE TABLE public.github_pull_request_comments_versioned (
     repository_fullname text NOT NULL,
     updated_at timestamp with time zone,
     user_id bigint NOT NULL,
-    user_login text NOT NULL
+    user_login text NOT NULL,
+    deleted_at timestamp with time zone,
+    installation_id bigint
 );
 
 
@@ -225,8 +977,56 @@ CREATE VIEW public.github_pull_request_comments AS
     github_pull_request_comments_versioned.repository_fullname,
     github_pull_request_comments_versioned.updated_at,
     github_pull_request_comments_versioned.user_id,
-    github_pull_request_comments_versioned.user_login
-   FROM public.github_pull_request_comments_versioned;
+    github_pull_request_comments_versioned.user_login,
+    github_pull_request_comments_versioned.installation_id
+   FROM public.github_pull_request_comments_versioned
+  WHERE (github_pull_request_comments_versioned.deleted_at IS NULL);
+
+
+--
+-- Name: github_pull_request_pushes; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_pull_request_pushes (
+    sum256 character varying(64) NOT NULL,
+    after text NOT NULL,
+    before text NOT NULL,
+    pull_request_id bigint NOT NULL,
+    pull_request_number bigint NOT NULL,
+    pull_request_sum256 character varying(64) NOT NULL,
+    pushed_at timestamp with time zone,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL,
+    sender_id bigint NOT NULL,
+    sender_login text NOT NULL,
+    installation_id bigint
+);
+
+
+--
+-- Name: github_pull_request_reviews_history; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_pull_request_reviews_history (
+    version integer NOT NULL,
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    body text,
+    commit_id text,
+    htmlurl text,
+    id bigint,
+    node_id text,
+    pull_request_number bigint NOT NULL,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL,
+    state text,
+    submitted_at timestamp with time zone,
+    user_id bigint NOT NULL,
+    user_login text NOT NULL,
+    installation_id bigint
+);
 
 
 --
@@ -248,7 +1048,8 @@ CREATE TABLE public.github_pull_request_reviews_versioned (
     state text,
     submitted_at timestamp with time zone,
     user_id bigint NOT NULL,
-    user_login text NOT NULL
+    user_login text NOT NULL,
+    installation_id bigint
 );
 
 
@@ -269,10 +1070,68 @@ CREATE VIEW public.github_pull_request_reviews AS
     github_pull_request_reviews_versioned.state,
     github_pull_request_reviews_versioned.submitted_at,
     github_pull_request_reviews_versioned.user_id,
-    github_pull_request_reviews_versioned.user_login
+    github_pull_request_reviews_versioned.user_login,
+    github_pull_request_reviews_versioned.installation_id
    FROM public.github_pull_request_reviews_versioned;
 
 
+--
+-- Name: github_pull_requests_history; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_pull_requests_history (
+    version integer NOT NULL,
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    additions bigint,
+    assignees text[] NOT NULL,
+    author_association text,
+    base_ref text NOT NULL,
+    base_repository_name text NOT NULL,
+    base_repository_owner text NOT NULL,
+    base_repository_fullname text NOT NULL,
+    base_sha text NOT NULL,
+    base_user text NOT NULL,
+    body text,
+    changed_files bigint,
+    closed_at timestamp with time zone,
+    comments bigint,
+    commits bigint,
+    created_at timestamp with time zone,
+    deletions bigint,
+    head_ref text NOT NULL,
+    head_repository_name text NOT NULL,
+    head_repository_owner text NOT NULL,
+    head_repository_fullname text NOT NULL,
+    head_sha text NOT NULL,
+    head_user text NOT NULL,
+    htmlurl text,
+    id bigint,
+    labels text[] NOT NULL,
+    maintainer_can_modify boolean,
+    merge_commit_sha text,
+    mergeable boolean,
+    merged boolean,
+    merged_at timestamp with time zone,
+    merged_by_id bigint NOT NULL,
+    merged_by_login text NOT NULL,
+    milestone_id text NOT NULL,
+    milestone_title text NOT NULL,
+    node_id text,
+    number bigint,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL,
+    review_comments bigint,
+    state text,
+    title text,
+    updated_at timestamp with time zone,
+    user_id bigint NOT NULL,
+    user_login text NOT NULL,
+    installation_id bigint
+);
+
+
 --
 -- Name: github_pull_requests_versioned; Type: TABLE; Schema: public; Owner: -
 --
@@ -324,7 +1183,8 @@ CREATE TABLE public.github_pull_requests_versioned (
     title text,
     updated_at timestamp with time zone,
     user_id bigint NOT NULL,
-    user_login text NOT NULL
+    user_login text NOT NULL,
+    installation_id bigint
 );
 
 
@@ -377,48 +1237,249 @@ CREATE VIEW public.github_pull_requests AS
     github_pull_requests_versioned.title,
     github_pull_requests_versioned.updated_at,
     github_pull_requests_versioned.user_id,
-    github_pull_requests_versioned.user_login
+    github_pull_requests_versioned.user_login,
+    github_pull_requests_versioned.installation_id
    FROM public.github_pull_requests_versioned;
 
 
 --
--- Name: github_repositories_versioned; Type: TABLE; Schema: public; Owner: -
+-- Name: github_refs_history; Type: TABLE; Schema: public; Owner: -
 --
 
-CREATE TABLE public.github_repositories_versioned (
+CREATE TABLE public.github_refs_history (
+    version integer NOT NULL,
     sum256 character varying(64) NOT NULL,
     versions integer[],
-    allow_merge_commit boolean,
-    allow_rebase_merge boolean,
-    allow_squash_merge boolean,
-    archived boolean,
-    clone_url text,
     created_at timestamp with time zone,
-    default_branch text,
-    description text,
-    disabled boolean,
-    fork boolean,
-    forks_count bigint,
-    fullname text,
-    has_issues boolean,
-    has_wiki boolean,
-    homepage text,
-    htmlurl text,
-    id bigint,
-    language text,
-    name text,
-    node_id text,
-    open_issues_count bigint,
-    owner_id bigint NOT NULL,
-    owner_login text NOT NULL,
-    owner_type text NOT NULL,
-    private boolean,
-    pushed_at timestamp with time zone,
+    created_by_id bigint,
+    created_by_login text,
+    deleted_at timestamp with time zone,
+    deleted_by_id bigint,
+    deleted_by_login text,
+    master_branch text,
+    pusher_type text,
+    ref text NOT NULL,
+    ref_type text NOT NULL,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL,
+    installation_id bigint
+);
+
+
+--
+-- Name: github_refs_versioned; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_refs_versioned (
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    created_at timestamp with time zone,
+    created_by_id bigint,
+    created_by_login text,
+    deleted_at timestamp with time zone,
+    deleted_by_id bigint,
+    deleted_by_login text,
+    master_branch text,
+    pusher_type text,
+    ref text NOT NULL,
+    ref_type text NOT NULL,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL,
+    installation_id bigint
+);
+
+
+--
+-- Name: github_refs; Type: VIEW; Schema: public; Owner: -
+--
+
+CREATE VIEW public.github_refs AS
+ SELECT github_refs_versioned.created_at,
+    github_refs_versioned.created_by_id,
+    github_refs_versioned.created_by_login,
+    github_refs_versioned.deleted_at,
+    github_refs_versioned.deleted_by_id,
+    github_refs_versioned.deleted_by_login,
+    github_refs_versioned.master_branch,
+    github_refs_versioned.pusher_type,
+    github_refs_versioned.ref,
+    github_refs_versioned.ref_type,
+    github_refs_versioned.repository_name,
+    github_refs_versioned.repository_owner,
+    github_refs_versioned.repository_fullname,
+    github_refs_versioned.installation_id
+   FROM public.github_refs_versioned;
+
+
+--
+-- Name: github_releases_history; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_releases_history (
+    version integer NOT NULL,
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    author_id bigint,
+    author_login text,
+    body text,
+    created_at timestamp with time zone,
+    draft boolean,
+    htmlurl text,
+    id bigint,
+    name text,
+    node_id text,
+    prerelease boolean,
+    published_at timestamp with time zone,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL,
+    tag_name text NOT NULL,
+    target_commitish text,
+    deleted_at timestamp with time zone,
+    installation_id bigint
+);
+
+
+--
+-- Name: github_releases_versioned; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_releases_versioned (
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    author_id bigint,
+    author_login text,
+    body text,
+    created_at timestamp with time zone,
+    draft boolean,
+    htmlurl text,
+    id bigint,
+    name text,
+    node_id text,
+    prerelease boolean,
+    published_at timestamp with time zone,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL,
+    tag_name text NOT NULL,
+    target_commitish text,
+    deleted_at timestamp with time zone,
+    installation_id bigint
+);
+
+
+--
+-- Name: github_releases; Type: VIEW; Schema: public; Owner: -
+--
+
+CREATE VIEW public.github_releases AS
+ SELECT github_releases_versioned.author_id,
+    github_releases_versioned.author_login,
+    github_releases_versioned.body,
+    github_releases_versioned.created_at,
+    github_releases_versioned.draft,
+    github_releases_versioned.htmlurl,
+    github_releases_versioned.id,
+    github_releases_versioned.name,
+    github_releases_versioned.node_id,
+    github_releases_versioned.prerelease,
+    github_releases_versioned.published_at,
+    github_releases_versioned.repository_name,
+    github_releases_versioned.repository_owner,
+    github_releases_versioned.repository_fullname,
+    github_releases_versioned.tag_name,
+    github_releases_versioned.target_commitish,
+    github_releases_versioned.installation_id
+   FROM public.github_releases_versioned
+  WHERE (github_releases_versioned.deleted_at IS NULL);
+
+
+--
+-- Name: github_repositories_history; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_repositories_history (
+    version integer NOT NULL,
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    allow_merge_commit boolean,
+    allow_rebase_merge boolean,
+    allow_squash_merge boolean,
+    archived boolean,
+    clone_url text,
+    created_at timestamp with time zone,
+    default_branch text,
+    description text,
+    disabled boolean,
+    fork boolean,
+    forks_count bigint,
+    fullname text,
+    has_issues boolean,
+    has_wiki boolean,
+    homepage text,
+    htmlurl text,
+    id bigint,
+    language text,
+    name text,
+    node_id text,
+    open_issues_count bigint,
+    owner_id bigint NOT NULL,
+    owner_login text NOT NULL,
+    owner_type text NOT NULL,
+    private boolean,
+    pushed_at timestamp with time zone,
+    sshurl text,
+    stargazers_count bigint,
+    topics text[] NOT NULL,
+    updated_at timestamp with time zone,
+    watchers_count bigint,
+    deleted_at timestamp with time zone,
+    installation_id bigint
+);
+
+
+--
+-- Name: github_repositories_versioned; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_repositories_versioned (
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    allow_merge_commit boolean,
+    allow_rebase_merge boolean,
+    allow_squash_merge boolean,
+    archived boolean,
+    clone_url text,
+    created_at timestamp with time zone,
+    default_branch text,
+    description text,
+    disabled boolean,
+    fork boolean,
+    forks_count bigint,
+    fullname text,
+    has_issues boolean,
+    has_wiki boolean,
+    homepage text,
+    htmlurl text,
+    id bigint,
+    language text,
+    name text,
+    node_id text,
+    open_issues_count bigint,
+    owner_id bigint NOT NULL,
+    owner_login text NOT NULL,
+    owner_type text NOT NULL,
+    private boolean,
+    pushed_at timestamp with time zone,
     sshurl text,
     stargazers_count bigint,
     topics text[] NOT NULL,
     updated_at timestamp with time zone,
-    watchers_count bigint
+    watchers_count bigint,
+    deleted_at timestamp with time zone,
+    installation_id bigint
 );
 
 
@@ -457,372 +1518,1195 @@ CREATE VIEW public.github_repositories AS
     github_repositories_versioned.stargazers_count,
     github_repositories_versioned.topics,
     github_repositories_versioned.updated_at,
-    github_repositories_versioned.watchers_count
-   FROM public.github_repositories_versioned;
+    github_repositories_versioned.watchers_count,
+    github_repositories_versioned.installation_id
+   FROM public.github_repositories_versioned
+  WHERE (github_repositories_versioned.deleted_at IS NULL);
+
+
+--
+-- Name: github_skipped_versions; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_skipped_versions (
+    table_name text NOT NULL,
+    sum256 character varying(64) NOT NULL,
+    version integer NOT NULL,
+    ordered_by text NOT NULL,
+    ordered_at timestamp with time zone,
+    skipped_at timestamp with time zone NOT NULL
+);
+
+
+--
+-- Name: github_statuses_history; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_statuses_history (
+    version integer NOT NULL,
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    context text NOT NULL,
+    created_at timestamp with time zone,
+    description text,
+    id bigint,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL,
+    sha text NOT NULL,
+    state text NOT NULL,
+    target_url text,
+    updated_at timestamp with time zone,
+    installation_id bigint
+);
+
+
+--
+-- Name: github_statuses_versioned; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_statuses_versioned (
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    context text NOT NULL,
+    created_at timestamp with time zone,
+    description text,
+    id bigint,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL,
+    sha text NOT NULL,
+    state text NOT NULL,
+    target_url text,
+    updated_at timestamp with time zone,
+    installation_id bigint
+);
+
+
+--
+-- Name: github_statuses; Type: VIEW; Schema: public; Owner: -
+--
+
+CREATE VIEW public.github_statuses AS
+ SELECT github_statuses_versioned.context,
+    github_statuses_versioned.created_at,
+    github_statuses_versioned.description,
+    github_statuses_versioned.id,
+    github_statuses_versioned.repository_name,
+    github_statuses_versioned.repository_owner,
+    github_statuses_versioned.repository_fullname,
+    github_statuses_versioned.sha,
+    github_statuses_versioned.state,
+    github_statuses_versioned.target_url,
+    github_statuses_versioned.updated_at,
+    github_statuses_versioned.installation_id
+   FROM public.github_statuses_versioned;
+
+
+--
+-- Name: github_users_history; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_users_history (
+    version integer NOT NULL,
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    avatar_url text,
+    bio text,
+    company text,
+    created_at timestamp with time zone,
+    email text,
+    followers bigint,
+    following bigint,
+    hireable boolean,
+    htmlurl text,
+    id bigint,
+    location text,
+    login text,
+    name text,
+    node_id text,
+    organization_id bigint NOT NULL,
+    organization_login text NOT NULL,
+    owned_private_repos bigint,
+    private_gists bigint,
+    public_gists bigint,
+    public_repos bigint,
+    total_private_repos bigint,
+    updated_at timestamp with time zone,
+    installation_id bigint
+);
+
+
+--
+-- Name: github_users_versioned; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.github_users_versioned (
+    sum256 character varying(64) NOT NULL,
+    versions integer[],
+    avatar_url text,
+    bio text,
+    company text,
+    created_at timestamp with time zone,
+    email text,
+    followers bigint,
+    following bigint,
+    hireable boolean,
+    htmlurl text,
+    id bigint,
+    location text,
+    login text,
+    name text,
+    node_id text,
+    organization_id bigint NOT NULL,
+    organization_login text NOT NULL,
+    owned_private_repos bigint,
+    private_gists bigint,
+    public_gists bigint,
+    public_repos bigint,
+    total_private_repos bigint,
+    updated_at timestamp with time zone,
+    installation_id bigint
+);
+
+
+--
+-- Name: github_users; Type: VIEW; Schema: public; Owner: -
+--
+
+CREATE VIEW public.github_users AS
+ SELECT github_users_versioned.avatar_url,
+    github_users_versioned.bio,
+    github_users_versioned.company,
+    github_users_versioned.created_at,
+    github_users_versioned.email,
+    github_users_versioned.followers,
+    github_users_versioned.following,
+    github_users_versioned.hireable,
+    github_users_versioned.htmlurl,
+    github_users_versioned.id,
+    github_users_versioned.location,
+    github_users_versioned.login,
+    github_users_versioned.name,
+    github_users_versioned.node_id,
+    github_users_versioned.organization_id,
+    github_users_versioned.organization_login,
+    github_users_versioned.owned_private_repos,
+    github_users_versioned.private_gists,
+    github_users_versioned.public_gists,
+    github_users_versioned.public_repos,
+    github_users_versioned.total_private_repos,
+    github_users_versioned.updated_at,
+    github_users_versioned.installation_id
+   FROM public.github_users_versioned;
+
+
+--
+-- Name: installations; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.installations (
+    id bigint NOT NULL,
+    app_id bigint NOT NULL,
+    account_id bigint NOT NULL,
+    account_login text NOT NULL,
+    account_type text NOT NULL,
+    target_type text NOT NULL,
+    repository_selection text NOT NULL,
+    permissions jsonb NOT NULL,
+    events text[] NOT NULL,
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+    suspended_at timestamp with time zone,
+    deleted_at timestamp with time zone,
+    purged_at timestamp with time zone
+);
+
+
+--
+-- Name: issue_changes; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.issue_changes (
+    sum256 character varying(64) NOT NULL,
+    action text NOT NULL,
+    assignee_id bigint,
+    assignee_login text,
+    changed_at timestamp with time zone,
+    issue_id bigint NOT NULL,
+    issue_number bigint NOT NULL,
+    label text,
+    milestone_id bigint,
+    milestone_title text,
+    pull_request boolean NOT NULL,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL,
+    sender_id bigint NOT NULL,
+    sender_login text NOT NULL,
+    user_id bigint NOT NULL,
+    user_login text NOT NULL,
+    installation_id bigint
+);
+
+
+--
+-- Name: issue_comments; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.issue_comments AS
+ SELECT c.repository_owner,
+    c.repository_name,
+    c.repository_fullname,
+    c.issue_number,
+    c.created_at,
+    c.body,
+    c.user_id,
+    c.user_login,
+    c.htmlurl AS html_url,
+    c.sum256,
+    c.installation_id
+   FROM (public.github_issue_comments_versioned c
+     JOIN public.github_issues_versioned i ON (((i.repository_owner = c.repository_owner) AND (i.repository_name = c.repository_name) AND (i.number = c.issue_number))))
+  WHERE ((c.deleted_at IS NULL) AND (i.deleted_at IS NULL))
+  WITH NO DATA;
+
+
+--
+-- Name: issues; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.issues AS
+ SELECT github_issues_versioned.repository_owner,
+    github_issues_versioned.repository_name,
+    github_issues_versioned.repository_fullname,
+    github_issues_versioned.number,
+    github_issues_versioned.state,
+    github_issues_versioned.title,
+    github_issues_versioned.body,
+    github_issues_versioned.created_at,
+    github_issues_versioned.closed_at,
+    github_issues_versioned.updated_at,
+    github_issues_versioned.comments,
+    github_issues_versioned.user_id,
+    github_issues_versioned.user_login,
+    github_issues_versioned.htmlurl AS html_url,
+    github_issues_versioned.labels,
+    github_issues_versioned.sum256,
+    github_issues_versioned.installation_id
+   FROM public.github_issues_versioned
+  WHERE (github_issues_versioned.deleted_at IS NULL)
+  WITH NO DATA;
+
+
+--
+-- Name: materialized_view_refreshes; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.materialized_view_refreshes (
+    name text NOT NULL,
+    refreshed_at timestamp with time zone NOT NULL,
+    duration interval NOT NULL
+);
+
+
+--
+-- Name: owners; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.owners AS
+ SELECT github_organizations_versioned.login,
+    github_organizations_versioned.name,
+    github_organizations_versioned.sum256,
+    github_organizations_versioned.installation_id
+   FROM public.github_organizations_versioned
+  WHERE (github_organizations_versioned.deleted_at IS NULL)
+  WITH NO DATA;
+
+
+--
+-- Name: pull_request_checks; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.pull_request_checks AS
+ SELECT p.repository_owner,
+    p.repository_name,
+    p.repository_fullname,
+    p.number AS pull_request_number,
+    p.sum256 AS pull_request_sum256,
+    c.kind,
+    c.head_sha,
+    c.name,
+    c.conclusion,
+    c.started_at,
+    c.completed_at,
+    c.app_name,
+    c.sum256,
+    p.installation_id
+   FROM (public.github_pull_requests_versioned p
+     JOIN ( SELECT 'check_run'::text AS kind,
+            r.repository_fullname,
+            r.head_sha,
+            r.name,
+            r.conclusion,
+            r.started_at,
+            r.completed_at,
+            r.app_name,
+            r.sum256
+           FROM public.github_check_runs_versioned r
+          WHERE (r.status = 'completed'::text)
+        UNION ALL
+         SELECT 'status'::text AS kind,
+            s.repository_fullname,
+            s.sha AS head_sha,
+            s.context AS name,
+            s.state AS conclusion,
+            COALESCE(( SELECT max(pending.created_at) AS max
+                   FROM public.github_statuses_versioned pending
+                  WHERE ((pending.repository_fullname = s.repository_fullname) AND (pending.sha = s.sha) AND (pending.context = s.context) AND (pending.state = 'pending'::text) AND (pending.created_at <= s.created_at))), s.created_at) AS started_at,
+            s.created_at AS completed_at,
+            NULL::text AS app_name,
+            s.sum256
+           FROM public.github_statuses_versioned s
+          WHERE (s.state <> 'pending'::text)) c ON (((c.repository_fullname = p.repository_fullname) AND ((c.head_sha = p.head_sha) OR (EXISTS ( SELECT 1
+           FROM public.github_pull_request_pushes pp
+          WHERE (((pp.pull_request_sum256)::text = (p.sum256)::text) AND (pp.after = c.head_sha))))))))
+  WITH NO DATA;
+
+
+--
+-- Name: pull_request_comments; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.pull_request_comments AS
+ SELECT c.repository_owner,
+    c.repository_name,
+    c.repository_fullname,
+    c.issue_number AS pull_request_number,
+    c.created_at,
+    c.body,
+    c.user_id,
+    c.user_login,
+    c.htmlurl AS html_url,
+    c.sum256,
+    c.installation_id
+   FROM (public.github_issue_comments_versioned c
+     JOIN public.github_pull_requests_versioned p ON (((p.repository_owner = c.repository_owner) AND (p.repository_name = c.repository_name) AND (p.number = c.issue_number))))
+  WHERE (c.deleted_at IS NULL)
+UNION
+ SELECT c.repository_owner,
+    c.repository_name,
+    c.repository_fullname,
+    c.pull_request_number,
+    c.created_at,
+    c.body,
+    c.user_id,
+    c.user_login,
+    c.htmlurl AS html_url,
+    c.sum256,
+    c.installation_id
+   FROM public.github_pull_request_comments_versioned c
+  WHERE (c.deleted_at IS NULL)
+UNION
+ SELECT c.repository_owner,
+    c.repository_name,
+    c.repository_fullname,
+    c.pull_request_number,
+    c.submitted_at AS created_at,
+    c.body,
+    c.user_id,
+    c.user_login,
+    c.htmlurl AS html_url,
+    c.sum256,
+    c.installation_id
+   FROM public.github_pull_request_reviews_versioned c
+  WHERE (c.body <> ''::text)
+  WITH NO DATA;
+
+
+--
+-- Name: pull_request_deployments; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.pull_request_deployments AS
+ SELECT DISTINCT ON (p.sum256, d.environment) p.repository_owner,
+    p.repository_name,
+    p.repository_fullname,
+    p.number,
+    p.merged_at,
+    d.id AS deployment_id,
+    d.environment,
+    s.created_at AS deployed_at,
+    p.sum256,
+    p.installation_id
+   FROM ((public.github_pull_requests_versioned p
+     JOIN public.github_deployments_versioned d ON (((d.repository_fullname = p.repository_fullname) AND ((d.sha = p.merge_commit_sha) OR (d.ref = p.base_ref)) AND (d.created_at >= p.merged_at))))
+     JOIN public.github_deployment_statuses_versioned s ON (((s.repository_fullname = d.repository_fullname) AND (s.deployment_id = d.id) AND (s.state = 'success'::text))))
+  WHERE p.merged
+  ORDER BY p.sum256, d.environment, s.created_at
+  WITH NO DATA;
+
+
+--
+-- Name: pull_request_releases; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.pull_request_releases AS
+ SELECT DISTINCT ON (p.sum256) p.repository_owner,
+    p.repository_name,
+    p.repository_fullname,
+    p.number,
+    p.merged_at,
+    r.id AS release_id,
+    r.tag_name AS release_tag_name,
+    r.name AS release_name,
+    r.published_at AS release_published_at,
+    p.sum256,
+    p.installation_id
+   FROM (public.github_pull_requests_versioned p
+     JOIN public.github_releases_versioned r ON (((r.repository_fullname = p.repository_fullname) AND (r.target_commitish = p.base_ref) AND (r.published_at >= p.merged_at))))
+  WHERE (p.merged AND (r.deleted_at IS NULL) AND (NOT r.draft) AND (NOT r.prerelease))
+  ORDER BY p.sum256, r.published_at
+  WITH NO DATA;
+
+
+--
+-- Name: pull_request_reviews; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.pull_request_reviews AS
+ SELECT github_pull_request_reviews_versioned.repository_owner,
+    github_pull_request_reviews_versioned.repository_name,
+    github_pull_request_reviews_versioned.repository_fullname,
+    github_pull_request_reviews_versioned.pull_request_number,
+    github_pull_request_reviews_versioned.submitted_at AS created_at,
+    github_pull_request_reviews_versioned.user_id,
+    github_pull_request_reviews_versioned.user_login,
+    github_pull_request_reviews_versioned.htmlurl AS html_url,
+        CASE
+            WHEN (github_pull_request_reviews_versioned.state = 'CHANGES_REQUESTED'::text) THEN 'COMMENTED'::text
+            ELSE github_pull_request_reviews_versioned.state
+        END AS state,
+    github_pull_request_reviews_versioned.sum256,
+    github_pull_request_reviews_versioned.installation_id
+   FROM public.github_pull_request_reviews_versioned
+  WITH NO DATA;
+
+
+--
+-- Name: pull_request_state_transitions; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.pull_request_state_transitions (
+    sum256 character varying(64) NOT NULL,
+    action text NOT NULL,
+    changed_at timestamp with time zone,
+    pull_request_id bigint NOT NULL,
+    pull_request_number bigint NOT NULL,
+    repository_name text NOT NULL,
+    repository_owner text NOT NULL,
+    repository_fullname text NOT NULL,
+    sender_id bigint NOT NULL,
+    sender_login text NOT NULL,
+    state text NOT NULL,
+    installation_id bigint
+);
+
+
+--
+-- Name: pull_requests; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.pull_requests AS
+ SELECT github_pull_requests_versioned.repository_owner,
+    github_pull_requests_versioned.repository_name,
+    github_pull_requests_versioned.repository_fullname,
+    github_pull_requests_versioned.number,
+    github_pull_requests_versioned.state,
+    github_pull_requests_versioned.title,
+    github_pull_requests_versioned.body,
+    github_pull_requests_versioned.created_at,
+    github_pull_requests_versioned.closed_at,
+    github_pull_requests_versioned.merged_at,
+    github_pull_requests_versioned.updated_at,
+    github_pull_requests_versioned.commits,
+    github_pull_requests_versioned.comments,
+    github_pull_requests_versioned.changed_files,
+    github_pull_requests_versioned.additions,
+    github_pull_requests_versioned.deletions,
+    github_pull_requests_versioned.review_comments AS reviews,
+    github_pull_requests_versioned.user_id,
+    github_pull_requests_versioned.user_login,
+    github_pull_requests_versioned.base_repository_name,
+    github_pull_requests_versioned.base_repository_owner,
+    github_pull_requests_versioned.base_repository_fullname,
+    github_pull_requests_versioned.head_ref,
+    github_pull_requests_versioned.head_sha,
+    github_pull_requests_versioned.merge_commit_sha,
+    github_pull_requests_versioned.htmlurl AS html_url,
+    github_pull_requests_versioned.labels,
+    github_pull_requests_versioned.sum256,
+    github_pull_requests_versioned.installation_id
+   FROM public.github_pull_requests_versioned
+  WITH NO DATA;
+
+
+--
+-- Name: releases; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.releases AS
+ SELECT github_releases_versioned.repository_owner,
+    github_releases_versioned.repository_name,
+    github_releases_versioned.repository_fullname,
+    github_releases_versioned.id,
+    github_releases_versioned.tag_name,
+    github_releases_versioned.name,
+    github_releases_versioned.target_commitish,
+    github_releases_versioned.prerelease,
+    github_releases_versioned.created_at,
+    github_releases_versioned.published_at,
+    github_releases_versioned.author_id,
+    github_releases_versioned.author_login,
+    github_releases_versioned.htmlurl AS html_url,
+    github_releases_versioned.sum256,
+    github_releases_versioned.installation_id
+   FROM public.github_releases_versioned
+  WHERE ((github_releases_versioned.deleted_at IS NULL) AND (NOT github_releases_versioned.draft))
+  WITH NO DATA;
+
+
+--
+-- Name: removed_repositories; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.removed_repositories (
+    installation_id bigint NOT NULL,
+    repository_id bigint NOT NULL,
+    repository_fullname text NOT NULL,
+    removed_at timestamp with time zone NOT NULL,
+    purged_at timestamp with time zone
+);
+
+
+--
+-- Name: repositories; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.repositories AS
+ SELECT github_repositories_versioned.owner_login AS owner,
+    github_repositories_versioned.name,
+    github_repositories_versioned.fullname,
+    github_repositories_versioned.private,
+    github_repositories_versioned.description,
+    github_repositories_versioned.sum256,
+    github_repositories_versioned.installation_id
+   FROM public.github_repositories_versioned
+  WHERE (github_repositories_versioned.deleted_at IS NULL)
+  WITH NO DATA;
+
+
+--
+-- Name: reconcile_checkpoints; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.reconcile_checkpoints (
+    repository_fullname text NOT NULL,
+    since timestamp with time zone NOT NULL,
+    reconciled_at timestamp with time zone NOT NULL,
+    repaired integer NOT NULL
+);
+
+
+--
+-- Name: schema_migrations; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.schema_migrations (
+    version bigint NOT NULL,
+    dirty boolean NOT NULL
+);
+
+
+--
+-- Name: users; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+--
+
+CREATE MATERIALIZED VIEW public.users AS
+ SELECT github_users_versioned.login,
+    github_users_versioned.name,
+    github_users_versioned.sum256,
+    github_users_versioned.installation_id
+   FROM public.github_users_versioned
+  WITH NO DATA;
+
+
+--
+-- Name: backfill_checkpoints backfill_checkpoints_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.backfill_checkpoints
+    ADD CONSTRAINT backfill_checkpoints_pkey PRIMARY KEY (repository_fullname, stage);
+
+
+--
+-- Name: github_check_runs_versioned check_runs_versioned_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.github_check_runs_versioned
+    ADD CONSTRAINT check_runs_versioned_pkey PRIMARY KEY (sum256);
+
+
+--
+-- Name: github_check_suites_versioned check_suites_versioned_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.github_check_suites_versioned
+    ADD CONSTRAINT check_suites_versioned_pkey PRIMARY KEY (sum256);
+
+
+--
+-- Name: github_commits_versioned commits_versioned_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.github_commits_versioned
+    ADD CONSTRAINT commits_versioned_pkey PRIMARY KEY (sum256);
+
+
+--
+-- Name: github_deliveries deliveries_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.github_deliveries
+    ADD CONSTRAINT deliveries_pkey PRIMARY KEY (delivery_id);
+
+
+--
+-- Name: github_deployment_statuses_versioned deployment_statuses_versioned_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.github_deployment_statuses_versioned
+    ADD CONSTRAINT deployment_statuses_versioned_pkey PRIMARY KEY (sum256);
+
+
+--
+-- Name: github_deployments_versioned deployments_versioned_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.github_deployments_versioned
+    ADD CONSTRAINT deployments_versioned_pkey PRIMARY KEY (sum256);
+
+
+--
+-- Name: failed_events failed_events_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.failed_events
+    ADD CONSTRAINT failed_events_pkey PRIMARY KEY (delivery_id);
+
+
+--
+-- Name: installations installations_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.installations
+    ADD CONSTRAINT installations_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: issue_changes issue_changes_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.issue_changes
+    ADD CONSTRAINT issue_changes_pkey PRIMARY KEY (sum256);
+
+
+--
+-- Name: github_issue_comments_versioned issue_comments_versioned_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.github_issue_comments_versioned
+    ADD CONSTRAINT issue_comments_versioned_pkey PRIMARY KEY (sum256);
+
+
+--
+-- Name: github_issues_versioned issues_versioned_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.github_issues_versioned
+    ADD CONSTRAINT issues_versioned_pkey PRIMARY KEY (sum256);
+
+
+--
+-- Name: github_labels_versioned labels_versioned_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.github_labels_versioned
+    ADD CONSTRAINT labels_versioned_pkey PRIMARY KEY (sum256);
+
+
+--
+-- Name: materialized_view_refreshes materialized_view_refreshes_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.materialized_view_refreshes
+    ADD CONSTRAINT materialized_view_refreshes_pkey PRIMARY KEY (name);
+
+
+--
+-- Name: github_milestones_versioned milestones_versioned_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.github_milestones_versioned
+    ADD CONSTRAINT milestones_versioned_pkey PRIMARY KEY (sum256);
+
+
+--
+-- Name: github_organizations_versioned organizations_versioned_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.github_organizations_versioned
+    ADD CONSTRAINT organizations_versioned_pkey PRIMARY KEY (sum256);
+
+
+--
+-- Name: github_pull_request_comments_versioned pull_request_comments_versioned_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.github_pull_request_comments_versioned
+    ADD CONSTRAINT pull_request_comments_versioned_pkey PRIMARY KEY (sum256);
+
+
+--
+-- Name: github_pull_request_pushes pull_request_pushes_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.github_pull_request_pushes
+    ADD CONSTRAINT pull_request_pushes_pkey PRIMARY KEY (sum256);
+
+
+--
+-- Name: github_pull_request_reviews_versioned pull_request_reviews_versioned_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.github_pull_request_reviews_versioned
+    ADD CONSTRAINT pull_request_reviews_versioned_pkey PRIMARY KEY (sum256);
+
+
+--
+-- Name: pull_request_state_transitions pull_request_state_transitions_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.pull_request_state_transitions
+    ADD CONSTRAINT pull_request_state_transitions_pkey PRIMARY KEY (sum256);
+
+
+--
+-- Name: github_pull_requests_versioned pull_requests_versioned_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.github_pull_requests_versioned
+    ADD CONSTRAINT pull_requests_versioned_pkey PRIMARY KEY (sum256);
+
+
+--
+-- Name: reconcile_checkpoints reconcile_checkpoints_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.reconcile_checkpoints
+    ADD CONSTRAINT reconcile_checkpoints_pkey PRIMARY KEY (repository_fullname);
+
+
+--
+-- Name: github_refs_versioned refs_versioned_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.github_refs_versioned
+    ADD CONSTRAINT refs_versioned_pkey PRIMARY KEY (sum256);
+
+
+--
+-- Name: github_releases_versioned releases_versioned_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.github_releases_versioned
+    ADD CONSTRAINT releases_versioned_pkey PRIMARY KEY (sum256);
+
+
+--
+-- Name: removed_repositories removed_repositories_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.removed_repositories
+    ADD CONSTRAINT removed_repositories_pkey PRIMARY KEY (installation_id, repository_id);
+
+
+--
+-- Name: github_repositories_versioned repositories_versioned_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.github_repositories_versioned
+    ADD CONSTRAINT repositories_versioned_pkey PRIMARY KEY (sum256);
+
+
+--
+-- Name: schema_migrations schema_migrations_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.schema_migrations
+    ADD CONSTRAINT schema_migrations_pkey PRIMARY KEY (version);
+
+
+--
+-- Name: github_statuses_versioned statuses_versioned_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.github_statuses_versioned
+    ADD CONSTRAINT statuses_versioned_pkey PRIMARY KEY (sum256);
+
+
+--
+-- Name: github_users_versioned users_versioned_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.github_users_versioned
+    ADD CONSTRAINT users_versioned_pkey PRIMARY KEY (sum256);
+
+
+--
+-- Name: check_runs_history_sum256_version; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX check_runs_history_sum256_version ON public.github_check_runs_history USING btree (sum256, version);
+
+
+--
+-- Name: check_runs_versions; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX check_runs_versions ON public.github_check_runs_versioned USING btree (versions);
+
+
+--
+-- Name: check_suites_history_sum256_version; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX check_suites_history_sum256_version ON public.github_check_suites_history USING btree (sum256, version);
+
+
+--
+-- Name: check_suites_versions; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX check_suites_versions ON public.github_check_suites_versioned USING btree (versions);
+
+
+--
+-- Name: commits_history_sum256_version; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX commits_history_sum256_version ON public.github_commits_history USING btree (sum256, version);
+
+
+--
+-- Name: commits_versions; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX commits_versions ON public.github_commits_versioned USING btree (versions);
+
+
+--
+-- Name: deliveries_processed_at; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX deliveries_processed_at ON public.github_deliveries USING btree (processed_at);
+
+
+--
+-- Name: deployment_statuses_history_sum256_version; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX deployment_statuses_history_sum256_version ON public.github_deployment_statuses_history USING btree (sum256, version);
+
+
+--
+-- Name: deployment_statuses_versions; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX deployment_statuses_versions ON public.github_deployment_statuses_versioned USING btree (versions);
+
+
+--
+-- Name: deployments_history_sum256_version; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX deployments_history_sum256_version ON public.github_deployments_history USING btree (sum256, version);
+
+
+--
+-- Name: deployments_sum256; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX deployments_sum256 ON public.deployments USING btree (sum256);
+
+
+--
+-- Name: deployments_versions; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX deployments_versions ON public.github_deployments_versioned USING btree (versions);
+
+
+--
+-- Name: failed_events_parked_at; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX failed_events_parked_at ON public.failed_events USING btree (parked_at);
+
+
+--
+-- Name: issue_changes_issue; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX issue_changes_issue ON public.issue_changes USING btree (repository_fullname, issue_number);
+
+
+--
+-- Name: issue_comments_history_sum256_version; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX issue_comments_history_sum256_version ON public.github_issue_comments_history USING btree (sum256, version);
+
+
+--
+-- Name: issue_comments_sum256; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX issue_comments_sum256 ON public.issue_comments USING btree (sum256);
+
+
+--
+-- Name: issue_comments_versions; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX issue_comments_versions ON public.github_issue_comments_versioned USING btree (versions);
+
+
+--
+-- Name: issues_history_sum256_version; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX issues_history_sum256_version ON public.github_issues_history USING btree (sum256, version);
+
+
+--
+-- Name: issues_sum256; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX issues_sum256 ON public.issues USING btree (sum256);
+
+
+--
+-- Name: issues_versions; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX issues_versions ON public.github_issues_versioned USING btree (versions);
+
+
+--
+-- Name: labels_history_sum256_version; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX labels_history_sum256_version ON public.github_labels_history USING btree (sum256, version);
+
+
+--
+-- Name: labels_versions; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX labels_versions ON public.github_labels_versioned USING btree (versions);
 
 
 --
--- Name: github_users_versioned; Type: TABLE; Schema: public; Owner: -
+-- Name: milestones_history_sum256_version; Type: INDEX; Schema: public; Owner: -
 --
 
-CREATE TABLE public.github_users_versioned (
-    sum256 character varying(64) NOT NULL,
-    versions integer[],
-    avatar_url text,
-    bio text,
-    company text,
-    created_at timestamp with time zone,
-    email text,
-    followers bigint,
-    following bigint,
-    hireable boolean,
-    htmlurl text,
-    id bigint,
-    location text,
-    login text,
-    name text,
-    node_id text,
-    organization_id bigint NOT NULL,
-    organization_login text NOT NULL,
-    owned_private_repos bigint,
-    private_gists bigint,
-    public_gists bigint,
-    public_repos bigint,
-    total_private_repos bigint,
-    updated_at timestamp with time zone
-);
+CREATE INDEX milestones_history_sum256_version ON public.github_milestones_history USING btree (sum256, version);
 
 
 --
--- Name: github_users; Type: VIEW; Schema: public; Owner: -
+-- Name: milestones_versions; Type: INDEX; Schema: public; Owner: -
 --
 
-CREATE VIEW public.github_users AS
- SELECT github_users_versioned.avatar_url,
-    github_users_versioned.bio,
-    github_users_versioned.company,
-    github_users_versioned.created_at,
-    github_users_versioned.email,
-    github_users_versioned.followers,
-    github_users_versioned.following,
-    github_users_versioned.hireable,
-    github_users_versioned.htmlurl,
-    github_users_versioned.id,
-    github_users_versioned.location,
-    github_users_versioned.login,
-    github_users_versioned.name,
-    github_users_versioned.node_id,
-    github_users_versioned.organization_id,
-    github_users_versioned.organization_login,
-    github_users_versioned.owned_private_repos,
-    github_users_versioned.private_gists,
-    github_users_versioned.public_gists,
-    github_users_versioned.public_repos,
-    github_users_versioned.total_private_repos,
-    github_users_versioned.updated_at
-   FROM public.github_users_versioned;
+CREATE INDEX milestones_versions ON public.github_milestones_versioned USING btree (versions);
 
 
 --
--- Name: issue_comments; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+-- Name: organizations_history_sum256_version; Type: INDEX; Schema: public; Owner: -
 --
 
-CREATE MATERIALIZED VIEW public.issue_comments AS
- SELECT c.repository_owner,
-    c.repository_name,
-    c.repository_fullname,
-    c.issue_number,
-    c.created_at,
-    c.body,
-    c.user_id,
-    c.user_login,
-    c.htmlurl AS html_url
-   FROM (public.github_issue_comments_versioned c
-     JOIN public.github_issues_versioned i ON (((i.repository_owner = c.repository_owner) AND (i.repository_name = c.repository_name) AND (i.number = c.issue_number))))
-  WITH NO DATA;
+CREATE INDEX organizations_history_sum256_version ON public.github_organizations_history USING btree (sum256, version);
 
 
 --
--- Name: issues; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+-- Name: organizations_versions; Type: INDEX; Schema: public; Owner: -
 --
 
-CREATE MATERIALIZED VIEW public.issues AS
- SELECT github_issues_versioned.repository_owner,
-    github_issues_versioned.repository_name,
-    github_issues_versioned.repository_fullname,
-    github_issues_versioned.number,
-    github_issues_versioned.state,
-    github_issues_versioned.title,
-    github_issues_versioned.body,
-    github_issues_versioned.created_at,
-    github_issues_versioned.closed_at,
-    github_issues_versioned.updated_at,
-    github_issues_versioned.comments,
-    github_issues_versioned.user_id,
-    github_issues_versioned.user_login,
-    github_issues_versioned.htmlurl AS html_url,
-    github_issues_versioned.labels
-   FROM public.github_issues_versioned
-  WITH NO DATA;
+CREATE INDEX organizations_versions ON public.github_organizations_versioned USING btree (versions);
 
 
 --
--- Name: owners; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+-- Name: owners_sum256; Type: INDEX; Schema: public; Owner: -
 --
 
-CREATE MATERIALIZED VIEW public.owners AS
- SELECT github_organizations_versioned.login,
-    github_organizations_versioned.name
-   FROM public.github_organizations_versioned
-  WITH NO DATA;
+CREATE UNIQUE INDEX owners_sum256 ON public.owners USING btree (sum256);
 
 
 --
--- Name: pull_request_comments; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+-- Name: pull_request_checks_sum256; Type: INDEX; Schema: public; Owner: -
 --
 
-CREATE MATERIALIZED VIEW public.pull_request_comments AS
- SELECT c.repository_owner,
-    c.repository_name,
-    c.repository_fullname,
-    c.issue_number AS pull_request_number,
-    c.created_at,
-    c.body,
-    c.user_id,
-    c.user_login,
-    c.htmlurl AS html_url
-   FROM (public.github_issue_comments_versioned c
-     JOIN public.github_pull_requests_versioned p ON (((p.repository_owner = c.repository_owner) AND (p.repository_name = c.repository_name) AND (p.number = c.issue_number))))
-UNION
- SELECT c.repository_owner,
-    c.repository_name,
-    c.repository_fullname,
-    c.pull_request_number,
-    c.created_at,
-    c.body,
-    c.user_id,
-    c.user_login,
-    c.htmlurl AS html_url
-   FROM public.github_pull_request_comments_versioned c
-UNION
- SELECT c.repository_owner,
-    c.repository_name,
-    c.repository_fullname,
-    c.pull_request_number,
-    c.submitted_at AS created_at,
-    c.body,
-    c.user_id,
-    c.user_login,
-    c.htmlurl AS html_url
-   FROM public.github_pull_request_reviews_versioned c
-  WHERE (c.body <> ''::text)
-  WITH NO DATA;
+CREATE UNIQUE INDEX pull_request_checks_sum256 ON public.pull_request_checks USING btree (pull_request_sum256, kind, sum256);
 
 
 --
--- Name: pull_request_reviews; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+-- Name: pull_request_comments_history_sum256_version; Type: INDEX; Schema: public; Owner: -
 --
 
-CREATE MATERIALIZED VIEW public.pull_request_reviews AS
- SELECT github_pull_request_reviews_versioned.repository_owner,
-    github_pull_request_reviews_versioned.repository_name,
-    github_pull_request_reviews_versioned.repository_fullname,
-    github_pull_request_reviews_versioned.pull_request_number,
-    github_pull_request_reviews_versioned.submitted_at AS created_at,
-    github_pull_request_reviews_versioned.user_id,
-    github_pull_request_reviews_versioned.user_login,
-    github_pull_request_reviews_versioned.htmlurl AS html_url,
-        CASE
-            WHEN (github_pull_request_reviews_versioned.state = 'CHANGES_REQUESTED'::text) THEN 'COMMENTED'::text
-            ELSE github_pull_request_reviews_versioned.state
-        END AS state
-   FROM public.github_pull_request_reviews_versioned
-  WITH NO DATA;
+CREATE INDEX pull_request_comments_history_sum256_version ON public.github_pull_request_comments_history USING btree (sum256, version);
 
 
 --
--- Name: pull_requests; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+-- Name: pull_request_comments_sum256; Type: INDEX; Schema: public; Owner: -
 --
 
-CREATE MATERIALIZED VIEW public.pull_requests AS
- SELECT github_pull_requests_versioned.repository_owner,
-    github_pull_requests_versioned.repository_name,
-    github_pull_requests_versioned.repository_fullname,
-    github_pull_requests_versioned.number,
-    github_pull_requests_versioned.state,
-    github_pull_requests_versioned.title,
-    github_pull_requests_versioned.body,
-    github_pull_requests_versioned.created_at,
-    github_pull_requests_versioned.closed_at,
-    github_pull_requests_versioned.merged_at,
-    github_pull_requests_versioned.updated_at,
-    github_pull_requests_versioned.commits,
-    github_pull_requests_versioned.comments,
-    github_pull_requests_versioned.changed_files,
-    github_pull_requests_versioned.additions,
-    github_pull_requests_versioned.deletions,
-    github_pull_requests_versioned.review_comments AS reviews,
-    github_pull_requests_versioned.user_id,
-    github_pull_requests_versioned.user_login,
-    github_pull_requests_versioned.base_repository_name,
-    github_pull_requests_versioned.base_repository_owner,
-    github_pull_requests_versioned.base_repository_fullname,
-    github_pull_requests_versioned.head_ref,
-    github_pull_requests_versioned.head_sha,
-    github_pull_requests_versioned.merge_commit_sha,
-    github_pull_requests_versioned.htmlurl AS html_url,
-    github_pull_requests_versioned.labels
-   FROM public.github_pull_requests_versioned
-  WITH NO DATA;
+CREATE UNIQUE INDEX pull_request_comments_sum256 ON public.pull_request_comments USING btree (sum256);
 
 
 --
--- Name: repositories; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+-- Name: pull_request_comments_versions; Type: INDEX; Schema: public; Owner: -
 --
 
-CREATE MATERIALIZED VIEW public.repositories AS
- SELECT github_repositories_versioned.owner_login AS owner,
-    github_repositories_versioned.name,
-    github_repositories_versioned.fullname,
-    github_repositories_versioned.private,
-    github_repositories_versioned.description
-   FROM public.github_repositories_versioned
-  WITH NO DATA;
+CREATE INDEX pull_request_comments_versions ON public.github_pull_request_comments_versioned USING btree (versions);
 
 
 --
--- Name: schema_migrations; Type: TABLE; Schema: public; Owner: -
+-- Name: pull_request_deployments_sum256; Type: INDEX; Schema: public; Owner: -
 --
 
-CREATE TABLE public.schema_migrations (
-    version bigint NOT NULL,
-    dirty boolean NOT NULL
-);
+CREATE UNIQUE INDEX pull_request_deployments_sum256 ON public.pull_request_deployments USING btree (sum256, environment);
 
 
 --
--- Name: users; Type: MATERIALIZED VIEW; Schema: public; Owner: -
+-- Name: pull_request_pushes_pull_request; Type: INDEX; Schema: public; Owner: -
 --
 
-CREATE MATERIALIZED VIEW public.users AS
- SELECT github_users_versioned.login,
-    github_users_versioned.name
-   FROM public.github_users_versioned
-  WITH NO DATA;
+CREATE INDEX pull_request_pushes_pull_request ON public.github_pull_request_pushes USING btree (pull_request_sum256);
 
 
 --
--- Name: github_issue_comments_versioned issue_comments_versioned_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+-- Name: pull_request_releases_sum256; Type: INDEX; Schema: public; Owner: -
 --
 
-ALTER TABLE ONLY public.github_issue_comments_versioned
-    ADD CONSTRAINT issue_comments_versioned_pkey PRIMARY KEY (sum256);
+CREATE UNIQUE INDEX pull_request_releases_sum256 ON public.pull_request_releases USING btree (sum256);
 
 
 --
--- Name: github_issues_versioned issues_versioned_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+-- Name: pull_request_reviews_history_sum256_version; Type: INDEX; Schema: public; Owner: -
 --
 
-ALTER TABLE ONLY public.github_issues_versioned
-    ADD CONSTRAINT issues_versioned_pkey PRIMARY KEY (sum256);
+CREATE INDEX pull_request_reviews_history_sum256_version ON public.github_pull_request_reviews_history USING btree (sum256, version);
 
 
 --
--- Name: github_organizations_versioned organizations_versioned_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+-- Name: pull_request_reviews_sum256; Type: INDEX; Schema: public; Owner: -
 --
 
-ALTER TABLE ONLY public.github_organizations_versioned
-    ADD CONSTRAINT organizations_versioned_pkey PRIMARY KEY (sum256);
+CREATE UNIQUE INDEX pull_request_reviews_sum256 ON public.pull_request_reviews USING btree (sum256);
 
 
 --
--- Name: github_pull_request_comments_versioned pull_request_comments_versioned_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+-- Name: pull_request_reviews_versions; Type: INDEX; Schema: public; Owner: -
 --
 
-ALTER TABLE ONLY public.github_pull_request_comments_versioned
-    ADD CONSTRAINT pull_request_comments_versioned_pkey PRIMARY KEY (sum256);
+CREATE INDEX pull_request_reviews_versions ON public.github_pull_request_reviews_versioned USING btree (versions);
 
 
 --
--- Name: github_pull_request_reviews_versioned pull_request_reviews_versioned_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+-- Name: pull_request_state_transitions_pull_request; Type: INDEX; Schema: public; Owner: -
 --
 
-ALTER TABLE ONLY public.github_pull_request_reviews_versioned
-    ADD CONSTRAINT pull_request_reviews_versioned_pkey PRIMARY KEY (sum256);
+CREATE INDEX pull_request_state_transitions_pull_request ON public.pull_request_state_transitions USING btree (repository_fullname, pull_request_number);
 
 
 --
--- Name: github_pull_requests_versioned pull_requests_versioned_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+-- Name: pull_requests_history_sum256_version; Type: INDEX; Schema: public; Owner: -
 --
 
-ALTER TABLE ONLY public.github_pull_requests_versioned
-    ADD CONSTRAINT pull_requests_versioned_pkey PRIMARY KEY (sum256);
+CREATE INDEX pull_requests_history_sum256_version ON public.github_pull_requests_history USING btree (sum256, version);
 
 
 --
--- Name: github_repositories_versioned repositories_versioned_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+-- Name: pull_requests_sum256; Type: INDEX; Schema: public; Owner: -
 --
 
-ALTER TABLE ONLY public.github_repositories_versioned
-    ADD CONSTRAINT repositories_versioned_pkey PRIMARY KEY (sum256);
+CREATE UNIQUE INDEX pull_requests_sum256 ON public.pull_requests USING btree (sum256);
 
 
 --
--- Name: schema_migrations schema_migrations_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+-- Name: pull_requests_versions; Type: INDEX; Schema: public; Owner: -
 --
 
-ALTER TABLE ONLY public.schema_migrations
-    ADD CONSTRAINT schema_migrations_pkey PRIMARY KEY (version);
+CREATE INDEX pull_requests_versions ON public.github_pull_requests_versioned USING btree (versions);
 
 
 --
--- Name: github_users_versioned users_versioned_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+-- Name: refs_history_sum256_version; Type: INDEX; Schema: public; Owner: -
 --
 
-ALTER TABLE ONLY public.github_users_versioned
-    ADD CONSTRAINT users_versioned_pkey PRIMARY KEY (sum256);
+CREATE INDEX refs_history_sum256_version ON public.github_refs_history USING btree (sum256, version);
 
 
 --
--- Name: issue_comments_versions; Type: INDEX; Schema: public; Owner: -
+-- Name: refs_versions; Type: INDEX; Schema: public; Owner: -
 --
 
-CREATE INDEX issue_comments_versions ON public.github_issue_comments_versioned USING btree (versions);
+CREATE INDEX refs_versions ON public.github_refs_versioned USING btree (versions);
 
 
 --
--- Name: issues_versions; Type: INDEX; Schema: public; Owner: -
+-- Name: releases_history_sum256_version; Type: INDEX; Schema: public; Owner: -
 --
 
-CREATE INDEX issues_versions ON public.github_issues_versioned USING btree (versions);
+CREATE INDEX releases_history_sum256_version ON public.github_releases_history USING btree (sum256, version);
 
 
 --
--- Name: organizations_versions; Type: INDEX; Schema: public; Owner: -
+-- Name: releases_sum256; Type: INDEX; Schema: public; Owner: -
 --
 
-CREATE INDEX organizations_versions ON public.github_organizations_versioned USING btree (versions);
+CREATE UNIQUE INDEX releases_sum256 ON public.releases USING btree (sum256);
 
 
 --
--- Name: pull_request_comments_versions; Type: INDEX; Schema: public; Owner: -
+-- Name: releases_versions; Type: INDEX; Schema: public; Owner: -
 --
 
-CREATE INDEX pull_request_comments_versions ON public.github_pull_request_comments_versioned USING btree (versions);
+CREATE INDEX releases_versions ON public.github_releases_versioned USING btree (versions);
 
 
 --
--- Name: pull_request_reviews_versions; Type: INDEX; Schema: public; Owner: -
+-- Name: repositories_history_sum256_version; Type: INDEX; Schema: public; Owner: -
 --
 
-CREATE INDEX pull_request_reviews_versions ON public.github_pull_request_reviews_versioned USING btree (versions);
+CREATE INDEX repositories_history_sum256_version ON public.github_repositories_history USING btree (sum256, version);
 
 
 --
--- Name: pull_requests_versions; Type: INDEX; Schema: public; Owner: -
+-- Name: repositories_sum256; Type: INDEX; Schema: public; Owner: -
 --
 
-CREATE INDEX pull_requests_versions ON public.github_pull_requests_versioned USING btree (versions);
+CREATE UNIQUE INDEX repositories_sum256 ON public.repositories USING btree (sum256);
 
 
 --
@@ -832,6 +2716,41 @@ CREATE INDEX pull_requests_versions ON public.github_pull_requests_versioned USI
 CREATE INDEX repositories_versions ON public.github_repositories_versioned USING btree (versions);
 
 
+--
+-- Name: skipped_versions_sum256; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX skipped_versions_sum256 ON public.github_skipped_versions USING btree (table_name, sum256);
+
+
+--
+-- Name: statuses_history_sum256_version; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX statuses_history_sum256_version ON public.github_statuses_history USING btree (sum256, version);
+
+
+--
+-- Name: statuses_versions; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX statuses_versions ON public.github_statuses_versioned USING btree (versions);
+
+
+--
+-- Name: users_history_sum256_version; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX users_history_sum256_version ON public.github_users_history USING btree (sum256, version);
+
+
+--
+-- Name: users_sum256; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX users_sum256 ON public.users USING btree (sum256);
+
+
 --
 -- Name: users_versions; Type: INDEX; Schema: public; Owner: -
 --